@@ -15,6 +15,33 @@ type ServiceContextImpl struct {
 	config map[ifctx.ConfigType]interface{}
 }
 
+// NewServiceContext creates a new `ServiceContextImpl` that is backed by _backing_.
+//
+// If _backing_ is `nil`, `context.Background()` is used. The _config_ is optional
+// and may be `nil`.
+//
+// .Creating a context with AWS configuration
+// [source,go]
+// ----
+// c := NewServiceContext(context.Background(), map[ifctx.ConfigType]interface{}{ifctx.ConfigAWS: &cfg})
+// ----
+func NewServiceContext(
+	backing context.Context,
+	config map[ifctx.ConfigType]interface{},
+) *ServiceContextImpl {
+
+	if backing == nil {
+		backing = context.Background()
+	}
+
+	if config == nil {
+		config = map[ifctx.ConfigType]interface{}{}
+	}
+
+	return &ServiceContextImpl{backing: backing, config: config}
+
+}
+
 func (c *ServiceContextImpl) Config(t ifctx.ConfigType) (config interface{}, ok bool) {
 	config, ok = c.config[t]
 	return
@@ -0,0 +1,101 @@
+package ifcert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// ErrInvalidCertificateRequest is returned when a certificate request is malformed, its
+// signature do not verify or it may not be issued by the `CertificateAuthority`.
+var ErrInvalidCertificateRequest = errors.New("invalid certificate request")
+
+// CertificateSpec specifies the content of a certificate, or certificate request, to create.
+//
+// When issuing from a certificate request, the _Subject_ and the _SANs_ in the spec replaces
+// those in the request if set. Hence the `CertificateAuthority` decides what is certified and
+// not the requester.
+type CertificateSpec struct {
+	// Subject is the distinguished name of the certificate.
+	Subject pkix.Name
+	// DNSNames are the _DNS_ subject alternative names.
+	DNSNames []string
+	// IPAddresses are the _IP_ subject alternative names.
+	IPAddresses []net.IP
+	// EmailAddresses are the _RFC 822_ subject alternative names.
+	EmailAddresses []string
+	// URIs are the _URI_ subject alternative names, e.g. a _SPIFFE_ id.
+	URIs []*url.URL
+	// KeyUsage is the key usage of the certificate.
+	//
+	// If zero, a certificate authority gets `x509.KeyUsageCertSign` and `x509.KeyUsageCRLSign`
+	// and all other certificates gets `x509.KeyUsageDigitalSignature` (and `x509.KeyUsageKeyEncipherment`
+	// for _RSA_ keys).
+	KeyUsage x509.KeyUsage
+	// ExtKeyUsage is the extended key usage of the certificate.
+	//
+	// If empty, a certificate that is not a certificate authority gets both `x509.ExtKeyUsageServerAuth`
+	// and `x509.ExtKeyUsageClientAuth` to be used in _mTLS_.
+	ExtKeyUsage []x509.ExtKeyUsage
+	// NotBefore is when the certificate becomes valid. If zero, current time is used.
+	NotBefore time.Time
+	// Validity is for how long the certificate is valid from _NotBefore_.
+	Validity time.Duration
+	// MaxPathLen is the maximum number of intermediate certificate authorities that may follow
+	// a certificate authority. A negative value means no limit.
+	//
+	// It is only used when creating a certificate authority. NOTE: The zero value is a path length
+	// of zero, i.e. the certificate authority may not issue intermediates. Set it to -1, or a positive
+	// value, to allow `CertificateAuthority.IssueIntermediate`.
+	MaxPathLen int
+	// SignAlgorithm is the algorithm to sign the certificate, or certificate request, with.
+	//
+	// If empty, it is selected from the signing key, i.e. `ifcrypto.SignAlgorithmRsaPkcs1V15Sha256` for
+	// _RSA_, the hash that matches the curve for _ECDSA_ and `ifcrypto.SignAlgorithmEd25519` for _Ed25519_.
+	SignAlgorithm ifcrypto.SignAlgorithm
+}
+
+// CertificateAuthority is a certificate authority that issues certificates using its private key.
+//
+// .Example Issue a mTLS Certificate
+// [source,go]
+// ----
+// csr, err := cm.CreateCertificateRequest(key, CertificateSpec{DNSNames: []string{"svc.internal"}}); cert, err := ca.Issue(csr, CertificateSpec{Validity: 24 * time.Hour})
+// ----
+type CertificateAuthority interface {
+	// GetCertificate returns the certificate of this certificate authority.
+	GetCertificate() *x509.Certificate
+	// GetChain returns the chain of this certificate authority, starting with its own certificate
+	// and ending with the root certificate.
+	GetChain() []*x509.Certificate
+	// Issue issues a certificate from the _csr_ using the _spec_.
+	//
+	// If the _csr_ signature do not verify, a error wrapping `ErrInvalidCertificateRequest` is returned.
+	// The certificate may not be valid after this certificate authority.
+	Issue(csr *x509.CertificateRequest, spec CertificateSpec) (*x509.Certificate, error)
+	// IssueIntermediate issues a certificate authority for the _key_ that is signed by this
+	// certificate authority.
+	IssueIntermediate(key ifcrypto.KeyPair, spec CertificateSpec) (CertificateAuthority, error)
+}
+
+// CertificateManager creates certificate requests and certificate authorities.
+//
+// All keys, that needs to sign, must be a `ifcrypto.KeyPair` that also implements `crypto.Signer`.
+// Remote keys that needs a context to sign, such as _AWS KMS_ keys, must have the context bound
+// before passed to the `CertificateManager`.
+type CertificateManager interface {
+	// CreateCertificateRequest creates a certificate request, signed by the _key_, using the _spec_.
+	//
+	// Only the _Subject_, _SANs_ and _SignAlgorithm_ are used from the _spec_.
+	CreateCertificateRequest(key ifcrypto.KeyPair, spec CertificateSpec) (*x509.CertificateRequest, error)
+	// CreateRootCA creates a self signed root certificate authority for the _key_.
+	CreateRootCA(key ifcrypto.KeyPair, spec CertificateSpec) (CertificateAuthority, error)
+	// LoadCA loads a existing certificate authority where the _key_ is the private key of the
+	// first certificate in the _chain_. The _chain_ must end with the root certificate.
+	LoadCA(key ifcrypto.KeyPair, chain ...*x509.Certificate) (CertificateAuthority, error)
+}
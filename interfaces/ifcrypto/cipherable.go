@@ -1,9 +1,16 @@
 package ifcrypto
 
-import "github.com/mariotoffia/goservice/interfaces/ifctx"
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
 
 // Cipherable is a encrypt / decrypt capable implementation.
 //
+// Additional, non secret, data that is bound to the encrypted data is passed as
+// `coremodel.MetaEncryptionContext` in the _tags_. The same encryption context must
+// be passed when decrypting.
+//
 // NOTE: Some keys do implement `crypto.Decrypter`, thus is
 // able to decrypt via the key directly.
 type Cipherable interface {
@@ -13,6 +20,7 @@ type Cipherable interface {
 		plaintext []byte,
 		key Key,
 		cipher Chipher,
+		tags ...coremodel.Meta,
 	) (encrypted []byte, err error)
 
 	// Decrypt will decrypt the _encrypted_ using the key.
@@ -21,5 +29,6 @@ type Cipherable interface {
 		encrypted []byte,
 		key Key,
 		cipher Chipher,
+		tags ...coremodel.Meta,
 	) (plaintext []byte, err error)
 }
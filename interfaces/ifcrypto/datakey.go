@@ -0,0 +1,39 @@
+package ifcrypto
+
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// DataKeyGenerator generates data keys for _envelope encryption_.
+//
+// A data key is a symmetric key, for the _cipher_, that is used to encrypt data locally.
+// The data key is returned both in plaintext and encrypted under the _key_, the encrypted
+// data key is stored along with the encrypted data and later decrypted using a `Cipherable`
+// and _key_.
+//
+// Any `coremodel.MetaEncryptionContext` in _tags_ is bound to the encrypted data key and must
+// be passed when it is decrypted.
+//
+// NOTE: The plaintext data key should be zeroed as soon as it has been used.
+type DataKeyGenerator interface {
+	// GenerateDataKey generates a new data key for the _cipher_ and returns it in both plaintext
+	// and encrypted under the _key_.
+	GenerateDataKey(
+		c ifctx.ServiceContext,
+		key Key,
+		cipher Chipher,
+		tags ...coremodel.Meta,
+	) (plaintext []byte, encrypted []byte, err error)
+
+	// GenerateDataKeyWithoutPlaintext generates a new data key for the _cipher_ and returns it
+	// encrypted under the _key_.
+	//
+	// This is useful when the data key is to be used later on, e.g. by another service.
+	GenerateDataKeyWithoutPlaintext(
+		c ifctx.ServiceContext,
+		key Key,
+		cipher Chipher,
+		tags ...coremodel.Meta,
+	) (encrypted []byte, err error)
+}
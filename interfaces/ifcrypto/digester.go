@@ -3,72 +3,257 @@ package ifcrypto
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/sha3"
 	"crypto/sha512"
+	"errors"
 	"fmt"
 	"hash"
+	"io"
+	"sync"
 )
 
+// ErrUnknownHashAlgorithm is returned when a `HashAlgorithm` is not registered.
+var ErrUnknownHashAlgorithm = errors.New("unknown hash algorithm")
+
 type HashAlgorithm string
 
 const (
-	HashNone   HashAlgorithm = "none"
-	HashSha256 HashAlgorithm = "sha256"
-	HashSha512 HashAlgorithm = "sha512"
-	HashHMac   HashAlgorithm = "hmac"
+	HashNone       HashAlgorithm = "none"
+	HashSha224     HashAlgorithm = "sha224"
+	HashSha256     HashAlgorithm = "sha256"
+	HashSha384     HashAlgorithm = "sha384"
+	HashSha512     HashAlgorithm = "sha512"
+	HashSha512_256 HashAlgorithm = "sha512_256"
+	HashSha3_256   HashAlgorithm = "sha3_256"
+	HashSha3_384   HashAlgorithm = "sha3_384"
+	HashSha3_512   HashAlgorithm = "sha3_512"
+	HashHMac       HashAlgorithm = "hmac"
 )
 
-// GetHasher returns the hash algorithm for the type.
+// HashWrapper creates a `hash.Hash` that wraps the hash created by _parent_, e.g. _HMAC_.
 //
-// If _HashAlgorithmNone_ nil is returned.
+// The _parent_ must be invoked each time a new instance of the wrapped hash is
+// needed since a `hash.Hash` is stateful. The _key_ is the key passed when composing
+// the hash and may be nil.
+type HashWrapper func(key []byte, parent func() hash.Hash) (hash.Hash, error)
+
+// hashEntry is a registered `HashAlgorithm`. Exactly one of _hash_ and _wrapper_ is set.
+type hashEntry struct {
+	hash    func() hash.Hash
+	wrapper HashWrapper
+}
+
+var (
+	hashRegistryMu sync.RWMutex
+	hashRegistry   = map[HashAlgorithm]hashEntry{}
+)
+
+func init() {
+
+	RegisterHash(HashSha224, sha256.New224)
+	RegisterHash(HashSha256, sha256.New)
+	RegisterHash(HashSha384, sha512.New384)
+	RegisterHash(HashSha512, sha512.New)
+	RegisterHash(HashSha512_256, sha512.New512_256)
+	RegisterHash(HashSha3_256, func() hash.Hash { return sha3.New256() })
+	RegisterHash(HashSha3_384, func() hash.Hash { return sha3.New384() })
+	RegisterHash(HashSha3_512, func() hash.Hash { return sha3.New512() })
+
+	RegisterHashWrapper(HashHMac, func(key []byte, parent func() hash.Hash) (hash.Hash, error) {
+
+		if len(key) == 0 {
+			return nil, fmt.Errorf("hmac requires a key")
+		}
+
+		return hmac.New(parent, key), nil
+
+	})
+
+}
+
+// RegisterHash registers, or replaces, a plain hash algorithm that starts a hash chain.
 //
-// .Requesting a SHA256
+// .Registering BLAKE2b-256
 // [source,go]
 // ----
-// sha256 := HashSha256.GetHasher()
+// RegisterHash("blake2b_256", func() hash.Hash { h, _ := blake2b.New256(nil); return h })
 // ----
-func (alg HashAlgorithm) GetHasher() hash.Hash {
-
-	switch alg {
-	case HashNone:
-		return nil
-	case HashSha256:
-		return sha256.New()
-	case HashSha512:
-		return sha512.New()
+func RegisterHash(alg HashAlgorithm, fn func() hash.Hash) {
+
+	if alg == HashNone || fn == nil {
+		panic(fmt.Sprintf("invalid hash registration: %s", alg))
+	}
+
+	hashRegistryMu.Lock()
+	defer hashRegistryMu.Unlock()
+
+	hashRegistry[alg] = hashEntry{hash: fn}
+}
+
+// RegisterHashWrapper registers, or replaces, a hash algorithm that wraps the previous
+// hash algorithm in a chain, e.g. `HashHMac`.
+func RegisterHashWrapper(alg HashAlgorithm, wrapper HashWrapper) {
+
+	if alg == HashNone || wrapper == nil {
+		panic(fmt.Sprintf("invalid hash registration: %s", alg))
 	}
 
-	panic(fmt.Sprintf("not valid hasher alg: %s", alg))
+	hashRegistryMu.Lock()
+	defer hashRegistryMu.Unlock()
+
+	hashRegistry[alg] = hashEntry{wrapper: wrapper}
+}
+
+// UnregisterHash removes the registration of _alg_, plain or wrapper. It is a no-op
+// if _alg_ is not registered.
+//
+// This is mainly used to remove hash algorithms registered in tests.
+func UnregisterHash(alg HashAlgorithm) {
+
+	hashRegistryMu.Lock()
+	defer hashRegistryMu.Unlock()
 
+	delete(hashRegistry, alg)
+}
+
+// lookupHash returns the registered entry for _alg_.
+func lookupHash(alg HashAlgorithm) (hashEntry, bool) {
+
+	hashRegistryMu.RLock()
+	defer hashRegistryMu.RUnlock()
+
+	entry, ok := hashRegistry[alg]
+	return entry, ok
+}
+
+// Available reports whether _alg_ is a registered hash that do not wrap another hash.
+func (alg HashAlgorithm) Available() bool {
+
+	entry, ok := lookupHash(alg)
+	return ok && entry.hash != nil
 }
 
 // GetHasher returns the hash algorithm for the type.
 //
-// If _HashAlgorithmNone_ nil is returned.
+// If _HashAlgorithmNone_ nil is returned. If the _alg_ is not registered, an error
+// wrapping `ErrUnknownHashAlgorithm` is returned.
 //
-// Parent is used when the current hash algorithm relies on a another.
+// .Requesting a SHA256
+// [source,go]
+// ----
+// sha256, err := HashSha256.GetHasher()
+// ----
+func (alg HashAlgorithm) GetHasher() (hash.Hash, error) {
+
+	if alg == HashNone {
+		return nil, nil
+	}
+
+	return ComposeHash(nil, alg)
+}
+
+// GetHasherWithKey returns the hash algorithm for the type, wrapping the hash
+// created by _parent_ if the algorithm is a wrapper such as `HashHMac`.
+//
+// If _HashAlgorithmNone_ nil is returned.
 //
 // .Requesting a SHA256 HMAC
 // [source,go]
 // ----
-// hmacSha256 := HashHMac.GetHasher(key, HashSha256.GetHasher())
+// hmacSha256, err := HashHMac.GetHasherWithKey(key, sha256.New)
 // ----
-func (alg HashAlgorithm) GetHasherWithKey(key []byte, parent hash.Hash) hash.Hash {
-
-	switch alg {
-	case HashNone:
-		return nil
-	case HashSha256:
-		return sha256.New()
-	case HashSha512:
-		return sha512.New()
-	case HashHMac:
-		return hmac.New(
-			func() hash.Hash { return parent }, key,
-		)
+func (alg HashAlgorithm) GetHasherWithKey(key []byte, parent func() hash.Hash) (hash.Hash, error) {
+
+	if alg == HashNone {
+		return nil, nil
 	}
 
-	panic(fmt.Sprintf("not valid hasher alg: %s", alg))
+	entry, ok := lookupHash(alg)
 
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHashAlgorithm, alg)
+	}
+
+	if entry.hash != nil {
+		return entry.hash(), nil
+	}
+
+	if parent == nil {
+		return nil, fmt.Errorf("hash algorithm: %s needs a hash to wrap", alg)
+	}
+
+	return entry.wrapper(key, parent)
+}
+
+// ComposeHash creates a `hash.Hash` from the chain of hash algorithms in _h_.
+//
+// The first algorithm must be a plain hash and each of the following must be a
+// wrapper of the hash composed so far. The _key_ is passed to each wrapper.
+//
+// .HMAC over SHA3-256
+// [source,go]
+// ----
+// mac, err := ComposeHash(key, HashSha3_256, HashHMac)
+// ----
+func ComposeHash(key []byte, h ...HashAlgorithm) (hash.Hash, error) {
+
+	fn, err := ComposeHashFunc(key, h...)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn(), nil
+}
+
+// ComposeHashFunc works as `ComposeHash` but returns a function that creates a new
+// `hash.Hash` instance for each invocation.
+//
+// The chain is validated by creating one instance before returning.
+func ComposeHashFunc(key []byte, h ...HashAlgorithm) (func() hash.Hash, error) {
+
+	if len(h) == 0 {
+		return nil, fmt.Errorf("at least one hash algorithm is required")
+	}
+
+	var fn func() hash.Hash
+
+	for i, alg := range h {
+
+		entry, ok := lookupHash(alg)
+
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownHashAlgorithm, alg)
+		}
+
+		if i == 0 {
+
+			if entry.hash == nil {
+				return nil, fmt.Errorf("hash algorithm: %s needs a hash to wrap", alg)
+			}
+
+			fn = entry.hash
+			continue
+
+		}
+
+		if entry.wrapper == nil {
+			return nil, fmt.Errorf("hash algorithm: %s can not wrap another hash", alg)
+		}
+
+		parent, wrapper := fn, entry.wrapper
+
+		if _, err := wrapper(key, parent); err != nil {
+			return nil, fmt.Errorf("hash algorithm: %s: %w", alg, err)
+		}
+
+		fn = func() hash.Hash {
+			hsh, _ := wrapper(key, parent)
+			return hsh
+		}
+
+	}
+
+	return fn, nil
 }
 
 // Digester is capable of producing a digest with or without a key.
@@ -79,7 +264,7 @@ type Digester interface {
 	// NOTE: Key is only needed when a hash algorithm needs a key, otherwise
 	// set it to nil.
 	//
-	// The `HashAlgorithm` is interpreted in sequential order.
+	// The `HashAlgorithm` is interpreted in sequential order, see `ComposeHash`.
 	//
 	// .Example Multi Hash Digest
 	// [source,go]
@@ -88,3 +273,38 @@ type Digester interface {
 	// ----
 	Digest(key, msg []byte, h ...HashAlgorithm) ([]byte, error)
 }
+
+// DigestProgress is invoked by a `StreamDigester` each time a chunk has been
+// hashed. The _read_ is the total number of bytes consumed from the reader so far.
+type DigestProgress func(read int64)
+
+// StreamDigester produces digests from an `io.Reader` without buffering the
+// whole content in memory.
+type StreamDigester interface {
+	// DigestReader works as `Digester.Digest` but reads the message from _r_ until
+	// `io.EOF`.
+	//
+	// The optional _progress_ is invoked after each chunk, set it to nil if not needed.
+	//
+	// .Example Digest a File
+	// [source,go]
+	// ----
+	// f, _ := os.Open("large.bin")
+	// defer f.Close()
+	//
+	// digest, err := NewDigester().DigestReader(nil, f, nil, HashSha256)
+	// ----
+	DigestReader(key []byte, r io.Reader, progress DigestProgress, h ...HashAlgorithm) ([]byte, error)
+	// MultiDigest reads _r_ once and computes one digest for each of the unkeyed
+	// hash algorithms in _h_.
+	//
+	// The optional _progress_ is invoked after each chunk, set it to nil if not needed.
+	//
+	// .Example SHA-256 and SHA3-256 in a Single Pass
+	// [source,go]
+	// ----
+	// digests, err := NewDigester().MultiDigest(f, nil, HashSha256, HashSha3_256)
+	// sha256 := digests[HashSha256]
+	// ----
+	MultiDigest(r io.Reader, progress DigestProgress, h ...HashAlgorithm) (map[HashAlgorithm][]byte, error)
+}
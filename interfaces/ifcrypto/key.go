@@ -2,6 +2,15 @@ package ifcrypto
 
 import (
 	"crypto"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnsupportedKeyType is returned when a `KeyType` is not supported.
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+	// ErrUnsupportedKeySize is returned when a key size is not supported by the `KeyType`.
+	ErrUnsupportedKeySize = errors.New("unsupported key size")
 )
 
 // KeyUsage is the usage of a key.
@@ -18,6 +27,10 @@ const (
 	KeyUsageDecrypt KeyUsage = "decrypt"
 	// KeyUsageEncrypt allows the key do encrypt a message
 	KeyUsageEncrypt KeyUsage = "encrypt"
+	// KeyUsageMac allows a symmetric key to generate and verify message authentication codes
+	KeyUsageMac KeyUsage = "mac"
+	// KeyUsageKeyAgreement allows a elliptic curve key to derive shared secrets using a `KeyAgreement`
+	KeyUsageKeyAgreement KeyUsage = "key-agreement"
 )
 
 // KeyType is the type of key
@@ -27,6 +40,11 @@ const (
 	KeyTypeRsa           KeyType = "rsa"
 	KeyTypeEccNistP      KeyType = "ecc-nist-p"
 	KeyTypeEccSecgP256k1 KeyType = "ecc-secg_p256K1"
+	// KeyTypeEd25519 is a _Edwards-curve_ key as of _RFC 8032_ that may only be used to sign and verify.
+	KeyTypeEd25519 KeyType = "ed25519"
+	// KeyTypeX25519 is a _Montgomery-curve_ key as of _RFC 7748_ that may only be used for key
+	// agreement and _ECIES_ encryption.
+	KeyTypeX25519 KeyType = "x25519"
 	// KeyTypeSymmetric is a key to use for symmetric operations in contrast to all other
 	// `KeyType` where those are asymmetric.
 	KeyTypeSymmetric KeyType = "symmetric"
@@ -39,9 +57,51 @@ var KeySizes = map[KeyType][]int{
 	KeyTypeRsa:           {2048, 3072, 4096},
 	KeyTypeEccNistP:      {256, 384, 521},
 	KeyTypeEccSecgP256k1: {256},
+	KeyTypeEd25519:       {256},
+	KeyTypeX25519:        {256},
 	KeyTypeSymmetric:     {},
 }
 
+// ValidateKeySize ensures that the _keySize_ is one of the `KeySizes` for the _keyType_.
+//
+// If the _keyType_ is unknown, an error wrapping `ErrUnsupportedKeyType` is returned. If the
+// _keySize_ is not supported, an error wrapping `ErrUnsupportedKeySize` is returned. Key types
+// without any sizes only requires the _keySize_ to be a positive multiple of eight.
+func ValidateKeySize(keyType KeyType, keySize int) error {
+
+	sizes, ok := KeySizes[keyType]
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedKeyType, keyType)
+	}
+
+	if len(sizes) == 0 {
+
+		if keySize <= 0 || keySize%8 != 0 {
+			return fmt.Errorf(
+				"%w: key type: %s requires a positive multiple of 8, got: %d",
+				ErrUnsupportedKeySize, keyType, keySize,
+			)
+		}
+
+		return nil
+
+	}
+
+	for _, size := range sizes {
+
+		if size == keySize {
+			return nil
+		}
+
+	}
+
+	return fmt.Errorf(
+		"%w: key type: %s do not support key size: %d, supported: %v",
+		ErrUnsupportedKeySize, keyType, keySize, sizes,
+	)
+}
+
 // SignAlgorithm specifies which type of signing algorithm being used to sign or verify.
 type SignAlgorithm string
 
@@ -56,14 +116,113 @@ const (
 	SignAlgorithmEcdSha256         SignAlgorithm = "ecd-sha256"
 	SignAlgorithmEcdSha384         SignAlgorithm = "ecd-sha384"
 	SignAlgorithmEcdSha512         SignAlgorithm = "ecd-sha512"
+	// SignAlgorithmEd25519 is _PureEdDSA_ using _Ed25519_, it signs the message as is
+	// and hence it has no `crypto.Hash`.
+	SignAlgorithmEd25519 SignAlgorithm = "ed25519"
 )
 
+// GetCryptoHash returns the `crypto.Hash` that is used to create the digest to sign.
+//
+// If unknown `SignAlgorithm`, zero is returned.
+func (alg SignAlgorithm) GetCryptoHash() crypto.Hash {
+
+	switch alg {
+	case SignAlgorithmRsaPssSha256, SignAlgorithmRsaPkcs1V15Sha256, SignAlgorithmEcdSha256:
+		return crypto.SHA256
+	case SignAlgorithmRsaPssSha384, SignAlgorithmRsaPkcs1V15Sha384, SignAlgorithmEcdSha384:
+		return crypto.SHA384
+	case SignAlgorithmRsaPssSha512, SignAlgorithmRsaPkcs1V15Sha512, SignAlgorithmEcdSha512:
+		return crypto.SHA512
+	}
+
+	return 0
+}
+
+// IsRsaPss returns `true` if the _alg_ is a _RSASSA-PSS_ algorithm.
+func (alg SignAlgorithm) IsRsaPss() bool {
+
+	return alg == SignAlgorithmRsaPssSha256 ||
+		alg == SignAlgorithmRsaPssSha384 ||
+		alg == SignAlgorithmRsaPssSha512
+
+}
+
+// IsRsaPkcs1V15 returns `true` if the _alg_ is a _RSASSA-PKCS1-v1_5_ algorithm.
+func (alg SignAlgorithm) IsRsaPkcs1V15() bool {
+
+	return alg == SignAlgorithmRsaPkcs1V15Sha256 ||
+		alg == SignAlgorithmRsaPkcs1V15Sha384 ||
+		alg == SignAlgorithmRsaPkcs1V15Sha512
+
+}
+
+// IsEcdsa returns `true` if the _alg_ is a _ECDSA_ algorithm.
+func (alg SignAlgorithm) IsEcdsa() bool {
+
+	return alg == SignAlgorithmEcdSha256 ||
+		alg == SignAlgorithmEcdSha384 ||
+		alg == SignAlgorithmEcdSha512
+
+}
+
+// IsEd25519 returns `true` if the _alg_ is _Ed25519_.
+func (alg SignAlgorithm) IsEd25519() bool {
+	return alg == SignAlgorithmEd25519
+}
+
+// Chipher specifies a encryption algorithm.
 type Chipher string
 
 const (
+	// ChiperAES256 is _AES_ using a 256 bit key in _GCM_ mode.
 	ChiperAES256 Chipher = "aes256"
+	// ChiperRsaOaepSha1 is _RSAES-OAEP_ using _SHA-1_ (same as _AWS KMS_ `RSAES_OAEP_SHA_1`).
+	ChiperRsaOaepSha1 Chipher = "rsa-oaep-sha1"
+	// ChiperRsaOaepSha256 is _RSAES-OAEP_ using _SHA-256_ (same as _AWS KMS_ `RSAES_OAEP_SHA_256`).
+	ChiperRsaOaepSha256 Chipher = "rsa-oaep-sha256"
+	// ChiperRsaPkcs1V15 is _RSAES-PKCS1-v1_5_.
+	//
+	// NOTE: This is not supported by _AWS KMS_ and should only be used for legacy purposes.
+	ChiperRsaPkcs1V15 Chipher = "rsa-pkcs1-v1.5"
+	// ChiperEciesHkdfSha256AES256 is a _ECIES_ hybrid encryption using a ephemeral _ECDH_ key, _HKDF-SHA256_
+	// and _AES-256-GCM_. It is supported by _NIST_ curve and `KeyTypeX25519` keys.
+	//
+	// The encrypted output is the ephemeral public key followed by the nonce, ciphertext and tag.
+	ChiperEciesHkdfSha256AES256 Chipher = "ecies-hkdf-sha256-aes256"
 )
 
+// GetMaxPlaintextSize returns the maximum number of bytes that may be encrypted
+// in a single operation using a key of _keySize_ bits.
+//
+// If there is no limit, -1 is returned.
+func (c Chipher) GetMaxPlaintextSize(keySize int) int {
+
+	k := (keySize + 7) / 8
+
+	switch c {
+	case ChiperRsaOaepSha1:
+		return k - 2*20 - 2
+	case ChiperRsaOaepSha256:
+		return k - 2*32 - 2
+	case ChiperRsaPkcs1V15:
+		return k - 11
+	}
+
+	return -1
+}
+
+// GetDataKeySize returns the size, in bytes, of a data key for this symmetric cipher.
+//
+// If not a symmetric cipher, -1 is returned.
+func (c Chipher) GetDataKeySize() int {
+
+	if c == ChiperAES256 {
+		return 32
+	}
+
+	return -1
+}
+
 // Key represents a single key.
 //
 // The key may or may not be present in memory, it may be within a hardware unit or in a service
@@ -86,6 +245,12 @@ type Key interface {
 	CanSign(alg SignAlgorithm) bool
 	// CanVerify checks if the current _Key_ may participate in _alg_ `SignAlgorithm` to do verify on
 	CanVerify(alg SignAlgorithm) bool
+	// CanMac checks if the current _Key_ may be used to generate and verify a _MAC_ using _alg_ `MacAlgorithm`.
+	CanMac(alg MacAlgorithm) bool
+	// CanEncrypt checks if the current _Key_ may be used to encrypt using the _cipher_.
+	CanEncrypt(cipher Chipher) bool
+	// CanDecrypt checks if the current _Key_ may be used to decrypt using the _cipher_.
+	CanDecrypt(cipher Chipher) bool
 	// GetKey gets the underlying key, if any.
 	//
 	// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
@@ -0,0 +1,30 @@
+package ifcrypto
+
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// KeyAgreement is implemented by those who may derive a shared secret between two parties
+// using _ECDH_ or _X25519_.
+//
+// The shared secret is not uniformly random and must not be used as a key directly, use
+// a `KeyDeriver` such as `KdfHkdf` to derive keys from it.
+//
+// .Example Derive a Shared Key
+// [source,go]
+// ----
+// secret, err := agreement.DeriveSharedSecret(c, private, peer); key, err := deriver.DeriveKey(c, secret, DeriveSpec{Algorithm: KdfHkdf, KeySize: 256})
+// ----
+type KeyAgreement interface {
+	// DeriveSharedSecret derives the shared secret between the _private_ key and the _peer_ public key.
+	//
+	// Both keys must be of the same `KeyType` and key size and the _private_ key must have
+	// `KeyUsageKeyAgreement`.
+	DeriveSharedSecret(
+		c ifctx.ServiceContext,
+		private Key,
+		peer PublicKey,
+		meta ...coremodel.Meta,
+	) (secret []byte, err error)
+}
@@ -0,0 +1,78 @@
+package ifcrypto
+
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// KdfAlgorithm specifies a key derivation function.
+type KdfAlgorithm string
+
+const (
+	// KdfHkdf is _HKDF_ extract and expand as of _RFC 5869_.
+	KdfHkdf KdfAlgorithm = "hkdf"
+	// KdfHkdfExtract is the _HKDF_ extract step only, the derived key is the pseudorandom key
+	// and has the same size as the hash.
+	KdfHkdfExtract KdfAlgorithm = "hkdf-extract"
+	// KdfHkdfExpand is the _HKDF_ expand step only, the input key material must already be
+	// a pseudorandom key, e.g. from `KdfHkdfExtract`.
+	KdfHkdfExpand KdfAlgorithm = "hkdf-expand"
+	// KdfPbkdf2 is _PBKDF2_ as of _RFC 8018_, used to derive keys from passwords.
+	KdfPbkdf2 KdfAlgorithm = "pbkdf2"
+	// KdfScrypt is the memory hard _scrypt_ as of _RFC 7914_, used to derive keys from passwords.
+	KdfScrypt KdfAlgorithm = "scrypt"
+)
+
+// Default costs used when the `DeriveSpec` do not specify any.
+const (
+	// DefaultPbkdf2Iterations is the _OWASP_ recommendation for _PBKDF2-HMAC-SHA256_.
+	DefaultPbkdf2Iterations = 600000
+	// DefaultScryptN is the _scrypt_ CPU / memory cost.
+	DefaultScryptN = 32768
+	// DefaultScryptR is the _scrypt_ block size.
+	DefaultScryptR = 8
+	// DefaultScryptP is the _scrypt_ parallelization.
+	DefaultScryptP = 1
+)
+
+// DeriveSpec specifies how to derive a key using a `KeyDeriver`.
+type DeriveSpec struct {
+	// ID is the id of the derived key, if empty, the `KeyDeriver` assigns one.
+	ID string
+	// Algorithm is the key derivation function to use.
+	Algorithm KdfAlgorithm
+	// Hash is the hash used by _HKDF_ and _PBKDF2_. If empty, `HashSha256` is used.
+	Hash HashAlgorithm
+	// Salt is the salt, it is optional for _HKDF_ but required for _PBKDF2_ and _scrypt_.
+	Salt []byte
+	// Info is the _HKDF_ context and application specific information.
+	Info []byte
+	// Iterations is the _PBKDF2_ iteration count. If zero, `DefaultPbkdf2Iterations` is used.
+	Iterations int
+	// N is the _scrypt_ CPU / memory cost, it must be a power of two. If zero, `DefaultScryptN` is used.
+	N int
+	// R is the _scrypt_ block size. If zero, `DefaultScryptR` is used.
+	R int
+	// P is the _scrypt_ parallelization. If zero, `DefaultScryptP` is used.
+	P int
+	// KeySize is the size in bits of the derived key, it must be a multiple of eight.
+	//
+	// It is ignored for `KdfHkdfExtract` since the size is the hash size.
+	KeySize int
+	// Usage is what the derived key may be used for.
+	Usage []KeyUsage
+}
+
+// KeyDeriver derives symmetric keys from input key material.
+//
+// .Example Derive a Encryption Key
+// [source,go]
+// ----
+// key, err := NewKeyDeriver().DeriveKey(c, secret, DeriveSpec{Algorithm: KdfHkdf, Salt: salt, Info: []byte("tenant-a"), KeySize: 256, Usage: []KeyUsage{KeyUsageEncrypt, KeyUsageDecrypt}})
+// ----
+type KeyDeriver interface {
+	// DeriveKey derives a `KeyTypeSymmetric` key from the _secret_ using the _spec_.
+	//
+	// The _secret_ is the input key material, e.g. a shared secret or a password.
+	DeriveKey(c ifctx.ServiceContext, secret []byte, spec DeriveSpec, meta ...coremodel.Meta) (Key, error)
+}
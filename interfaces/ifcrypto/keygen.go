@@ -0,0 +1,38 @@
+package ifcrypto
+
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// KeySpec specifies a key to generate using a `KeyGenerator`.
+type KeySpec struct {
+	// ID is the id of the key, if empty, the `KeyGenerator` assigns one.
+	//
+	// Remote key generators, such as _AWS KMS_, ignores this since the id is
+	// assigned by the service.
+	ID string
+	// KeyType is the type of key to generate.
+	KeyType KeyType
+	// KeySize is the size in bits, it must be one of the `KeySizes` for the `KeyType`.
+	KeySize int
+	// Usage is what the key may be used for.
+	Usage []KeyUsage
+	// Chiphers restricts the supported chiphers of the key. If empty, all chiphers
+	// that the `KeyType` supports are allowed.
+	Chiphers []Chipher
+	// Description is a optional description of the key.
+	Description string
+	// Tags are optional tags to associate with the key.
+	Tags []coremodel.Tag
+}
+
+// KeyGenerator generates new keys from a `KeySpec`.
+//
+// Asymmetric keys are returned as `KeyPair` and symmetric keys as `Key`. Hence, provisioning
+// code may stay backend agnostic, i.e. it do not need to know if it is generated in memory or
+// in a remote service such as _AWS KMS_.
+type KeyGenerator interface {
+	// GenerateKey generates a new key using the _spec_.
+	GenerateKey(c ifctx.ServiceContext, spec KeySpec, meta ...coremodel.Meta) (Key, error)
+}
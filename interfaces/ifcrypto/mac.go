@@ -0,0 +1,69 @@
+package ifcrypto
+
+import (
+	"errors"
+
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// ErrInvalidMac is returned by a `Mac` when the _MAC_ do not match the message.
+//
+// Implementations may wrap this error, hence use `errors.Is` to check for it.
+var ErrInvalidMac = errors.New("invalid mac")
+
+// MacAlgorithm specifies which message authentication code algorithm to use.
+type MacAlgorithm string
+
+// Enum values for MacAlgorithm
+const (
+	MacAlgorithmHmacSha224 MacAlgorithm = "hmac-sha224"
+	MacAlgorithmHmacSha256 MacAlgorithm = "hmac-sha256"
+	MacAlgorithmHmacSha384 MacAlgorithm = "hmac-sha384"
+	MacAlgorithmHmacSha512 MacAlgorithm = "hmac-sha512"
+)
+
+// GetHashAlgorithm returns the `HashAlgorithm` that the _HMAC_ is computed over.
+//
+// If unknown `MacAlgorithm`, `HashNone` is returned.
+func (alg MacAlgorithm) GetHashAlgorithm() HashAlgorithm {
+
+	switch alg {
+	case MacAlgorithmHmacSha224:
+		return HashSha224
+	case MacAlgorithmHmacSha256:
+		return HashSha256
+	case MacAlgorithmHmacSha384:
+		return HashSha384
+	case MacAlgorithmHmacSha512:
+		return HashSha512
+	}
+
+	return HashNone
+}
+
+// Mac is implemented by those who may generate and verify message authentication codes
+// using a symmetric `Key`.
+type Mac interface {
+	// GenerateMac computes the _MAC_ of _msg_ using the _key_ and _alg_.
+	GenerateMac(
+		c ifctx.ServiceContext,
+		msg []byte,
+		key Key,
+		alg MacAlgorithm,
+		tags ...coremodel.Meta,
+	) (mac []byte, err error)
+	// VerifyMac will verify the _mac_ of _msg_ using the _key_ and _alg_.
+	//
+	// The comparison is done in constant time. If the _mac_ is valid, `nil` is returned.
+	// If it do not match, an error that wraps `ErrInvalidMac` is returned. Any other error
+	// is returned when not possible to do the verification at all.
+	VerifyMac(
+		c ifctx.ServiceContext,
+		msg []byte,
+		mac []byte,
+		key Key,
+		alg MacAlgorithm,
+		tags ...coremodel.Meta,
+	) error
+}
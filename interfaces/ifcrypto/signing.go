@@ -1,30 +1,45 @@
 package ifcrypto
 
 import (
+	"errors"
+
 	"github.com/mariotoffia/goservice/interfaces/ifctx"
 	"github.com/mariotoffia/goservice/model/coremodel"
 )
 
+// ErrInvalidSignature is returned by a `Verifier` when the signature do not match the message.
+//
+// Implementations may wrap this error, hence use `errors.Is` to check for it.
+var ErrInvalidSignature = errors.New("invalid signature")
+
 // Signer is a entity that may sign a signature.
 //
 // NOTE: Some keys do implement `crypto.Signer` interface directly on the key.
 type Signer interface {
-	// Sign will sign the _msg_ using the provided _key_.
+	// Sign will sign the _msg_ using the provided _key_ and return the signature.
+	//
+	// The _msg_ is the complete message, it is hashed by the signer using the hash
+	// algorithm specified by the _signAlgorithm_.
 	Sign(
 		c ifctx.ServiceContext,
 		msg []byte,
 		key Key,
 		signAlgorithm SignAlgorithm,
 		tags ...coremodel.Meta,
-	) error
+	) (signature []byte, err error)
 }
 
 // Verifier is implemented by those who may verify a signature.
 type Verifier interface {
-	// Verify will verify the _msg_ using the provided _key_
+	// Verify will verify the _signature_ of _msg_ using the provided _key_.
+	//
+	// If the _signature_ is valid, `nil` is returned. If the signature do not match
+	// it returns an error that wraps `ErrInvalidSignature`. Any other error is returned
+	// when not possible to do the verification at all.
 	Verify(
 		c ifctx.ServiceContext,
 		msg []byte,
+		signature []byte,
 		key Key,
 		signAlgorithm SignAlgorithm,
 		tags ...coremodel.Meta,
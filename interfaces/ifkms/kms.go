@@ -1 +1,129 @@
 package ifkms
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+var (
+	// ErrKeyNotFound is returned when the key, or alias, do not exist.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyDisabled is returned when a operation is done on a disabled key.
+	ErrKeyDisabled = errors.New("key is disabled")
+	// ErrInvalidKeyState is returned when the key is in a state that do not allow the operation,
+	// for example the key is pending deletion.
+	ErrInvalidKeyState = errors.New("invalid key state")
+	// ErrInvalidKeyUsage is returned when the key do not have the `ifcrypto.KeyUsage`, or do not
+	// support the algorithm, that the operation needs.
+	ErrInvalidKeyUsage = errors.New("invalid key usage")
+	// ErrInvalidCiphertext is returned when the encrypted data, or encryption context, is not valid.
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	// ErrAlreadyExists is returned when creating a resource, such as a alias, that already exists.
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// KeyState is the lifecycle state of a key in a _KMS_.
+type KeyState string
+
+const (
+	// KeyStateEnabled is a key that can be used in cryptographic operations.
+	KeyStateEnabled KeyState = "enabled"
+	// KeyStateDisabled is a key that can not be used until enabled again.
+	KeyStateDisabled KeyState = "disabled"
+	// KeyStatePendingDeletion is a key that is scheduled for deletion. It can not be used
+	// but the deletion can be cancelled until the deletion date.
+	KeyStatePendingDeletion KeyState = "pending-deletion"
+)
+
+// KeyInfo describes a key that is managed by a `KeyManager`.
+type KeyInfo struct {
+	// Key is the handle to be used in cryptographic operations.
+	//
+	// If asymmetric key, this is a `ifcrypto.KeyPair`.
+	Key ifcrypto.Key
+	// State is the current `KeyState` of the key.
+	State KeyState
+	// Description is a optional, free text, description of the key.
+	Description string
+	// Aliases are all aliases that points to the key.
+	Aliases []string
+	// Tags are the tags that the key has been tagged with.
+	Tags []coremodel.Tag
+	// CreationDate is when the key was created.
+	CreationDate time.Time
+	// DeletionDate is set when `KeyStatePendingDeletion`.
+	DeletionDate time.Time
+}
+
+// KeyManager manages the lifecycle of keys within a _KMS_.
+//
+// All functions that accepts a _keyID_ accepts the key id, _ARN_ or a alias. Aliases
+// is always prefixed with _"alias/"_, the same as in _AWS KMS_.
+//
+// Backend specific options such as `coremodel.MetaGrantToken` is passed in the _meta_
+// parameter. Errors returned wraps the errors, such as `ErrKeyNotFound`, declared in
+// this package hence use `errors.Is` to check for them.
+type KeyManager interface {
+	// CreateKey creates a new key of _keyType_ and _keySize_ bits that may be used for the _usage_.
+	//
+	// If a asymmetric _keyType_, the returned key is a `ifcrypto.KeyPair`.
+	CreateKey(
+		c ifctx.ServiceContext,
+		keyType ifcrypto.KeyType,
+		keySize int,
+		usage []ifcrypto.KeyUsage,
+		description string,
+		tags []coremodel.Tag,
+		meta ...coremodel.Meta,
+	) (ifcrypto.Key, error)
+
+	// GetKey returns a handle to the key with _keyID_.
+	//
+	// If a asymmetric key, the returned key is a `ifcrypto.KeyPair`.
+	GetKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) (ifcrypto.Key, error)
+
+	// DescribeKey returns the `KeyInfo` of the key with _keyID_.
+	DescribeKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) (*KeyInfo, error)
+
+	// ListKeys lists all keys that are managed by this `KeyManager`.
+	ListKeys(c ifctx.ServiceContext, meta ...coremodel.Meta) ([]KeyInfo, error)
+
+	// EnableKey sets the key in `KeyStateEnabled`.
+	EnableKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error
+
+	// DisableKey sets the key in `KeyStateDisabled`.
+	DisableKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error
+
+	// ScheduleKeyDeletion sets the key in `KeyStatePendingDeletion` and deletes it after
+	// _pendingWindowInDays_. It returns the date when the key will be deleted.
+	//
+	// The _pendingWindowInDays_ must be between 7 and 30 days.
+	ScheduleKeyDeletion(
+		c ifctx.ServiceContext,
+		keyID string,
+		pendingWindowInDays int,
+		meta ...coremodel.Meta,
+	) (deletionDate time.Time, err error)
+
+	// CancelKeyDeletion cancels a scheduled deletion. The key is then in `KeyStateDisabled`.
+	CancelKeyDeletion(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error
+
+	// CreateAlias creates the _alias_ that points to the key with _keyID_.
+	CreateAlias(c ifctx.ServiceContext, alias, keyID string, meta ...coremodel.Meta) error
+
+	// UpdateAlias changes the _alias_ to point to the key with _keyID_.
+	UpdateAlias(c ifctx.ServiceContext, alias, keyID string, meta ...coremodel.Meta) error
+
+	// DeleteAlias deletes the _alias_, the key it points to is not affected.
+	DeleteAlias(c ifctx.ServiceContext, alias string, meta ...coremodel.Meta) error
+
+	// TagKey adds or replaces the _tags_ on the key with _keyID_.
+	TagKey(c ifctx.ServiceContext, keyID string, tags []coremodel.Tag, meta ...coremodel.Meta) error
+
+	// UntagKey removes the tags with _tagNames_ from the key with _keyID_.
+	UntagKey(c ifctx.ServiceContext, keyID string, tagNames []string, meta ...coremodel.Meta) error
+}
@@ -0,0 +1,119 @@
+package awskms
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// Encrypt implements the `ifcrypto.Cipherable` interface.
+//
+// Use `ifcrypto.ChiperAES256` for symmetric keys and `ifcrypto.ChiperRsaOaepSha1` or
+// `ifcrypto.ChiperRsaOaepSha256` for _RSA_ keys. The `coremodel.MetaEncryptionContext`
+// is passed as _EncryptionContext_, note that _AWS KMS_ only supports it for symmetric keys.
+func (km *AwsKms) Encrypt(
+	c ifctx.ServiceContext,
+	plaintext []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to encrypt with")
+	}
+
+	alg, err := encryptionAlgorithmSpec(cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	ec, err := coremodel.GetEncryptionContext(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.Encrypt(c, &kms.EncryptInput{
+		KeyId:               utils.ToStringPtrNil(key.GetID()),
+		Plaintext:           plaintext,
+		EncryptionAlgorithm: alg,
+		EncryptionContext:   ec,
+		GrantTokens:         grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return out.CiphertextBlob, nil
+}
+
+// Decrypt implements the `ifcrypto.Cipherable` interface.
+//
+// The same encryption context, as when encrypted, must be passed as `coremodel.MetaEncryptionContext`.
+func (km *AwsKms) Decrypt(
+	c ifctx.ServiceContext,
+	encrypted []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to decrypt with")
+	}
+
+	alg, err := encryptionAlgorithmSpec(cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	ec, err := coremodel.GetEncryptionContext(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.Decrypt(c, &kms.DecryptInput{
+		KeyId:               utils.ToStringPtrNil(key.GetID()),
+		CiphertextBlob:      encrypted,
+		EncryptionAlgorithm: alg,
+		EncryptionContext:   ec,
+		GrantTokens:         grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return out.Plaintext, nil
+}
+
+// encryptionAlgorithmSpec maps the _cipher_ onto the _AWS KMS_ encryption algorithm.
+func encryptionAlgorithmSpec(cipher ifcrypto.Chipher) (types.EncryptionAlgorithmSpec, error) {
+
+	switch cipher {
+	case ifcrypto.ChiperAES256:
+		return types.EncryptionAlgorithmSpecSymmetricDefault, nil
+	case ifcrypto.ChiperRsaOaepSha1:
+		return types.EncryptionAlgorithmSpecRsaesOaepSha1, nil
+	case ifcrypto.ChiperRsaOaepSha256:
+		return types.EncryptionAlgorithmSpecRsaesOaepSha256, nil
+	}
+
+	return "", fmt.Errorf("cipher: %s is not supported by AWS KMS", cipher)
+}
@@ -0,0 +1,110 @@
+package awskms
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// GenerateDataKey implements the `ifcrypto.DataKeyGenerator` interface.
+//
+// The _key_ must be a symmetric _AWS KMS_ key and the `coremodel.MetaEncryptionContext`
+// is passed as _EncryptionContext_.
+func (km *AwsKms) GenerateDataKey(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, []byte, error) {
+
+	if key == nil {
+		return nil, nil, fmt.Errorf("must specify a key")
+	}
+
+	spec, err := dataKeySpec(cipher)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ec, err := coremodel.GetEncryptionContext(tags...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := client.GenerateDataKey(c, &kms.GenerateDataKeyInput{
+		KeyId:             utils.ToStringPtrNil(key.GetID()),
+		KeySpec:           spec,
+		EncryptionContext: ec,
+		GrantTokens:       grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, nil, mapError(err)
+	}
+
+	return out.Plaintext, out.CiphertextBlob, nil
+}
+
+// GenerateDataKeyWithoutPlaintext implements the `ifcrypto.DataKeyGenerator` interface.
+//
+// The _key_ must be a symmetric _AWS KMS_ key and the `coremodel.MetaEncryptionContext`
+// is passed as _EncryptionContext_.
+func (km *AwsKms) GenerateDataKeyWithoutPlaintext(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key")
+	}
+
+	spec, err := dataKeySpec(cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	ec, err := coremodel.GetEncryptionContext(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GenerateDataKeyWithoutPlaintext(c, &kms.GenerateDataKeyWithoutPlaintextInput{
+		KeyId:             utils.ToStringPtrNil(key.GetID()),
+		KeySpec:           spec,
+		EncryptionContext: ec,
+		GrantTokens:       grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return out.CiphertextBlob, nil
+}
+
+// dataKeySpec maps the _cipher_ onto the _AWS KMS_ data key spec.
+func dataKeySpec(cipher ifcrypto.Chipher) (types.DataKeySpec, error) {
+
+	if cipher == ifcrypto.ChiperAES256 {
+		return types.DataKeySpecAes256, nil
+	}
+
+	return "", fmt.Errorf("cipher: %s is not supported as data key by AWS KMS", cipher)
+}
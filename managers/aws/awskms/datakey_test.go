@@ -0,0 +1,69 @@
+package awskms
+
+import (
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ifcrypto.DataKeyGenerator = &AwsKms{}
+
+func TestGenerateDataKey(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewSymmetricKey("aes-key", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(key)
+
+	km := &AwsKms{}
+	ec := coremodel.Meta{Name: coremodel.MetaEncryptionContext, Value: map[string]string{"tenant": "a"}}
+
+	plaintext, encrypted, err := km.GenerateDataKey(c, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Len(t, plaintext, 32)
+
+	req := fake.lastRequest("GenerateDataKey")
+	assert.Equal(t, "AES_256", req.Body["KeySpec"])
+	assert.Equal(t, map[string]interface{}{"tenant": "a"}, req.Body["EncryptionContext"])
+
+	decrypted, err := km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	encrypted, err = km.GenerateDataKeyWithoutPlaintext(c, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.NotEmpty(t, encrypted)
+
+	_, _, err = km.GenerateDataKey(c, key, ifcrypto.ChiperRsaOaepSha256)
+	assert.Error(t, err)
+
+}
+
+func TestEnvelopeUsingAwsKms(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewSymmetricKey("aes-key", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(key)
+
+	km := &AwsKms{}
+	env := gocrypto.NewEnvelope(km, km)
+
+	payload := make([]byte, 64*1024)
+
+	blob, err := env.Encrypt(c, payload, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+
+	decrypted, err := env.Decrypt(c, blob, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decrypted)
+
+}
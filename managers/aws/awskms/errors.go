@@ -0,0 +1,47 @@
+package awskms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+)
+
+// mapError maps _AWS KMS_ errors onto the errors declared in `ifkms` and `ifcrypto`.
+//
+// The returned error wraps the well known error and keeps the _AWS KMS_ message. If
+// not a well known error, the _err_ is returned as is.
+func mapError(err error) error {
+
+	var (
+		notFound         *types.NotFoundException
+		disabled         *types.DisabledException
+		invalidState     *types.KMSInvalidStateException
+		invalidUsage     *types.InvalidKeyUsageException
+		invalidSignature *types.KMSInvalidSignatureException
+		invalidMac       *types.KMSInvalidMacException
+		invalidCipher    *types.InvalidCiphertextException
+		incorrectKey     *types.IncorrectKeyException
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		return fmt.Errorf("%w: %s", ifkms.ErrKeyNotFound, err.Error())
+	case errors.As(err, &disabled):
+		return fmt.Errorf("%w: %s", ifkms.ErrKeyDisabled, err.Error())
+	case errors.As(err, &invalidState):
+		return fmt.Errorf("%w: %s", ifkms.ErrInvalidKeyState, err.Error())
+	case errors.As(err, &invalidUsage):
+		return fmt.Errorf("%w: %s", ifkms.ErrInvalidKeyUsage, err.Error())
+	case errors.As(err, &invalidSignature):
+		return fmt.Errorf("%w: %s", ifcrypto.ErrInvalidSignature, err.Error())
+	case errors.As(err, &invalidMac):
+		return fmt.Errorf("%w: %s", ifcrypto.ErrInvalidMac, err.Error())
+	case errors.As(err, &invalidCipher), errors.As(err, &incorrectKey):
+		return fmt.Errorf("%w: %s", ifkms.ErrInvalidCiphertext, err.Error())
+	}
+
+	return err
+}
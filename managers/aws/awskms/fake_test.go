@@ -0,0 +1,778 @@
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+)
+
+// fakeKms is a local _HTTP_ stand-in for the _AWS KMS_ endpoint.
+//
+// It implements the operations used by `AwsKms` using in memory `gocrypto` keys
+// and records all requests so the tests may assert on what was sent.
+type fakeKms struct {
+	mu       sync.Mutex
+	keys     map[string]ifcrypto.Key
+	disabled map[string]bool
+	requests []fakeRequest
+}
+
+// fakeRequest is a recorded request, the _Body_ is the decoded _JSON_ body.
+type fakeRequest struct {
+	Operation string
+	Body      map[string]interface{}
+}
+
+// fakeError is rendered as a _AWS KMS_ error response.
+type fakeError struct {
+	Type    string
+	Message string
+}
+
+func (e *fakeError) Error() string {
+	return e.Type + ": " + e.Message
+}
+
+// newFakeKms starts a new `fakeKms` and returns it along with a `ifctx.ServiceContext`
+// that is configured to use it.
+func newFakeKms(t *testing.T) (*fakeKms, ifctx.ServiceContext) {
+
+	f := &fakeKms{keys: map[string]ifcrypto.Key{}, disabled: map[string]bool{}}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	cfg := &aws.Config{
+		Region: "eu-west-1",
+		Credentials: aws.CredentialsProviderFunc(
+			func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+			},
+		),
+		EndpointResolver: aws.EndpointResolverFunc(
+			func(service, region string) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: srv.URL}, nil
+			},
+		),
+		HTTPClient: srv.Client(),
+		Retryer:    func() aws.Retryer { return aws.NopRetryer{} },
+	}
+
+	return f, ctx.NewServiceContext(nil, map[ifctx.ConfigType]interface{}{ifctx.ConfigAWS: cfg})
+}
+
+// addKey makes the _key_ available using its `ifcrypto.Key.GetID`.
+func (f *fakeKms) addKey(key ifcrypto.Key) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.keys[key.GetID()] = key
+
+}
+
+// disableKey makes all operations on the key with _id_ fail with a _DisabledException_.
+func (f *fakeKms) disableKey(id string) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.disabled[id] = true
+
+}
+
+// lastRequest returns the last recorded request of _operation_.
+func (f *fakeKms) lastRequest(operation string) fakeRequest {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.requests) - 1; i >= 0; i-- {
+
+		if f.requests[i].Operation == operation {
+			return f.requests[i]
+		}
+
+	}
+
+	return fakeRequest{}
+}
+
+func (f *fakeKms) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "TrentService.")
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.writeError(w, &fakeError{Type: "ValidationException", Message: err.Error()})
+		return
+	}
+
+	body := map[string]interface{}{}
+	_ = json.Unmarshal(data, &body)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, fakeRequest{Operation: operation, Body: body})
+	f.mu.Unlock()
+
+	var out interface{}
+
+	switch operation {
+	case "Sign":
+		out, err = f.sign(data)
+	case "Verify":
+		out, err = f.verify(data)
+	case "Encrypt":
+		out, err = f.encrypt(data)
+	case "Decrypt":
+		out, err = f.decrypt(data)
+	case "CreateKey":
+		out, err = f.createKey(data)
+	case "DescribeKey":
+		out, err = f.describeKey(data)
+	case "GetPublicKey":
+		out, err = f.getPublicKey(data)
+	case "GenerateMac":
+		out, err = f.generateMac(data)
+	case "VerifyMac":
+		out, err = f.verifyMac(data)
+	case "GenerateDataKey":
+		out, err = f.generateDataKey(data, true)
+	case "GenerateDataKeyWithoutPlaintext":
+		out, err = f.generateDataKey(data, false)
+	default:
+		err = &fakeError{Type: "UnsupportedOperationException", Message: operation}
+	}
+
+	if err != nil {
+		f.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(out)
+
+}
+
+func (f *fakeKms) writeError(w http.ResponseWriter, err error) {
+
+	fe, ok := err.(*fakeError)
+
+	if !ok {
+		fe = &fakeError{Type: "KMSInternalException", Message: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Header().Set("X-Amzn-ErrorType", fe.Type)
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"__type": fe.Type, "message": fe.Message})
+
+}
+
+func (f *fakeKms) key(id string) (ifcrypto.Key, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id = strings.TrimPrefix(id, fakeArnPrefix)
+
+	if f.disabled[id] {
+		return nil, &fakeError{Type: "DisabledException", Message: fmt.Sprintf("key: %s is disabled", id)}
+	}
+
+	if key, ok := f.keys[id]; ok {
+		return key, nil
+	}
+
+	return nil, &fakeError{Type: "NotFoundException", Message: fmt.Sprintf("key: %s not found", id)}
+}
+
+type fakeSignInput struct {
+	KeyId            string
+	Message          []byte
+	MessageType      string
+	Signature        []byte
+	SigningAlgorithm string
+	GrantTokens      []string
+}
+
+func (f *fakeKms) sign(data []byte) (interface{}, error) {
+
+	var in fakeSignInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, pss, err := fakeSigningAlgorithm(in.SigningAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := fakeDigest(hash, in.MessageType, in.Message)
+
+	var signature []byte
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PrivateKey:
+
+		if pss {
+			signature, err = rsa.SignPSS(rand.Reader, k, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+
+	case *ecdsa.PrivateKey:
+		signature, err = key.(*gocrypto.ECDSAPrivateKey).Sign(rand.Reader, digest, hash)
+	default:
+		return nil, &fakeError{Type: "InvalidKeyUsageException", Message: "not a signing key"}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"KeyId":            in.KeyId,
+		"Signature":        signature,
+		"SigningAlgorithm": in.SigningAlgorithm,
+	}, nil
+}
+
+func (f *fakeKms) verify(data []byte) (interface{}, error) {
+
+	var in fakeSignInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, pss, err := fakeSigningAlgorithm(in.SigningAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := fakeDigest(hash, in.MessageType, in.Message)
+	valid := false
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PrivateKey:
+
+		if pss {
+			valid = rsa.VerifyPSS(&k.PublicKey, hash, digest, in.Signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		} else {
+			valid = rsa.VerifyPKCS1v15(&k.PublicKey, hash, digest, in.Signature) == nil
+		}
+
+	case *ecdsa.PrivateKey:
+		valid = ecdsa.VerifyASN1(&k.PublicKey, digest, in.Signature)
+	}
+
+	if !valid {
+		return nil, &fakeError{Type: "KMSInvalidSignatureException", Message: "signature is not valid"}
+	}
+
+	return map[string]interface{}{
+		"KeyId":            in.KeyId,
+		"SignatureValid":   true,
+		"SigningAlgorithm": in.SigningAlgorithm,
+	}, nil
+}
+
+// fakeSigningAlgorithm returns the hash and if _RSASSA-PSS_ is used for the _AWS KMS_ _alg_.
+func fakeSigningAlgorithm(alg string) (crypto.Hash, bool, error) {
+
+	var hash crypto.Hash
+
+	switch {
+	case strings.HasSuffix(alg, "SHA_256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(alg, "SHA_384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(alg, "SHA_512"):
+		hash = crypto.SHA512
+	default:
+		return 0, false, &fakeError{Type: "ValidationException", Message: "unknown algorithm: " + alg}
+	}
+
+	return hash, strings.HasPrefix(alg, "RSASSA_PSS"), nil
+}
+
+// fakeDigest hashes the _msg_ if _messageType_ is not a digest.
+func fakeDigest(hash crypto.Hash, messageType string, msg []byte) []byte {
+
+	if messageType == "DIGEST" {
+		return msg
+	}
+
+	h := hash.New()
+	h.Write(msg)
+
+	return h.Sum(nil)
+}
+
+type fakeCipherInput struct {
+	KeyId               string
+	Plaintext           []byte
+	CiphertextBlob      []byte
+	EncryptionAlgorithm string
+	EncryptionContext   map[string]string
+	GrantTokens         []string
+}
+
+func (f *fakeKms) encrypt(data []byte) (interface{}, error) {
+
+	var in fakeCipherInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := fakeCipher(in.EncryptionAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if kp, ok := key.(ifcrypto.KeyPair); ok {
+		key = kp.GetPublic()
+	}
+
+	blob, err := gocrypto.NewCipher().Encrypt(nil, in.Plaintext, key, cipher, fakeEncryptionContext(in.EncryptionContext)...)
+	if err != nil {
+		return nil, &fakeError{Type: "InvalidKeyUsageException", Message: err.Error()}
+	}
+
+	return map[string]interface{}{
+		"KeyId":               in.KeyId,
+		"CiphertextBlob":      blob,
+		"EncryptionAlgorithm": in.EncryptionAlgorithm,
+	}, nil
+}
+
+func (f *fakeKms) decrypt(data []byte) (interface{}, error) {
+
+	var in fakeCipherInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := fakeCipher(in.EncryptionAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gocrypto.NewCipher().Decrypt(nil, in.CiphertextBlob, key, cipher, fakeEncryptionContext(in.EncryptionContext)...)
+	if err != nil {
+		return nil, &fakeError{Type: "InvalidCiphertextException", Message: err.Error()}
+	}
+
+	return map[string]interface{}{
+		"KeyId":               in.KeyId,
+		"Plaintext":           plaintext,
+		"EncryptionAlgorithm": in.EncryptionAlgorithm,
+	}, nil
+}
+
+// fakeCipher maps the _AWS KMS_ encryption algorithm onto a `ifcrypto.Chipher`.
+func fakeCipher(alg string) (ifcrypto.Chipher, error) {
+
+	switch alg {
+	case "", "SYMMETRIC_DEFAULT":
+		return ifcrypto.ChiperAES256, nil
+	case "RSAES_OAEP_SHA_1":
+		return ifcrypto.ChiperRsaOaepSha1, nil
+	case "RSAES_OAEP_SHA_256":
+		return ifcrypto.ChiperRsaOaepSha256, nil
+	}
+
+	return "", &fakeError{Type: "ValidationException", Message: "unknown algorithm: " + alg}
+}
+
+// fakeEncryptionContext renders the _ec_ as `coremodel.Meta`.
+func fakeEncryptionContext(ec map[string]string) []coremodel.Meta {
+
+	if len(ec) == 0 {
+		return nil
+	}
+
+	return []coremodel.Meta{{Name: coremodel.MetaEncryptionContext, Value: ec}}
+}
+
+// fakeArnPrefix is prepended to the key id to form the _ARN_ of a key.
+const fakeArnPrefix = "arn:aws:kms:eu-west-1:111122223333:key/"
+
+type fakeKeyInput struct {
+	KeyId       string
+	GrantTokens []string
+}
+
+func (f *fakeKms) describeKey(data []byte) (interface{}, error) {
+
+	var in fakeKeyInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := fakeKeyMetadata(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"KeyMetadata": meta}, nil
+}
+
+type fakeCreateKeyInput struct {
+	KeySpec     string
+	KeyUsage    string
+	Description string
+	Tags        []struct{ TagKey, TagValue string }
+}
+
+func (f *fakeKms) createKey(data []byte) (interface{}, error) {
+
+	var in fakeCreateKeyInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	var (
+		keyType ifcrypto.KeyType
+		keySize int
+	)
+
+	switch {
+	case in.KeySpec == "SYMMETRIC_DEFAULT":
+		keyType, keySize = ifcrypto.KeyTypeSymmetric, 256
+	case in.KeySpec == "ECC_SECG_P256K1":
+		keyType, keySize = ifcrypto.KeyTypeEccSecgP256k1, 256
+	case strings.HasPrefix(in.KeySpec, "RSA_"):
+		keyType = ifcrypto.KeyTypeRsa
+		fmt.Sscanf(in.KeySpec, "RSA_%d", &keySize)
+	case strings.HasPrefix(in.KeySpec, "ECC_NIST_P"):
+		keyType = ifcrypto.KeyTypeEccNistP
+		fmt.Sscanf(in.KeySpec, "ECC_NIST_P%d", &keySize)
+	case strings.HasPrefix(in.KeySpec, "HMAC_"):
+		keyType = ifcrypto.KeyTypeSymmetric
+		fmt.Sscanf(in.KeySpec, "HMAC_%d", &keySize)
+	default:
+		return nil, &fakeError{Type: "ValidationException", Message: "unknown key spec: " + in.KeySpec}
+	}
+
+	usage := []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt}
+
+	switch in.KeyUsage {
+	case "SIGN_VERIFY":
+		usage = []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+	case "GENERATE_VERIFY_MAC":
+		usage = []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}
+	}
+
+	key, err := gocrypto.GenerateKey(utils.NewUUID(), keyType, keySize, usage...)
+	if err != nil {
+		return nil, &fakeError{Type: "ValidationException", Message: err.Error()}
+	}
+
+	f.addKey(key)
+
+	meta, err := fakeKeyMetadata(key)
+	if err != nil {
+		return nil, err
+	}
+
+	meta["Description"] = in.Description
+
+	return map[string]interface{}{"KeyMetadata": meta}, nil
+}
+
+// fakeKeyMetadata renders the _AWS KMS_ key metadata of _key_.
+func fakeKeyMetadata(key ifcrypto.Key) (map[string]interface{}, error) {
+
+	spec, err := fakeKeySpec(key)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := map[string]interface{}{
+		"KeyId":                 key.GetID(),
+		"Arn":                   fakeArnPrefix + key.GetID(),
+		"KeySpec":               spec,
+		"CustomerMasterKeySpec": spec,
+		"KeyUsage":              fakeKeyUsage(key),
+		"KeyState":              "Enabled",
+		"Enabled":               true,
+	}
+
+	if algs := fakeEncryptionAlgorithms(key); len(algs) > 0 {
+		meta["EncryptionAlgorithms"] = algs
+	}
+
+	if alg, ok := fakeMacAlgorithm(key); ok {
+		meta["MacAlgorithms"] = []string{alg}
+	}
+
+	return meta, nil
+}
+
+func (f *fakeKms) getPublicKey(data []byte) (interface{}, error) {
+
+	var in fakeKeyInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	kp, ok := key.(ifcrypto.KeyPair)
+	if !ok {
+		return nil, &fakeError{Type: "UnsupportedOperationException", Message: "not a asymmetric key"}
+	}
+
+	var der []byte
+
+	if pub, ok := kp.GetPublic().GetKey().(*ecdsa.PublicKey); ok {
+		der, err = cryptoutils.MarshalPKIXECPublicKey(pub)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(kp.GetPublic().GetKey())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := fakeKeySpec(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"KeyId":                 fakeArnPrefix + key.GetID(),
+		"PublicKey":             der,
+		"KeySpec":               spec,
+		"CustomerMasterKeySpec": spec,
+		"KeyUsage":              fakeKeyUsage(key),
+	}, nil
+}
+
+// fakeKeySpec renders the _AWS KMS_ key spec of _key_.
+func fakeKeySpec(key ifcrypto.Key) (string, error) {
+
+	switch key.GetKeyType() {
+	case ifcrypto.KeyTypeSymmetric:
+
+		if key.CanMac(ifcrypto.MacAlgorithmHmacSha256) {
+			return fmt.Sprintf("HMAC_%d", key.GetKeySize()), nil
+		}
+
+		return "SYMMETRIC_DEFAULT", nil
+	case ifcrypto.KeyTypeRsa:
+		return fmt.Sprintf("RSA_%d", key.GetKeySize()), nil
+	case ifcrypto.KeyTypeEccNistP:
+		return fmt.Sprintf("ECC_NIST_P%d", key.GetKeySize()), nil
+	case ifcrypto.KeyTypeEccSecgP256k1:
+		return "ECC_SECG_P256K1", nil
+	}
+
+	return "", &fakeError{Type: "UnsupportedOperationException", Message: "unknown key type"}
+}
+
+// fakeKeyUsage renders the _AWS KMS_ key usage of _key_.
+func fakeKeyUsage(key ifcrypto.Key) string {
+
+	for _, u := range key.GetKeyUsage() {
+
+		switch u {
+		case ifcrypto.KeyUsageSign:
+			return "SIGN_VERIFY"
+		case ifcrypto.KeyUsageMac:
+			return "GENERATE_VERIFY_MAC"
+		}
+
+	}
+
+	return "ENCRYPT_DECRYPT"
+}
+
+// fakeEncryptionAlgorithms renders the _AWS KMS_ encryption algorithms supported by _key_.
+func fakeEncryptionAlgorithms(key ifcrypto.Key) []string {
+
+	var algs []string
+
+	if key.CanDecrypt(ifcrypto.ChiperAES256) {
+		algs = append(algs, "SYMMETRIC_DEFAULT")
+	}
+
+	if key.CanDecrypt(ifcrypto.ChiperRsaOaepSha1) {
+		algs = append(algs, "RSAES_OAEP_SHA_1", "RSAES_OAEP_SHA_256")
+	}
+
+	return algs
+}
+
+// fakeMacAlgorithm renders the _AWS KMS_ mac algorithm of a _HMAC_ _key_, it is the same size as the key.
+func fakeMacAlgorithm(key ifcrypto.Key) (string, bool) {
+
+	if !key.CanMac(ifcrypto.MacAlgorithmHmacSha256) {
+		return "", false
+	}
+
+	return fmt.Sprintf("HMAC_SHA_%d", key.GetKeySize()), true
+}
+
+type fakeMacInput struct {
+	KeyId        string
+	Message      []byte
+	Mac          []byte
+	MacAlgorithm string
+	GrantTokens  []string
+}
+
+func (f *fakeKms) macInput(data []byte) (*fakeMacInput, ifcrypto.Key, ifcrypto.MacAlgorithm, error) {
+
+	var in fakeMacInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, nil, "", err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if expected, ok := fakeMacAlgorithm(key); !ok || expected != in.MacAlgorithm {
+		return nil, nil, "", &fakeError{Type: "InvalidKeyUsageException", Message: "invalid mac algorithm: " + in.MacAlgorithm}
+	}
+
+	var size int
+	fmt.Sscanf(in.MacAlgorithm, "HMAC_SHA_%d", &size)
+
+	return &in, key, ifcrypto.MacAlgorithm(fmt.Sprintf("hmac-sha%d", size)), nil
+}
+
+func (f *fakeKms) generateMac(data []byte) (interface{}, error) {
+
+	in, key, alg, err := f.macInput(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := gocrypto.NewMac().GenerateMac(nil, in.Message, key, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"KeyId":        fakeArnPrefix + key.GetID(),
+		"Mac":          mac,
+		"MacAlgorithm": in.MacAlgorithm,
+	}, nil
+}
+
+func (f *fakeKms) verifyMac(data []byte) (interface{}, error) {
+
+	in, key, alg, err := f.macInput(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gocrypto.NewMac().VerifyMac(nil, in.Message, in.Mac, key, alg); err != nil {
+		return nil, &fakeError{Type: "KMSInvalidMacException", Message: err.Error()}
+	}
+
+	return map[string]interface{}{
+		"KeyId":        fakeArnPrefix + key.GetID(),
+		"MacValid":     true,
+		"MacAlgorithm": in.MacAlgorithm,
+	}, nil
+}
+
+type fakeDataKeyInput struct {
+	KeyId             string
+	KeySpec           string
+	EncryptionContext map[string]string
+	GrantTokens       []string
+}
+
+func (f *fakeKms) generateDataKey(data []byte, withPlaintext bool) (interface{}, error) {
+
+	var in fakeDataKeyInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	key, err := f.key(in.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.KeySpec != "AES_256" {
+		return nil, &fakeError{Type: "ValidationException", Message: "unknown key spec: " + in.KeySpec}
+	}
+
+	plaintext := make([]byte, 32)
+
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+
+	blob, err := gocrypto.NewCipher().Encrypt(nil, plaintext, key, ifcrypto.ChiperAES256, fakeEncryptionContext(in.EncryptionContext)...)
+	if err != nil {
+		return nil, &fakeError{Type: "InvalidKeyUsageException", Message: err.Error()}
+	}
+
+	out := map[string]interface{}{
+		"KeyId":          in.KeyId,
+		"CiphertextBlob": blob,
+	}
+
+	if withPlaintext {
+		out["Plaintext"] = plaintext
+	}
+
+	return out, nil
+}
@@ -0,0 +1,154 @@
+package awskms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/managers/go/gojose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWSSignedInKmsVerifiedFromJWK(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecKey, err := gocrypto.NewECDSAPrivateKey("ec-key", 384, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(rsaKey)
+	fake.addKey(ecKey)
+
+	km := &AwsKms{}
+
+	tests := []struct {
+		id  string
+		alg ifcrypto.SignAlgorithm
+	}{
+		{"rsa-key", ifcrypto.SignAlgorithmRsaPssSha256},
+		{"ec-key", ifcrypto.SignAlgorithmEcdSha384},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(tt.id, func(t *testing.T) {
+
+			key, err := km.LoadKey(c, tt.id)
+			require.NoError(t, err)
+
+			token, err := gojose.NewJWS(km, km).SignCompact(c, []byte("hello world"), key, tt.alg, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "RAW", fake.lastRequest("Sign").Body["MessageType"])
+
+			jwk, err := gojose.NewJWKFromKey(key, "")
+			require.NoError(t, err)
+			assert.False(t, jwk.IsPrivate())
+			assert.Equal(t, key.GetID(), jwk.KeyID)
+
+			data, err := json.Marshal(gojose.NewJWKSet(jwk))
+			require.NoError(t, err)
+
+			set, err := gojose.ParseJWKSet(data)
+			require.NoError(t, err)
+
+			public, err := set.Keys[0].Key()
+			require.NoError(t, err)
+
+			signer := gocrypto.NewSigner()
+
+			payload, err := gojose.NewJWS(nil, signer).VerifyCompact(c, token, public, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(payload))
+
+			_, err = gojose.NewJWS(nil, km).VerifyCompact(c, token, key, nil)
+			require.NoError(t, err)
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("forged")) + "." + parts[2]
+
+			_, err = gojose.NewJWS(nil, km).VerifyCompact(c, tampered, key, nil)
+			assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature), "got: %v", err)
+
+		})
+
+	}
+
+}
+
+func TestJWTIssuedWithKmsKey(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewECDSAPrivateKey("jwt-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "jwt-key")
+	require.NoError(t, err)
+
+	keys := gojose.NewJWTKeySet()
+	require.NoError(t, keys.Rotate(key, ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	token, err := gojose.NewJWTIssuer(km, keys).WithTTL(time.Hour).Issue(c, &gojose.JWTClaims{Subject: "device"})
+	require.NoError(t, err)
+	assert.Equal(t, key.GetID(), fake.lastRequest("Sign").Body["KeyId"])
+
+	set, err := keys.JWKSet(time.Now())
+	require.NoError(t, err)
+
+	published := gojose.NewJWTKeySet()
+	require.NoError(t, published.AddJWKSet(set))
+
+	claims, err := gojose.NewJWTValidator(gocrypto.NewSigner(), published).Validate(c, token)
+	require.NoError(t, err)
+	assert.Equal(t, "device", claims.Subject)
+
+}
+
+func TestJWEDecryptedInKms(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewRSAPrivateKey("jwe-key", 2048, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "jwe-key")
+	require.NoError(t, err)
+
+	jwk, err := gojose.NewJWKFromKey(key, gojose.AlgorithmRsaOaep256)
+	require.NoError(t, err)
+
+	public, err := jwk.Key()
+	require.NoError(t, err)
+
+	token, err := gojose.NewJWE(gocrypto.NewCipher(), nil).EncryptCompact(
+		c, []byte("top secret"), public, gojose.AlgorithmRsaOaep256, nil,
+	)
+	require.NoError(t, err)
+
+	plaintext, hdr, err := gojose.NewJWE(km, nil).DecryptCompact(c, token, key)
+	require.NoError(t, err)
+	assert.Equal(t, "top secret", string(plaintext))
+	assert.Equal(t, key.GetID(), hdr.KeyID)
+
+	req := fake.lastRequest("Decrypt")
+	assert.Equal(t, key.GetID(), req.Body["KeyId"])
+	assert.Equal(t, "RSAES_OAEP_SHA_256", req.Body["EncryptionAlgorithm"])
+
+}
@@ -0,0 +1,195 @@
+package awskms
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// GenerateKey implements the `ifcrypto.KeyGenerator` interface using _CreateKey_.
+//
+// The key is either used for sign / verify, encrypt / decrypt or, if symmetric, generate / verify mac.
+// It is not possible to mix since _AWS KMS_ do not support it. The _ID_ of the _spec_ is ignored since _AWS KMS_ assigns the id,
+// the returned `*KmsKey` has the _ARN_ as id. The _Tags_ values are rendered as strings.
+//
+// The returned key is bound to _c_ and _meta_, see `LoadKey`.
+func (km *AwsKms) GenerateKey(
+	c ifctx.ServiceContext,
+	spec ifcrypto.KeySpec,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	if err := ifcrypto.ValidateKeySize(spec.KeyType, spec.KeySize); err != nil {
+		return nil, err
+	}
+
+	usage, err := keyUsageTypeFromUsage(spec.Usage)
+	if err != nil {
+		return nil, err
+	}
+
+	keySpec, err := keySpecFromType(spec.KeyType, spec.KeySize, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateChiphers(spec.KeyType, usage, spec.Chiphers); err != nil {
+		return nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]types.Tag, 0, len(spec.Tags))
+
+	for _, tag := range spec.Tags {
+
+		tags = append(tags, types.Tag{
+			TagKey:   utils.ToStringPtr(tag.Name),
+			TagValue: utils.ToStringPtr(fmt.Sprint(tag.Value)),
+		})
+
+	}
+
+	out, err := client.CreateKey(c, &kms.CreateKeyInput{
+		KeySpec:     keySpec,
+		KeyUsage:    usage,
+		Description: utils.ToStringPtrNil(spec.Description),
+		Tags:        tags,
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	key, err := km.keyFromMetadata(c, client, out.KeyMetadata, "", meta...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.Chiphers) > 0 {
+
+		key.KeyBase = gocrypto.NewKeyBase(
+			key.GetID(), key.GetKeyType(), key.GetKeySize(), key.GetKeyUsage(), spec.Chiphers,
+		)
+
+	}
+
+	return key, nil
+}
+
+// keySpecFromType maps the _keyType_ and _keySize_ onto a _AWS KMS_ key spec.
+//
+// Symmetric keys with _usage_ `types.KeyUsageTypeGenerateVerifyMac` are mapped onto the _HMAC_ key specs.
+func keySpecFromType(
+	keyType ifcrypto.KeyType,
+	keySize int,
+	usage types.KeyUsageType,
+) (types.KeySpec, error) {
+
+	switch keyType {
+	case ifcrypto.KeyTypeRsa:
+		return types.KeySpec(fmt.Sprintf("RSA_%d", keySize)), nil
+	case ifcrypto.KeyTypeEccNistP:
+		return types.KeySpec(fmt.Sprintf("ECC_NIST_P%d", keySize)), nil
+	case ifcrypto.KeyTypeEccSecgP256k1:
+		return types.KeySpecEccSecgP256k1, nil
+	case ifcrypto.KeyTypeSymmetric:
+
+		if usage == types.KeyUsageTypeGenerateVerifyMac {
+
+			switch keySize {
+			case 224, 256, 384, 512:
+				return types.KeySpec(fmt.Sprintf("HMAC_%d", keySize)), nil
+			}
+
+			return "", fmt.Errorf(
+				"%w: AWS KMS only supports 224, 256, 384 and 512 bit HMAC keys", ifcrypto.ErrUnsupportedKeySize,
+			)
+
+		}
+
+		if keySize == 256 {
+			return types.KeySpecSymmetricDefault, nil
+		}
+
+		return "", fmt.Errorf("%w: AWS KMS only supports 256 bit symmetric keys", ifcrypto.ErrUnsupportedKeySize)
+
+	}
+
+	return "", fmt.Errorf("%w: %s is not supported by AWS KMS", ifcrypto.ErrUnsupportedKeyType, keyType)
+}
+
+// keyUsageTypeFromUsage maps the _usage_ onto the _AWS KMS_ key usage.
+func keyUsageTypeFromUsage(usage []ifcrypto.KeyUsage) (types.KeyUsageType, error) {
+
+	var keyUsage types.KeyUsageType
+
+	for _, u := range usage {
+
+		var t types.KeyUsageType
+
+		switch u {
+		case ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify:
+			t = types.KeyUsageTypeSignVerify
+		case ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt:
+			t = types.KeyUsageTypeEncryptDecrypt
+		case ifcrypto.KeyUsageMac:
+			t = types.KeyUsageTypeGenerateVerifyMac
+		default:
+			return "", fmt.Errorf("key usage: %s is not supported by AWS KMS", u)
+		}
+
+		if keyUsage != "" && keyUsage != t {
+			return "", fmt.Errorf("AWS KMS keys may either sign / verify, encrypt / decrypt or generate / verify mac")
+		}
+
+		keyUsage = t
+
+	}
+
+	if keyUsage == "" {
+		return "", fmt.Errorf("must specify at least one key usage")
+	}
+
+	return keyUsage, nil
+}
+
+// validateChiphers ensures that _AWS KMS_ supports the _chiphers_ for the _keyType_ and _usage_.
+func validateChiphers(
+	keyType ifcrypto.KeyType,
+	usage types.KeyUsageType,
+	chiphers []ifcrypto.Chipher,
+) error {
+
+	for _, c := range chiphers {
+
+		supported := false
+
+		if usage == types.KeyUsageTypeEncryptDecrypt {
+
+			switch keyType {
+			case ifcrypto.KeyTypeSymmetric:
+				supported = c == ifcrypto.ChiperAES256
+			case ifcrypto.KeyTypeRsa:
+				supported = c == ifcrypto.ChiperRsaOaepSha1 || c == ifcrypto.ChiperRsaOaepSha256
+			}
+
+		}
+
+		if !supported {
+			return fmt.Errorf("cipher: %s is not supported by AWS KMS for key type: %s", c, keyType)
+		}
+
+	}
+
+	return nil
+}
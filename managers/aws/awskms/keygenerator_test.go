@@ -0,0 +1,113 @@
+package awskms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ifcrypto.KeyGenerator = &AwsKms{}
+
+func TestGenerateKeyUsingCreateKey(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	key, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType:     ifcrypto.KeyTypeEccNistP,
+		KeySize:     384,
+		Usage:       []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify},
+		Description: "signing key",
+		Tags:        []coremodel.Tag{{Name: "team", Value: "payments"}, {Name: "version", Value: 2}},
+	})
+	require.NoError(t, err)
+
+	req := fake.lastRequest("CreateKey")
+	assert.Equal(t, "ECC_NIST_P384", req.Body["KeySpec"])
+	assert.Equal(t, "SIGN_VERIFY", req.Body["KeyUsage"])
+	assert.Equal(t, "signing key", req.Body["Description"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"TagKey": "team", "TagValue": "payments"},
+		map[string]interface{}{"TagKey": "version", "TagValue": "2"},
+	}, req.Body["Tags"])
+
+	kp, ok := key.(ifcrypto.KeyPair)
+	require.True(t, ok)
+	assert.Equal(t, ifcrypto.KeyTypeEccNistP, kp.GetKeyType())
+	assert.Equal(t, 384, kp.GetKeySize())
+	assert.NotNil(t, kp.GetPublic())
+
+	signature, err := km.Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEcdSha384)
+	require.NoError(t, err)
+	assert.NoError(t, km.Verify(c, []byte("msg"), signature, key, ifcrypto.SignAlgorithmEcdSha384))
+
+}
+
+func TestGenerateKeyRestrictsChiphers(t *testing.T) {
+
+	_, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	key, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType:  ifcrypto.KeyTypeRsa,
+		KeySize:  2048,
+		Usage:    []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt},
+		Chiphers: []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256}, key.GetSupportedChiphers())
+	assert.False(t, key.CanEncrypt(ifcrypto.ChiperRsaOaepSha1))
+
+	symmetric, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 256,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt},
+	})
+	require.NoError(t, err)
+	assert.True(t, symmetric.IsSymmetric())
+	assert.True(t, symmetric.CanEncrypt(ifcrypto.ChiperAES256))
+
+}
+
+func TestGenerateKeyValidatesSpec(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	tests := []ifcrypto.KeySpec{
+		{KeyType: ifcrypto.KeyTypeRsa, KeySize: 1024, Usage: []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign}},
+		{KeyType: ifcrypto.KeyTypeEd25519, KeySize: 256, Usage: []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign}},
+		{KeyType: ifcrypto.KeyTypeSymmetric, KeySize: 128, Usage: []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt}},
+		{KeyType: ifcrypto.KeyTypeRsa, KeySize: 2048},
+		{
+			KeyType: ifcrypto.KeyTypeRsa, KeySize: 2048,
+			Usage: []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageEncrypt},
+		},
+		{
+			KeyType: ifcrypto.KeyTypeRsa, KeySize: 2048,
+			Usage:    []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt},
+			Chiphers: []ifcrypto.Chipher{ifcrypto.ChiperRsaPkcs1V15},
+		},
+	}
+
+	for _, spec := range tests {
+
+		_, err := km.GenerateKey(c, spec)
+		assert.Error(t, err, "%+v", spec)
+
+	}
+
+	assert.Equal(t, "", fake.lastRequest("CreateKey").Operation)
+
+	_, err := km.GenerateKey(c, tests[0])
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnsupportedKeySize))
+
+	_, err = km.GenerateKey(c, tests[1])
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnsupportedKeyType))
+
+}
@@ -1,42 +1,224 @@
 package awskms
 
 import (
+	_ "crypto/sha256" // register SHA-256 with crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 with crypto.Hash
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
 	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
 	"github.com/mariotoffia/goservice/model/coremodel"
 	"github.com/mariotoffia/goservice/utils"
 )
 
-// AwsKms implements xyz interfaces to use the
-// _AWS Key Management System_ as backing sign and crypto.
+// MaxMessageSize is the largest message, in bytes, that _AWS KMS_ accepts
+// as `types.MessageTypeRaw` when signing or verifying.
+//
+// Larger messages are hashed locally and sent as `types.MessageTypeDigest`.
+const MaxMessageSize = 4096
+
+// AwsKms implements the `ifcrypto.Signer`, `ifcrypto.Verifier`, `ifcrypto.Mac`, `ifcrypto.Cipherable` and
+// `ifcrypto.DataKeyGenerator` interfaces to use the _AWS Key Management System_ as backing sign and crypto.
+//
+// Any `coremodel.MetaGrantToken` passed as _tags_ is passed as _GrantTokens_ to _AWS KMS_.
 type AwsKms struct {
 }
 
-// Sign implements the `ifkms.Signer` interface
+// Sign implements the `ifcrypto.Signer` interface
+//
+// If the _msg_ is larger than `MaxMessageSize` it is hashed locally and only the
+// digest is sent to _AWS KMS_.
 func (km *AwsKms) Sign(
 	c ifctx.ServiceContext,
 	msg []byte,
 	key ifcrypto.Key,
 	signAlgorithm ifcrypto.SignAlgorithm,
 	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to sign with")
+	}
+
+	alg, err := signingAlgorithmSpec(signAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	message, messageType, err := messageForSigning(msg, signAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return signMessage(c, key.GetID(), message, messageType, alg, tags...)
+}
+
+// Verify implements the `ifcrypto.Verifier` interface.
+//
+// If the _key_ is a `*KmsKey` loaded using `LoadKey`, the signature is verified
+// locally using the in memory public key without calling _AWS KMS_.
+//
+// If the _msg_ is larger than `MaxMessageSize` it is hashed locally and only the
+// digest is sent to _AWS KMS_.
+func (km *AwsKms) Verify(
+	c ifctx.ServiceContext,
+	msg []byte,
+	signature []byte,
+	key ifcrypto.Key,
+	signAlgorithm ifcrypto.SignAlgorithm,
+	tags ...coremodel.Meta,
 ) error {
 
+	if key == nil {
+		return fmt.Errorf("must specify a key to verify with")
+	}
+
+	if kk, ok := key.(*KmsKey); ok && kk.public != nil {
+		return gocrypto.NewSigner().Verify(c, msg, signature, kk.public, signAlgorithm, tags...)
+	}
+
+	alg, err := signingAlgorithmSpec(signAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	message, messageType, err := messageForSigning(msg, signAlgorithm)
+	if err != nil {
+		return err
+	}
+
 	client, err := kmsClientFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	client.Sign(c, &kms.SignInput{
-		KeyId: utils.ToStringPtrNil(key.GetID()),
+	out, err := client.Verify(c, &kms.VerifyInput{
+		KeyId:            utils.ToStringPtrNil(key.GetID()),
+		Message:          message,
+		MessageType:      messageType,
+		Signature:        signature,
+		SigningAlgorithm: alg,
+		GrantTokens:      grantTokens(tags...),
 	})
 
+	if err != nil {
+		return mapError(err)
+	}
+
+	if !out.SignatureValid {
+		return ifcrypto.ErrInvalidSignature
+	}
+
 	return nil
 }
 
+// signMessage signs the _message_, or digest depending on _messageType_, using _AWS KMS_.
+func signMessage(
+	c ifctx.ServiceContext,
+	keyID string,
+	message []byte,
+	messageType types.MessageType,
+	alg types.SigningAlgorithmSpec,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.Sign(c, &kms.SignInput{
+		KeyId:            utils.ToStringPtrNil(keyID),
+		Message:          message,
+		MessageType:      messageType,
+		SigningAlgorithm: alg,
+		GrantTokens:      grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return out.Signature, nil
+}
+
+// signingAlgorithmSpec maps the _alg_ onto the _AWS KMS_ signing algorithm.
+func signingAlgorithmSpec(alg ifcrypto.SignAlgorithm) (types.SigningAlgorithmSpec, error) {
+
+	switch alg {
+	case ifcrypto.SignAlgorithmRsaPssSha256:
+		return types.SigningAlgorithmSpecRsassaPssSha256, nil
+	case ifcrypto.SignAlgorithmRsaPssSha384:
+		return types.SigningAlgorithmSpecRsassaPssSha384, nil
+	case ifcrypto.SignAlgorithmRsaPssSha512:
+		return types.SigningAlgorithmSpecRsassaPssSha512, nil
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha256:
+		return types.SigningAlgorithmSpecRsassaPkcs1V15Sha256, nil
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha384:
+		return types.SigningAlgorithmSpecRsassaPkcs1V15Sha384, nil
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha512:
+		return types.SigningAlgorithmSpecRsassaPkcs1V15Sha512, nil
+	case ifcrypto.SignAlgorithmEcdSha256:
+		return types.SigningAlgorithmSpecEcdsaSha256, nil
+	case ifcrypto.SignAlgorithmEcdSha384:
+		return types.SigningAlgorithmSpecEcdsaSha384, nil
+	case ifcrypto.SignAlgorithmEcdSha512:
+		return types.SigningAlgorithmSpecEcdsaSha512, nil
+	}
+
+	return "", fmt.Errorf("sign algorithm: %s is not supported by AWS KMS", alg)
+}
+
+// messageForSigning returns the _msg_ as is if it fits within `MaxMessageSize`,
+// otherwise it is hashed using the hash of _alg_ and `types.MessageTypeDigest` is
+// returned.
+func messageForSigning(
+	msg []byte,
+	alg ifcrypto.SignAlgorithm,
+) ([]byte, types.MessageType, error) {
+
+	if len(msg) <= MaxMessageSize {
+		return msg, types.MessageTypeRaw, nil
+	}
+
+	hash := alg.GetCryptoHash()
+
+	if hash == 0 || !hash.Available() {
+		return nil, "", fmt.Errorf("no hash available for sign algorithm: %s", alg)
+	}
+
+	h := hash.New()
+	h.Write(msg)
+
+	return h.Sum(nil), types.MessageTypeDigest, nil
+
+}
+
+// grantTokens collects all `coremodel.MetaGrantToken` in _tags_.
+//
+// The value may either be a `string` or a `[]string`.
+func grantTokens(tags ...coremodel.Meta) []string {
+
+	var tokens []string
+
+	for _, v := range coremodel.GetMetaValues(coremodel.MetaGrantToken, tags...) {
+
+		switch t := v.(type) {
+		case string:
+			tokens = append(tokens, t)
+		case []string:
+			tokens = append(tokens, t...)
+		}
+
+	}
+
+	return tokens
+}
+
 // kmsClientFromContext creates a new `*kms.Client` from context.
 func kmsClientFromContext(
 	c ifctx.ServiceContext,
@@ -1,10 +1,16 @@
 package awskms
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ahmetb/go-linq/v3"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToUseLinq(t *testing.T) {
@@ -14,3 +20,191 @@ func TestToUseLinq(t *testing.T) {
 	linq.From(arr)
 	assert.Equal(t, 4, len(arr))
 }
+
+func TestSignVerifyAllAlgorithms(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecdsaKey, err := gocrypto.NewECDSAPrivateKey("ecdsa-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(rsaKey)
+	fake.addKey(ecdsaKey)
+
+	tests := []struct {
+		alg  ifcrypto.SignAlgorithm
+		spec string
+		key  ifcrypto.KeyPair
+	}{
+		{ifcrypto.SignAlgorithmRsaPssSha256, "RSASSA_PSS_SHA_256", rsaKey},
+		{ifcrypto.SignAlgorithmRsaPssSha384, "RSASSA_PSS_SHA_384", rsaKey},
+		{ifcrypto.SignAlgorithmRsaPssSha512, "RSASSA_PSS_SHA_512", rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, "RSASSA_PKCS1_V1_5_SHA_256", rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha384, "RSASSA_PKCS1_V1_5_SHA_384", rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha512, "RSASSA_PKCS1_V1_5_SHA_512", rsaKey},
+		{ifcrypto.SignAlgorithmEcdSha256, "ECDSA_SHA_256", ecdsaKey},
+		{ifcrypto.SignAlgorithmEcdSha384, "ECDSA_SHA_384", ecdsaKey},
+		{ifcrypto.SignAlgorithmEcdSha512, "ECDSA_SHA_512", ecdsaKey},
+	}
+
+	km := &AwsKms{}
+	msg := []byte("hello world")
+
+	for _, tt := range tests {
+
+		t.Run(string(tt.alg), func(t *testing.T) {
+
+			signature, err := km.Sign(c, msg, tt.key, tt.alg)
+			require.NoError(t, err)
+
+			req := fake.lastRequest("Sign")
+			assert.Equal(t, tt.spec, req.Body["SigningAlgorithm"])
+			assert.Equal(t, "RAW", req.Body["MessageType"])
+
+			assert.NoError(t, km.Verify(c, msg, signature, tt.key, tt.alg))
+			assert.NoError(t, gocrypto.NewSigner().Verify(c, msg, signature, tt.key.GetPublic(), tt.alg))
+
+			err = km.Verify(c, []byte("tampered"), signature, tt.key, tt.alg)
+			assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+		})
+
+	}
+}
+
+func TestSignLargeMessageUsesDigest(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewECDSAPrivateKey("ecdsa-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(key)
+
+	km := &AwsKms{}
+	msg := make([]byte, MaxMessageSize+1)
+
+	signature, err := km.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	req := fake.lastRequest("Sign")
+	assert.Equal(t, "DIGEST", req.Body["MessageType"])
+
+	assert.NoError(t, km.Verify(c, msg, signature, key, ifcrypto.SignAlgorithmEcdSha256))
+	assert.Equal(t, "DIGEST", fake.lastRequest("Verify").Body["MessageType"])
+
+	assert.NoError(t, gocrypto.NewSigner().Verify(c, msg, signature, key, ifcrypto.SignAlgorithmEcdSha256))
+}
+
+func TestSignPassesGrantTokensAndMapsErrors(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewECDSAPrivateKey("ecdsa-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	km := &AwsKms{}
+
+	_, err = km.Sign(
+		c, []byte("msg"), key, ifcrypto.SignAlgorithmEcdSha256,
+		coremodel.Meta{Name: coremodel.MetaGrantToken, Value: "token-1"},
+		coremodel.Meta{Name: coremodel.MetaGrantToken, Value: []string{"token-2"}},
+	)
+
+	assert.True(t, errors.Is(err, ifkms.ErrKeyNotFound))
+
+	assert.Equal(
+		t, []interface{}{"token-1", "token-2"}, fake.lastRequest("Sign").Body["GrantTokens"],
+	)
+}
+
+func TestNilKeyIsRejected(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	_, err := km.Sign(c, []byte("msg"), nil, ifcrypto.SignAlgorithmEcdSha256)
+	assert.Error(t, err)
+
+	assert.Error(t, km.Verify(c, []byte("msg"), []byte("sig"), nil, ifcrypto.SignAlgorithmEcdSha256))
+
+	_, err = km.Encrypt(c, []byte("msg"), nil, ifcrypto.ChiperAES256)
+	assert.Error(t, err)
+
+	_, err = km.Decrypt(c, []byte("msg"), nil, ifcrypto.ChiperAES256)
+	assert.Error(t, err)
+
+	_, err = km.GenerateMac(c, []byte("msg"), nil, ifcrypto.MacAlgorithmHmacSha256)
+	assert.Error(t, err)
+
+	_, _, err = km.GenerateDataKey(c, nil, ifcrypto.ChiperAES256)
+	assert.Error(t, err)
+
+	assert.Equal(t, "", fake.lastRequest("Sign").Operation)
+	assert.Equal(t, "", fake.lastRequest("Encrypt").Operation)
+}
+
+func TestEncryptDecryptWithEncryptionContext(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewSymmetricKey("aes-key", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(key)
+
+	km := &AwsKms{}
+	ec := coremodel.Meta{Name: coremodel.MetaEncryptionContext, Value: map[string]string{"tenant": "a"}}
+
+	encrypted, err := km.Encrypt(c, []byte("secret"), key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+
+	req := fake.lastRequest("Encrypt")
+	assert.Equal(t, "SYMMETRIC_DEFAULT", req.Body["EncryptionAlgorithm"])
+	assert.Equal(t, map[string]interface{}{"tenant": "a"}, req.Body["EncryptionContext"])
+
+	plaintext, err := km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidCiphertext))
+
+	fake.disableKey(key.GetID())
+
+	_, err = km.Encrypt(c, []byte("secret"), key, ifcrypto.ChiperAES256, ec)
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+}
+
+func TestEncryptDecryptRSA(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	key, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(key)
+
+	km := &AwsKms{}
+
+	for _, cipher := range []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha1, ifcrypto.ChiperRsaOaepSha256} {
+
+		encrypted, err := km.Encrypt(c, []byte("secret"), key, cipher)
+		require.NoError(t, err)
+
+		plaintext, err := km.Decrypt(c, encrypted, key, cipher)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+
+		plaintext, err = gocrypto.NewCipher().Decrypt(c, encrypted, key, cipher)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+
+	}
+
+	_, err = km.Encrypt(c, []byte("secret"), key, ifcrypto.ChiperRsaPkcs1V15)
+	assert.Error(t, err)
+}
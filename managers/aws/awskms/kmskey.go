@@ -1,11 +1,424 @@
 package awskms
 
-import "github.com/mariotoffia/goservice/managers/go/gocrypto"
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+)
 
 // KmsKey implements the `ifcrypto.Key` interface.
 //
 // The `GetID` represents the _KMS ARN_, alias or id of this key.
+//
+// When a asymmetric key is loaded using `AwsKms.LoadKey` it also implements the
+// `ifcrypto.KeyPair` where the public portion is present in memory, hence it
+// is possible to verify or encrypt locally.
+//
+// A asymmetric signing key do also implement `crypto.Signer` and may therefore be used
+// in e.g. `x509.CreateCertificate`, `tls.Certificate.PrivateKey` or `ssh.NewSignerFromSigner`
+// where the private key never leaves _AWS KMS_. Since `crypto.Signer` do not accept a
+// context, the `ifctx.ServiceContext` is bound to the key, see `WithContext`.
+//
+// .Example
+// [source,go]
+// ----
+// key, err := km.LoadKey(c, "alias/my-ca"); cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+// ----
 type KmsKey struct {
 	// Derive from `gocrypto.KeyBase`
 	gocrypto.KeyBase
+	// public is the in memory public key, `nil` if symmetric.
+	public ifcrypto.PublicKey
+	// macAlgorithms are the _MAC_ algorithms supported by a _HMAC_ key.
+	macAlgorithms []ifcrypto.MacAlgorithm
+	// c is the bound context used when invoked as `crypto.Signer`.
+	c ifctx.ServiceContext
+	// tags are passed to _AWS KMS_ when invoked as `crypto.Signer`.
+	tags []coremodel.Meta
+}
+
+// LoadKey loads the key with _keyID_ from _AWS KMS_ using _DescribeKey_ and, if
+// asymmetric, _GetPublicKey_.
+//
+// The _keyID_ may be a key id, _ARN_ or alias. The returned key has the _ARN_ as
+// id. Any `coremodel.MetaGrantToken` is passed as _GrantTokens_ to _GetPublicKey_.
+//
+// The _c_ and _tags_ are bound to the returned key and used when it is used as
+// a `crypto.Signer`.
+func (km *AwsKms) LoadKey(
+	c ifctx.ServiceContext,
+	keyID string,
+	tags ...coremodel.Meta,
+) (*KmsKey, error) {
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := client.DescribeKey(c, &kms.DescribeKeyInput{
+		KeyId:       utils.ToStringPtrNil(keyID),
+		GrantTokens: grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return km.keyFromMetadata(c, client, desc.KeyMetadata, keyID, tags...)
+}
+
+// keyFromMetadata creates a `KmsKey` from the _meta_ and, if asymmetric, fetches the public key.
+func (km *AwsKms) keyFromMetadata(
+	c ifctx.ServiceContext,
+	client *kms.Client,
+	meta *types.KeyMetadata,
+	keyID string,
+	tags ...coremodel.Meta,
+) (*KmsKey, error) {
+
+	if meta == nil || meta.Arn == nil {
+		return nil, fmt.Errorf("no key metadata returned for key: %s", keyID)
+	}
+
+	keyType, keySize, err := keyTypeFromSpec(meta.KeySpec)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &KmsKey{
+		c:    c,
+		tags: tags,
+		KeyBase: gocrypto.NewKeyBase(
+			*meta.Arn,
+			keyType,
+			keySize,
+			keyUsageFromType(meta.KeyUsage),
+			chiphersFromSpecs(meta.EncryptionAlgorithms),
+		),
+	}
+
+	if keyType == ifcrypto.KeyTypeSymmetric {
+
+		for _, spec := range meta.MacAlgorithms {
+
+			if alg := macAlgorithmFromSpec(spec); alg != "" {
+				key.macAlgorithms = append(key.macAlgorithms, alg)
+			}
+
+		}
+
+		return key, nil
+	}
+
+	pub, err := client.GetPublicKey(c, &kms.GetPublicKeyInput{
+		KeyId:       meta.Arn,
+		GrantTokens: grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	public, err := parsePublicKey(keyType, pub.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := publicKeyUsageFromType(meta.KeyUsage)
+
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		key.public = gocrypto.NewRSAPublicKeyFromKey(*meta.Arn, k, usage...)
+	case *ecdsa.PublicKey:
+		key.public = gocrypto.NewECDSAPublicKeyFromKey(*meta.Arn, k, usage...)
+	default:
+		return nil, fmt.Errorf("unsupported public key: %T for key: %s", public, *meta.Arn)
+	}
+
+	return key, nil
+}
+
+// WithContext returns a copy of this key that is bound to _c_ and _tags_.
+//
+// The bound context, and _tags_ such as `coremodel.MetaGrantToken`, are used when the
+// key is invoked as a `crypto.Signer`.
+func (r *KmsKey) WithContext(c ifctx.ServiceContext, tags ...coremodel.Meta) *KmsKey {
+
+	key := *r
+	key.c = c
+	key.tags = tags
+
+	return &key
+}
+
+// Public implements the `crypto.Signer` interface.
+//
+// It returns the underlying public key, e.g. `*rsa.PublicKey` or `*ecdsa.PublicKey`, or `nil`
+// if no public key is loaded.
+func (r *KmsKey) Public() crypto.PublicKey {
+
+	if r.public == nil {
+		return nil
+	}
+
+	return r.public.GetKey()
+}
+
+// Sign implements the `crypto.Signer` interface.
+//
+// The _digest_ is sent as `types.MessageTypeDigest` to _AWS KMS_ using the signing algorithm
+// that matches the key type and _opts_. For _RSA_ keys a `*rsa.PSSOptions` selects _RSASSA-PSS_
+// where the salt length must be `rsa.PSSSaltLengthEqualsHash`, `rsa.PSSSaltLengthAuto` or the
+// size of the hash since that is what _AWS KMS_ uses. The _rand_ is not used.
+func (r *KmsKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+
+	if r.c == nil {
+		return nil, fmt.Errorf("no context is bound to key: %s", r.GetID())
+	}
+
+	alg, err := signAlgorithmFromOpts(r.GetKeyType(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf(
+			"digest size: %d do not match hash size: %d", len(digest), opts.HashFunc().Size(),
+		)
+	}
+
+	spec, err := signingAlgorithmSpec(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	return signMessage(r.c, r.GetID(), digest, types.MessageTypeDigest, spec, r.tags...)
+}
+
+// GetPublic returns the public portion of the key.
+//
+// If symmetric key, or not loaded using `AwsKms.LoadKey`, `nil` is returned.
+func (r *KmsKey) GetPublic() ifcrypto.PublicKey {
+	return r.public
+}
+
+// CanMac checks if the current _Key_ may be used to generate and verify a _MAC_ using _alg_ `MacAlgorithm`.
+//
+// A _HMAC_ key only supports the _MAC_ algorithms reported by _AWS KMS_, e.g. _HMAC_256_
+// only supports `ifcrypto.MacAlgorithmHmacSha256`.
+func (r *KmsKey) CanMac(alg ifcrypto.MacAlgorithm) bool {
+
+	if !r.KeyBase.CanMac(alg) {
+		return false
+	}
+
+	for _, a := range r.macAlgorithms {
+
+		if a == alg {
+			return true
+		}
+
+	}
+
+	return false
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+func (r *KmsKey) GetKey() interface{} {
+	return r.GetID()
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *KmsKey) IsSymmetric() bool {
+	return r.GetKeyType() == ifcrypto.KeyTypeSymmetric
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *KmsKey) IsPrivate() bool {
+	return true
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *KmsKey) IsRemoteKey() bool {
+	return true
+}
+
+// signAlgorithmFromOpts maps the `crypto.SignerOpts` onto a `ifcrypto.SignAlgorithm` for _keyType_.
+func signAlgorithmFromOpts(
+	keyType ifcrypto.KeyType,
+	opts crypto.SignerOpts,
+) (ifcrypto.SignAlgorithm, error) {
+
+	if opts == nil {
+		return "", fmt.Errorf("must specify signer opts with the hash of the digest")
+	}
+
+	hash := opts.HashFunc()
+
+	switch keyType {
+	case ifcrypto.KeyTypeRsa:
+
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+
+			if pss.SaltLength != rsa.PSSSaltLengthEqualsHash &&
+				pss.SaltLength != rsa.PSSSaltLengthAuto &&
+				pss.SaltLength != hash.Size() {
+				return "", fmt.Errorf("PSS salt length: %d is not supported by AWS KMS", pss.SaltLength)
+			}
+
+			switch hash {
+			case crypto.SHA256:
+				return ifcrypto.SignAlgorithmRsaPssSha256, nil
+			case crypto.SHA384:
+				return ifcrypto.SignAlgorithmRsaPssSha384, nil
+			case crypto.SHA512:
+				return ifcrypto.SignAlgorithmRsaPssSha512, nil
+			}
+
+		} else {
+
+			switch hash {
+			case crypto.SHA256:
+				return ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, nil
+			case crypto.SHA384:
+				return ifcrypto.SignAlgorithmRsaPkcs1V15Sha384, nil
+			case crypto.SHA512:
+				return ifcrypto.SignAlgorithmRsaPkcs1V15Sha512, nil
+			}
+
+		}
+
+	case ifcrypto.KeyTypeEccNistP, ifcrypto.KeyTypeEccSecgP256k1:
+
+		switch hash {
+		case crypto.SHA256:
+			return ifcrypto.SignAlgorithmEcdSha256, nil
+		case crypto.SHA384:
+			return ifcrypto.SignAlgorithmEcdSha384, nil
+		case crypto.SHA512:
+			return ifcrypto.SignAlgorithmEcdSha512, nil
+		}
+
+	default:
+		return "", fmt.Errorf("key type: %s can not be used as crypto.Signer", keyType)
+	}
+
+	return "", fmt.Errorf("hash: %s is not supported by AWS KMS for key type: %s", hash, keyType)
+}
+
+// parsePublicKey parses the _SPKI_ _der_ public key.
+//
+// Since `crypto/x509` do not support _secp256k1_ it is parsed using `cryptoutils`.
+func parsePublicKey(keyType ifcrypto.KeyType, der []byte) (interface{}, error) {
+
+	if keyType == ifcrypto.KeyTypeEccSecgP256k1 {
+		return cryptoutils.ParseSecp256k1PublicKey(der)
+	}
+
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// keyTypeFromSpec maps the _AWS KMS_ key spec onto a `ifcrypto.KeyType` and key size.
+func keyTypeFromSpec(spec types.KeySpec) (ifcrypto.KeyType, int, error) {
+
+	switch spec {
+	case types.KeySpecRsa2048:
+		return ifcrypto.KeyTypeRsa, 2048, nil
+	case types.KeySpecRsa3072:
+		return ifcrypto.KeyTypeRsa, 3072, nil
+	case types.KeySpecRsa4096:
+		return ifcrypto.KeyTypeRsa, 4096, nil
+	case types.KeySpecEccNistP256:
+		return ifcrypto.KeyTypeEccNistP, 256, nil
+	case types.KeySpecEccNistP384:
+		return ifcrypto.KeyTypeEccNistP, 384, nil
+	case types.KeySpecEccNistP521:
+		return ifcrypto.KeyTypeEccNistP, 521, nil
+	case types.KeySpecEccSecgP256k1:
+		return ifcrypto.KeyTypeEccSecgP256k1, 256, nil
+	case types.KeySpecSymmetricDefault:
+		return ifcrypto.KeyTypeSymmetric, 256, nil
+	case types.KeySpecHmac224:
+		return ifcrypto.KeyTypeSymmetric, 224, nil
+	case types.KeySpecHmac256:
+		return ifcrypto.KeyTypeSymmetric, 256, nil
+	case types.KeySpecHmac384:
+		return ifcrypto.KeyTypeSymmetric, 384, nil
+	case types.KeySpecHmac512:
+		return ifcrypto.KeyTypeSymmetric, 512, nil
+	}
+
+	return "", 0, fmt.Errorf("unsupported AWS KMS key spec: %s", spec)
+}
+
+// keyUsageFromType maps the _AWS KMS_ key usage onto `ifcrypto.KeyUsage`.
+func keyUsageFromType(usage types.KeyUsageType) []ifcrypto.KeyUsage {
+
+	switch usage {
+	case types.KeyUsageTypeSignVerify:
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+	case types.KeyUsageTypeEncryptDecrypt:
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt}
+	case types.KeyUsageTypeGenerateVerifyMac:
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}
+	}
+
+	return []ifcrypto.KeyUsage{}
+}
+
+// publicKeyUsageFromType maps the _AWS KMS_ key usage onto what the public key may be used for.
+func publicKeyUsageFromType(usage types.KeyUsageType) []ifcrypto.KeyUsage {
+
+	switch usage {
+	case types.KeyUsageTypeSignVerify:
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageVerify}
+	case types.KeyUsageTypeEncryptDecrypt:
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt}
+	}
+
+	return []ifcrypto.KeyUsage{}
+}
+
+// chiphersFromSpecs maps the _AWS KMS_ encryption algorithms onto `ifcrypto.Chipher`.
+func chiphersFromSpecs(specs []types.EncryptionAlgorithmSpec) []ifcrypto.Chipher {
+
+	chiphers := []ifcrypto.Chipher{}
+
+	for _, spec := range specs {
+
+		switch spec {
+		case types.EncryptionAlgorithmSpecSymmetricDefault:
+			chiphers = append(chiphers, ifcrypto.ChiperAES256)
+		case types.EncryptionAlgorithmSpecRsaesOaepSha1:
+			chiphers = append(chiphers, ifcrypto.ChiperRsaOaepSha1)
+		case types.EncryptionAlgorithmSpecRsaesOaepSha256:
+			chiphers = append(chiphers, ifcrypto.ChiperRsaOaepSha256)
+		}
+
+	}
+
+	return chiphers
 }
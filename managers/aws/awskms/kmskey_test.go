@@ -0,0 +1,309 @@
+package awskms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcert"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/managers/go/gocert"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeyRSASignAndVerifyLocally(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "rsa-key", coremodel.Meta{Name: coremodel.MetaGrantToken, Value: "token"})
+	require.NoError(t, err)
+
+	assert.Equal(t, fakeArnPrefix+"rsa-key", key.GetID())
+	assert.Equal(t, ifcrypto.KeyTypeRsa, key.GetKeyType())
+	assert.Equal(t, 2048, key.GetKeySize())
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}, key.GetKeyUsage())
+	assert.True(t, key.IsRemoteKey())
+	assert.True(t, key.IsPrivate())
+	assert.False(t, key.IsSymmetric())
+	assert.Equal(t, key.GetID(), key.GetKey())
+	assert.Equal(t, []interface{}{"token"}, fake.lastRequest("GetPublicKey").Body["GrantTokens"])
+
+	public, ok := key.GetPublic().(*gocrypto.RSAPublicKey)
+	require.True(t, ok)
+	assert.Equal(t, &private.GetKey().(*rsa.PrivateKey).PublicKey, public.GetKey())
+	assert.False(t, public.IsPrivate())
+
+	msg := []byte("hello world")
+
+	signature, err := km.Sign(c, msg, key, ifcrypto.SignAlgorithmRsaPssSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, msg, signature, key, ifcrypto.SignAlgorithmRsaPssSha256))
+
+	err = km.Verify(c, []byte("tampered"), signature, key, ifcrypto.SignAlgorithmRsaPssSha256)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+	assert.Equal(t, "", fake.lastRequest("Verify").Operation, "verify shall be done locally")
+
+}
+
+func TestLoadKeyECDSA(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewECDSAPrivateKey("ecdsa-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "ecdsa-key")
+	require.NoError(t, err)
+
+	assert.Equal(t, ifcrypto.KeyTypeEccNistP, key.GetKeyType())
+	assert.Equal(t, 256, key.GetKeySize())
+
+	public, ok := key.GetPublic().(*gocrypto.ECDSAPublicKey)
+	require.True(t, ok)
+	assert.True(t, public.GetKey().(*ecdsa.PublicKey).Equal(&private.GetKey().(*ecdsa.PrivateKey).PublicKey))
+
+	msg := []byte("hello world")
+
+	signature, err := km.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, msg, signature, key, ifcrypto.SignAlgorithmEcdSha256))
+
+}
+
+func TestLoadKeySymmetric(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewSymmetricKey("aes-key", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "aes-key")
+	require.NoError(t, err)
+
+	assert.True(t, key.IsSymmetric())
+	assert.Nil(t, key.GetPublic())
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperAES256}, key.GetSupportedChiphers())
+	assert.True(t, key.CanEncrypt(ifcrypto.ChiperAES256))
+	assert.False(t, key.CanSign(ifcrypto.SignAlgorithmEcdSha256))
+	assert.Equal(t, "", fake.lastRequest("GetPublicKey").Operation)
+
+	encrypted, err := km.Encrypt(c, []byte("secret"), key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+
+	plaintext, err := km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+}
+
+func TestLoadKeyNotFound(t *testing.T) {
+
+	_, c := newFakeKms(t)
+
+	_, err := (&AwsKms{}).LoadKey(c, "missing")
+	assert.True(t, errors.Is(err, ifkms.ErrKeyNotFound))
+
+}
+
+func TestKmsKeyAsX509Signer(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecdsaKey, err := gocrypto.NewECDSAPrivateKey("ecdsa-key", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(rsaKey)
+	fake.addKey(ecdsaKey)
+
+	tests := []struct {
+		id   string
+		alg  x509.SignatureAlgorithm
+		spec string
+	}{
+		{"rsa-key", x509.SHA256WithRSAPSS, "RSASSA_PSS_SHA_256"},
+		{"rsa-key", x509.SHA384WithRSA, "RSASSA_PKCS1_V1_5_SHA_384"},
+		{"ecdsa-key", x509.ECDSAWithSHA256, "ECDSA_SHA_256"},
+	}
+
+	km := &AwsKms{}
+
+	for _, tt := range tests {
+
+		t.Run(tt.alg.String(), func(t *testing.T) {
+
+			key, err := km.LoadKey(c, tt.id)
+			require.NoError(t, err)
+
+			var signer crypto.Signer = key
+
+			tmpl := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "kms-ca"},
+				NotBefore:             time.Now(),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+				SignatureAlgorithm:    tt.alg,
+			}
+
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+			require.NoError(t, err)
+
+			cert, err := x509.ParseCertificate(der)
+			require.NoError(t, err)
+			assert.NoError(t, cert.CheckSignatureFrom(cert))
+
+			req := fake.lastRequest("Sign")
+			assert.Equal(t, tt.spec, req.Body["SigningAlgorithm"])
+			assert.Equal(t, "DIGEST", req.Body["MessageType"])
+
+		})
+
+	}
+}
+
+func TestKmsKeyAsCertificateAuthority(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	rootKey, err := gocrypto.NewECDSAPrivateKey("root-key", 384, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(rootKey)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "root-key")
+	require.NoError(t, err)
+
+	cm := gocert.NewCertificateManager()
+
+	ca, err := cm.CreateRootCA(key, ifcert.CertificateSpec{
+		Subject:  pkix.Name{CommonName: "kms-root"},
+		Validity: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ECDSA_SHA_384", fake.lastRequest("Sign").Body["SigningAlgorithm"])
+
+	device, err := gocrypto.NewEd25519PrivateKey("device", ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	csr, err := cm.CreateCertificateRequest(device, ifcert.CertificateSpec{DNSNames: []string{"device.iot.internal"}})
+	require.NoError(t, err)
+
+	cert, err := ca.Issue(csr, ifcert.CertificateSpec{Validity: time.Hour})
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(ca.GetCertificate()))
+	assert.Equal(t, key.GetID(), fake.lastRequest("Sign").Body["KeyId"])
+
+}
+
+func TestKmsKeySignerOpts(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewRSAPrivateKey("rsa-key", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	key, err := (&AwsKms{}).LoadKey(c, "rsa-key")
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("hello world"))
+
+	_, err = key.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256, SaltLength: 20})
+	assert.Error(t, err, "salt length do not match hash size")
+
+	_, err = key.Sign(rand.Reader, digest[:16], crypto.SHA256)
+	assert.Error(t, err, "digest size do not match")
+
+	_, err = key.Sign(rand.Reader, digest[:], crypto.SHA1)
+	assert.Error(t, err, "SHA-1 is not supported")
+
+	_, err = key.Sign(rand.Reader, digest[:], nil)
+	assert.Error(t, err, "no signer opts")
+
+	_, err = (&KmsKey{KeyBase: key.KeyBase, public: key.public}).Sign(rand.Reader, digest[:], crypto.SHA256)
+	assert.Error(t, err, "no bound context")
+
+	bound := key.WithContext(c, coremodel.Meta{Name: coremodel.MetaGrantToken, Value: "token"})
+
+	signature, err := bound.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256, SaltLength: rsa.PSSSaltLengthEqualsHash})
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"token"}, fake.lastRequest("Sign").Body["GrantTokens"])
+	assert.NoError(t, rsa.VerifyPSS(bound.Public().(*rsa.PublicKey), crypto.SHA256, digest[:], signature, nil))
+
+}
+
+func TestLoadKeySecp256k1(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+
+	private, err := gocrypto.NewSecp256k1PrivateKey("k1-key", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	fake.addKey(private)
+
+	km := &AwsKms{}
+
+	key, err := km.LoadKey(c, "k1-key")
+	require.NoError(t, err)
+
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, key.GetKeyType())
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, key.GetPublic().GetKeyType())
+	assert.True(t, key.Public().(*ecdsa.PublicKey).Equal(&private.GetKey().(*ecdsa.PrivateKey).PublicKey))
+
+	msg := []byte("hello world")
+
+	signature, err := km.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, msg, signature, key, ifcrypto.SignAlgorithmEcdSha256))
+
+	low, err := gocrypto.NormalizeLowS(key.Public().(*ecdsa.PublicKey).Curve, signature)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, msg, low, key, ifcrypto.SignAlgorithmEcdSha256))
+
+	local, err := gocrypto.NewSigner().Sign(c, msg, private, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, msg, local, key, ifcrypto.SignAlgorithmEcdSha256))
+
+}
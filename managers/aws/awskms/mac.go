@@ -0,0 +1,152 @@
+package awskms
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// GenerateMac implements the `ifcrypto.Mac` interface using a _AWS KMS_ _HMAC_ key.
+//
+// The _HMAC_ key never leaves _AWS KMS_. Since _AWS KMS_ do not accept digests for _MAC_
+// operations, the _msg_ may not be larger than `MaxMessageSize`.
+func (km *AwsKms) GenerateMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to generate mac with")
+	}
+
+	spec, err := macAlgorithmSpec(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateMacMessage(msg); err != nil {
+		return nil, err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GenerateMac(c, &kms.GenerateMacInput{
+		KeyId:        utils.ToStringPtrNil(key.GetID()),
+		Message:      msg,
+		MacAlgorithm: spec,
+		GrantTokens:  grantTokens(tags...),
+	})
+
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return out.Mac, nil
+}
+
+// VerifyMac implements the `ifcrypto.Mac` interface using a _AWS KMS_ _HMAC_ key.
+//
+// The comparison is done by _AWS KMS_. If the _mac_ do not match, an error that wraps
+// `ifcrypto.ErrInvalidMac` is returned.
+func (km *AwsKms) VerifyMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	mac []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) error {
+
+	if key == nil {
+		return fmt.Errorf("must specify a key to verify mac with")
+	}
+
+	spec, err := macAlgorithmSpec(alg)
+	if err != nil {
+		return err
+	}
+
+	if err := validateMacMessage(msg); err != nil {
+		return err
+	}
+
+	client, err := kmsClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	out, err := client.VerifyMac(c, &kms.VerifyMacInput{
+		KeyId:        utils.ToStringPtrNil(key.GetID()),
+		Message:      msg,
+		Mac:          mac,
+		MacAlgorithm: spec,
+		GrantTokens:  grantTokens(tags...),
+	})
+
+	if err != nil {
+		return mapError(err)
+	}
+
+	if !out.MacValid {
+		return ifcrypto.ErrInvalidMac
+	}
+
+	return nil
+}
+
+// macAlgorithmSpec maps the _alg_ onto the _AWS KMS_ mac algorithm.
+func macAlgorithmSpec(alg ifcrypto.MacAlgorithm) (types.MacAlgorithmSpec, error) {
+
+	switch alg {
+	case ifcrypto.MacAlgorithmHmacSha224:
+		return types.MacAlgorithmSpecHmacSha224, nil
+	case ifcrypto.MacAlgorithmHmacSha256:
+		return types.MacAlgorithmSpecHmacSha256, nil
+	case ifcrypto.MacAlgorithmHmacSha384:
+		return types.MacAlgorithmSpecHmacSha384, nil
+	case ifcrypto.MacAlgorithmHmacSha512:
+		return types.MacAlgorithmSpecHmacSha512, nil
+	}
+
+	return "", fmt.Errorf("mac algorithm: %s is not supported by AWS KMS", alg)
+}
+
+// macAlgorithmFromSpec maps the _AWS KMS_ mac algorithm onto `ifcrypto.MacAlgorithm`.
+//
+// If unknown, an empty `ifcrypto.MacAlgorithm` is returned.
+func macAlgorithmFromSpec(spec types.MacAlgorithmSpec) ifcrypto.MacAlgorithm {
+
+	switch spec {
+	case types.MacAlgorithmSpecHmacSha224:
+		return ifcrypto.MacAlgorithmHmacSha224
+	case types.MacAlgorithmSpecHmacSha256:
+		return ifcrypto.MacAlgorithmHmacSha256
+	case types.MacAlgorithmSpecHmacSha384:
+		return ifcrypto.MacAlgorithmHmacSha384
+	case types.MacAlgorithmSpecHmacSha512:
+		return ifcrypto.MacAlgorithmHmacSha512
+	}
+
+	return ""
+}
+
+// validateMacMessage ensures that _msg_ is within the limits of _AWS KMS_.
+func validateMacMessage(msg []byte) error {
+
+	if len(msg) == 0 || len(msg) > MaxMessageSize {
+		return fmt.Errorf("mac message must be between 1 and %d bytes, got: %d", MaxMessageSize, len(msg))
+	}
+
+	return nil
+}
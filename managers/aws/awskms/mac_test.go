@@ -0,0 +1,120 @@
+package awskms
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ifcrypto.Mac = &AwsKms{}
+
+func TestGenerateVerifyMacUsingHmacKey(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	key, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 384,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac},
+	})
+	require.NoError(t, err)
+
+	req := fake.lastRequest("CreateKey")
+	assert.Equal(t, "HMAC_384", req.Body["KeySpec"])
+	assert.Equal(t, "GENERATE_VERIFY_MAC", req.Body["KeyUsage"])
+
+	assert.True(t, key.IsSymmetric())
+	assert.Equal(t, 384, key.GetKeySize())
+	assert.True(t, key.CanMac(ifcrypto.MacAlgorithmHmacSha384))
+	assert.False(t, key.CanMac(ifcrypto.MacAlgorithmHmacSha256))
+	assert.False(t, key.CanEncrypt(ifcrypto.ChiperAES256))
+
+	payload := []byte(`{"event":"payment.succeeded"}`)
+	token := coremodel.Meta{Name: coremodel.MetaGrantToken, Value: "token-1"}
+
+	mac, err := km.GenerateMac(c, payload, key, ifcrypto.MacAlgorithmHmacSha384, token)
+	require.NoError(t, err)
+	assert.Len(t, mac, 48)
+
+	req = fake.lastRequest("GenerateMac")
+	assert.Equal(t, "HMAC_SHA_384", req.Body["MacAlgorithm"])
+	assert.Equal(t, []interface{}{"token-1"}, req.Body["GrantTokens"])
+
+	assert.NoError(t, km.VerifyMac(c, payload, mac, key, ifcrypto.MacAlgorithmHmacSha384, token))
+	assert.Equal(t, []interface{}{"token-1"}, fake.lastRequest("VerifyMac").Body["GrantTokens"])
+
+	err = km.VerifyMac(c, []byte("tampered"), mac, key, ifcrypto.MacAlgorithmHmacSha384)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac))
+
+	_, err = km.GenerateMac(c, payload, key, ifcrypto.MacAlgorithmHmacSha256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyUsage))
+
+}
+
+func TestMacMatchesLocalHmac(t *testing.T) {
+
+	_, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	key, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 256,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac},
+	})
+	require.NoError(t, err)
+
+	loaded, err := km.LoadKey(c, key.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}, loaded.GetKeyUsage())
+	assert.True(t, loaded.CanMac(ifcrypto.MacAlgorithmHmacSha256))
+
+	mac, err := km.GenerateMac(c, []byte("msg"), loaded, ifcrypto.MacAlgorithmHmacSha256)
+	require.NoError(t, err)
+
+	local, err := gocrypto.NewSymmetricKey("local", 256, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	err = gocrypto.NewMac().VerifyMac(nil, []byte("msg"), mac, local, ifcrypto.MacAlgorithmHmacSha256)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac), "different keys must not produce the same mac")
+
+}
+
+func TestMacValidation(t *testing.T) {
+
+	fake, c := newFakeKms(t)
+	km := &AwsKms{}
+
+	key, err := gocrypto.NewSymmetricKey("hmac-key", 256, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	_, err = km.GenerateMac(c, bytes.Repeat([]byte{'a'}, MaxMessageSize+1), key, ifcrypto.MacAlgorithmHmacSha256)
+	assert.Error(t, err)
+
+	_, err = km.GenerateMac(c, []byte("msg"), key, ifcrypto.MacAlgorithm("hmac-md5"))
+	assert.Error(t, err)
+
+	assert.Equal(t, "", fake.lastRequest("GenerateMac").Operation)
+
+	_, err = km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 128,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac},
+	})
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnsupportedKeySize))
+
+	_, err = km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 256,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac, ifcrypto.KeyUsageEncrypt},
+	})
+	assert.Error(t, err)
+
+}
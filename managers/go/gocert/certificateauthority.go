@@ -0,0 +1,160 @@
+package gocert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcert"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// GoCertificateAuthority implements the `ifcert.CertificateAuthority` interface.
+//
+// It is created using `GoCertificateManager.CreateRootCA`, `GoCertificateManager.LoadCA` or
+// `GoCertificateAuthority.IssueIntermediate`.
+type GoCertificateAuthority struct {
+	key    ifcrypto.KeyPair
+	signer crypto.Signer
+	chain  []*x509.Certificate
+}
+
+// GetCertificate implements the `ifcert.CertificateAuthority` interface.
+func (ca *GoCertificateAuthority) GetCertificate() *x509.Certificate {
+	return ca.chain[0]
+}
+
+// GetChain implements the `ifcert.CertificateAuthority` interface.
+func (ca *GoCertificateAuthority) GetChain() []*x509.Certificate {
+	return append([]*x509.Certificate{}, ca.chain...)
+}
+
+// GetKey returns the key that this certificate authority signs with.
+func (ca *GoCertificateAuthority) GetKey() ifcrypto.KeyPair {
+	return ca.key
+}
+
+// Issue implements the `ifcert.CertificateAuthority` interface.
+//
+// If no _KeyUsage_ is set in the _spec_, `x509.KeyUsageDigitalSignature` is used and for _RSA_ keys
+// also `x509.KeyUsageKeyEncipherment`.
+func (ca *GoCertificateAuthority) Issue(
+	csr *x509.CertificateRequest,
+	spec ifcert.CertificateSpec,
+) (*x509.Certificate, error) {
+
+	if csr == nil {
+		return nil, fmt.Errorf("%w: must specify a certificate request", ifcert.ErrInvalidCertificateRequest)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ifcert.ErrInvalidCertificateRequest, err)
+	}
+
+	tmpl, err := newTemplate(spec, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tmpl.Subject.ToRDNSequence()) == 0 {
+		tmpl.Subject = csr.Subject
+	}
+
+	if len(tmpl.DNSNames) == 0 && len(tmpl.IPAddresses) == 0 &&
+		len(tmpl.EmailAddresses) == 0 && len(tmpl.URIs) == 0 {
+
+		tmpl.DNSNames = csr.DNSNames
+		tmpl.IPAddresses = csr.IPAddresses
+		tmpl.EmailAddresses = csr.EmailAddresses
+		tmpl.URIs = csr.URIs
+
+	}
+
+	if tmpl.KeyUsage == 0 {
+
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+
+		if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+			tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+		}
+
+	}
+
+	return ca.issue(tmpl, csr.PublicKey, spec.SignAlgorithm)
+}
+
+// IssueIntermediate implements the `ifcert.CertificateAuthority` interface.
+//
+// The _MaxPathLen_ in the _spec_ is lowered to fit within the path length of this certificate
+// authority. If this certificate authority may not issue intermediates, an error is returned.
+func (ca *GoCertificateAuthority) IssueIntermediate(
+	key ifcrypto.KeyPair,
+	spec ifcert.CertificateSpec,
+) (ifcert.CertificateAuthority, error) {
+
+	cert := ca.GetCertificate()
+
+	if cert.MaxPathLen == 0 || cert.MaxPathLenZero {
+		return nil, fmt.Errorf("certificate authority: %s may not issue intermediates", cert.Subject)
+	}
+
+	if cert.MaxPathLen > 0 && (spec.MaxPathLen < 0 || spec.MaxPathLen >= cert.MaxPathLen) {
+		spec.MaxPathLen = cert.MaxPathLen - 1
+	}
+
+	signer, _, err := signerFor(key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := newTemplate(spec, true)
+	if err != nil {
+		return nil, err
+	}
+
+	issued, err := ca.issue(tmpl, signer.Public(), spec.SignAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoCertificateAuthority{
+		key:    key,
+		signer: signer,
+		chain:  append([]*x509.Certificate{issued}, ca.chain...),
+	}, nil
+}
+
+// issue signs the _tmpl_ for the _public_ key using _alg_ with the key of this certificate authority.
+func (ca *GoCertificateAuthority) issue(
+	tmpl *x509.Certificate,
+	public crypto.PublicKey,
+	alg ifcrypto.SignAlgorithm,
+) (*x509.Certificate, error) {
+
+	parent := ca.GetCertificate()
+
+	if tmpl.NotAfter.After(parent.NotAfter) {
+
+		return nil, fmt.Errorf(
+			"certificate may not be valid after: %s, the certificate authority: %s expires",
+			parent.NotAfter, parent.Subject,
+		)
+
+	}
+
+	_, x509Alg, err := signerFor(ca.key, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.SignatureAlgorithm = x509Alg
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, public, ca.signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate using key: %s: %w", ca.key.GetID(), err)
+	}
+
+	return x509.ParseCertificate(der)
+}
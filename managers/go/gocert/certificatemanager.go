@@ -0,0 +1,275 @@
+package gocert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcert"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// GoCertificateManager implements the `ifcert.CertificateManager` interface using `crypto/x509`.
+//
+// Any `ifcrypto.KeyPair` that also is a `crypto.Signer` may be used, e.g. the `gocrypto` in memory
+// keys or a _AWS KMS_ key that has a bound context. The _secp256k1_ curve is not supported by `crypto/x509`.
+type GoCertificateManager int
+
+// NewCertificateManager creates a new `GoCertificateManager`.
+func NewCertificateManager() GoCertificateManager {
+	return 0
+}
+
+// CreateCertificateRequest implements the `ifcert.CertificateManager` interface.
+func (cm GoCertificateManager) CreateCertificateRequest(
+	key ifcrypto.KeyPair,
+	spec ifcert.CertificateSpec,
+) (*x509.CertificateRequest, error) {
+
+	signer, alg, err := signerFor(key, spec.SignAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.CertificateRequest{
+		Subject:            spec.Subject,
+		DNSNames:           spec.DNSNames,
+		IPAddresses:        spec.IPAddresses,
+		EmailAddresses:     spec.EmailAddresses,
+		URIs:               spec.URIs,
+		SignatureAlgorithm: alg,
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request using key: %s: %w", key.GetID(), err)
+	}
+
+	return x509.ParseCertificateRequest(der)
+}
+
+// CreateRootCA implements the `ifcert.CertificateManager` interface.
+func (cm GoCertificateManager) CreateRootCA(
+	key ifcrypto.KeyPair,
+	spec ifcert.CertificateSpec,
+) (ifcert.CertificateAuthority, error) {
+
+	signer, alg, err := signerFor(key, spec.SignAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := newTemplate(spec, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.SignatureAlgorithm = alg
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root certificate using key: %s: %w", key.GetID(), err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoCertificateAuthority{
+		key:    key,
+		signer: signer,
+		chain:  []*x509.Certificate{cert},
+	}, nil
+}
+
+// LoadCA implements the `ifcert.CertificateManager` interface.
+//
+// The _chain_ is verified such that each certificate is signed by the next and the last
+// is self signed.
+func (cm GoCertificateManager) LoadCA(
+	key ifcrypto.KeyPair,
+	chain ...*x509.Certificate,
+) (ifcert.CertificateAuthority, error) {
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("must specify the certificate chain of the certificate authority")
+	}
+
+	signer, _, err := signerFor(key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !chain[0].IsCA || chain[0].KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("certificate: %s is not a certificate authority", chain[0].Subject)
+	}
+
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(chain[0].PublicKey) {
+		return nil, fmt.Errorf("key: %s do not match certificate: %s", key.GetID(), chain[0].Subject)
+	}
+
+	for i, cert := range chain {
+
+		parent := cert
+		if i+1 < len(chain) {
+			parent = chain[i+1]
+		}
+
+		if err := cert.CheckSignatureFrom(parent); err != nil {
+			return nil, fmt.Errorf("certificate: %s is not signed by: %s: %w", cert.Subject, parent.Subject, err)
+		}
+
+	}
+
+	return &GoCertificateAuthority{
+		key:    key,
+		signer: signer,
+		chain:  append([]*x509.Certificate{}, chain...),
+	}, nil
+}
+
+// signerFor returns the _key_ as a `crypto.Signer` along with the `x509.SignatureAlgorithm`
+// for _alg_. If _alg_ is empty, the default algorithm for the _key_ is used.
+func signerFor(
+	key ifcrypto.KeyPair,
+	alg ifcrypto.SignAlgorithm,
+) (crypto.Signer, x509.SignatureAlgorithm, error) {
+
+	if key == nil {
+		return nil, x509.UnknownSignatureAlgorithm, fmt.Errorf("must specify a key to sign with")
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok || signer.Public() == nil {
+		return nil, x509.UnknownSignatureAlgorithm, fmt.Errorf("key: %s is not a crypto.Signer", key.GetID())
+	}
+
+	if alg == "" {
+		alg = defaultSignAlgorithm(key)
+	}
+
+	if !key.CanSign(alg) {
+		return nil, x509.UnknownSignatureAlgorithm, fmt.Errorf("key: %s can not sign using: %s", key.GetID(), alg)
+	}
+
+	x509Alg, err := x509SignatureAlgorithm(alg)
+	if err != nil {
+		return nil, x509.UnknownSignatureAlgorithm, err
+	}
+
+	return signer, x509Alg, nil
+}
+
+// defaultSignAlgorithm returns the `ifcrypto.SignAlgorithm` to use when not specified.
+//
+// _ECDSA_ uses the hash that matches the curve size.
+func defaultSignAlgorithm(key ifcrypto.Key) ifcrypto.SignAlgorithm {
+
+	switch key.GetKeyType() {
+	case ifcrypto.KeyTypeRsa:
+		return ifcrypto.SignAlgorithmRsaPkcs1V15Sha256
+	case ifcrypto.KeyTypeEd25519:
+		return ifcrypto.SignAlgorithmEd25519
+	}
+
+	switch key.GetKeySize() {
+	case 384:
+		return ifcrypto.SignAlgorithmEcdSha384
+	case 521:
+		return ifcrypto.SignAlgorithmEcdSha512
+	}
+
+	return ifcrypto.SignAlgorithmEcdSha256
+}
+
+// x509SignatureAlgorithm maps the _alg_ onto the `x509.SignatureAlgorithm`.
+func x509SignatureAlgorithm(alg ifcrypto.SignAlgorithm) (x509.SignatureAlgorithm, error) {
+
+	switch alg {
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha256:
+		return x509.SHA256WithRSA, nil
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha384:
+		return x509.SHA384WithRSA, nil
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha512:
+		return x509.SHA512WithRSA, nil
+	case ifcrypto.SignAlgorithmRsaPssSha256:
+		return x509.SHA256WithRSAPSS, nil
+	case ifcrypto.SignAlgorithmRsaPssSha384:
+		return x509.SHA384WithRSAPSS, nil
+	case ifcrypto.SignAlgorithmRsaPssSha512:
+		return x509.SHA512WithRSAPSS, nil
+	case ifcrypto.SignAlgorithmEcdSha256:
+		return x509.ECDSAWithSHA256, nil
+	case ifcrypto.SignAlgorithmEcdSha384:
+		return x509.ECDSAWithSHA384, nil
+	case ifcrypto.SignAlgorithmEcdSha512:
+		return x509.ECDSAWithSHA512, nil
+	case ifcrypto.SignAlgorithmEd25519:
+		return x509.PureEd25519, nil
+	}
+
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported sign algorithm: %s", alg)
+}
+
+// newTemplate creates a certificate template from the _spec_ with a random serial number.
+//
+// The default key usages are applied when not set in the _spec_.
+func newTemplate(spec ifcert.CertificateSpec, isCA bool) (*x509.Certificate, error) {
+
+	if spec.Validity <= 0 {
+		return nil, fmt.Errorf("must specify a positive validity, got: %s", spec.Validity)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := spec.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial.Add(serial, big.NewInt(1)),
+		Subject:               spec.Subject,
+		DNSNames:              spec.DNSNames,
+		IPAddresses:           spec.IPAddresses,
+		EmailAddresses:        spec.EmailAddresses,
+		URIs:                  spec.URIs,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(spec.Validity),
+		KeyUsage:              spec.KeyUsage,
+		ExtKeyUsage:           spec.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	if isCA {
+
+		if tmpl.KeyUsage == 0 {
+			tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		}
+
+		if spec.MaxPathLen < 0 {
+			tmpl.MaxPathLen = -1
+		} else {
+			tmpl.MaxPathLen = spec.MaxPathLen
+			tmpl.MaxPathLenZero = spec.MaxPathLen == 0
+		}
+
+		return tmpl, nil
+
+	}
+
+	if len(tmpl.ExtKeyUsage) == 0 {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+
+	return tmpl, nil
+}
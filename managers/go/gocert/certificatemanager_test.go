@@ -0,0 +1,255 @@
+package gocert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcert"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCA(t *testing.T, maxPathLen int) ifcert.CertificateAuthority {
+
+	key, err := gocrypto.NewECDSAPrivateKey("root", 384, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	ca, err := NewCertificateManager().CreateRootCA(key, ifcert.CertificateSpec{
+		Subject:    pkix.Name{CommonName: "Root CA", Organization: []string{"goservice"}},
+		Validity:   365 * 24 * time.Hour,
+		MaxPathLen: maxPathLen,
+	})
+	require.NoError(t, err)
+
+	return ca
+}
+
+func TestIssueChainAndVerify(t *testing.T) {
+
+	cm := NewCertificateManager()
+	root := newCA(t, 1)
+
+	assert.Equal(t, x509.ECDSAWithSHA384, root.GetCertificate().SignatureAlgorithm)
+	assert.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, root.GetCertificate().KeyUsage)
+
+	interKey, err := gocrypto.NewRSAPrivateKey("intermediate", 2048, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	inter, err := root.IssueIntermediate(interKey, ifcert.CertificateSpec{
+		Subject:       pkix.Name{CommonName: "Devices CA"},
+		Validity:      30 * 24 * time.Hour,
+		MaxPathLen:    -1,
+		SignAlgorithm: ifcrypto.SignAlgorithmEcdSha512,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, x509.ECDSAWithSHA512, inter.GetCertificate().SignatureAlgorithm)
+	assert.True(t, inter.GetCertificate().MaxPathLenZero, "path length is lowered to fit root")
+	require.Len(t, inter.GetChain(), 2)
+
+	_, err = inter.IssueIntermediate(interKey, ifcert.CertificateSpec{Validity: time.Hour})
+	assert.Error(t, err, "path length exhausted")
+
+	keys := []ifcrypto.KeyPair{}
+
+	for _, keyType := range []ifcrypto.KeyType{ifcrypto.KeyTypeEccNistP, ifcrypto.KeyTypeEd25519} {
+
+		key, err := gocrypto.GenerateKey("device", keyType, 256, ifcrypto.KeyUsageSign)
+		require.NoError(t, err)
+
+		keys = append(keys, key.(ifcrypto.KeyPair))
+
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.GetCertificate())
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(inter.GetCertificate())
+
+	for _, key := range keys {
+
+		csr, err := cm.CreateCertificateRequest(key, ifcert.CertificateSpec{
+			Subject:     pkix.Name{CommonName: "device-1"},
+			DNSNames:    []string{"device-1.iot.internal"},
+			IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		})
+		require.NoError(t, err)
+
+		cert, err := inter.Issue(csr, ifcert.CertificateSpec{Validity: 24 * time.Hour})
+		require.NoError(t, err)
+
+		assert.Equal(t, "device-1", cert.Subject.CommonName)
+		assert.Equal(t, []string{"device-1.iot.internal"}, cert.DNSNames)
+		assert.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage)
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, cert.ExtKeyUsage)
+		assert.Equal(t, x509.SHA256WithRSA, cert.SignatureAlgorithm)
+
+		_, err = cert.Verify(x509.VerifyOptions{
+			DNSName:       "device-1.iot.internal",
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		assert.NoError(t, err)
+
+	}
+
+}
+
+func TestIssueSpecReplacesRequest(t *testing.T) {
+
+	cm := NewCertificateManager()
+	ca := newCA(t, 0)
+
+	key, err := gocrypto.NewRSAPrivateKey("service", 2048, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	csr, err := cm.CreateCertificateRequest(key, ifcert.CertificateSpec{
+		Subject:  pkix.Name{CommonName: "admin"},
+		DNSNames: []string{"evil.example.com"},
+	})
+	require.NoError(t, err)
+
+	spiffe, _ := url.Parse("spiffe://internal/service/orders")
+
+	cert, err := ca.Issue(csr, ifcert.CertificateSpec{
+		Subject:     pkix.Name{CommonName: "orders"},
+		URIs:        []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		Validity:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "orders", cert.Subject.CommonName)
+	assert.Empty(t, cert.DNSNames)
+	assert.Equal(t, spiffe.String(), cert.URIs[0].String())
+	assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, cert.KeyUsage)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, cert.ExtKeyUsage)
+
+	_, err = ca.IssueIntermediate(key, ifcert.CertificateSpec{Validity: time.Hour})
+	assert.Error(t, err, "root with zero path length may not issue intermediates")
+
+	forged := *csr
+	forged.Signature = append([]byte{}, csr.Signature...)
+	forged.Signature[10] ^= 1
+
+	_, err = ca.Issue(&forged, ifcert.CertificateSpec{Validity: time.Hour})
+	assert.True(t, errors.Is(err, ifcert.ErrInvalidCertificateRequest), "got: %v", err)
+
+}
+
+func TestCertificateManagerRejects(t *testing.T) {
+
+	cm := NewCertificateManager()
+	ca := newCA(t, 0)
+
+	verifyOnly, err := gocrypto.NewECDSAPrivateKey("verify", 256, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	_, err = cm.CreateCertificateRequest(verifyOnly, ifcert.CertificateSpec{})
+	assert.Error(t, err, "key without sign usage")
+
+	x25519, err := gocrypto.NewX25519PrivateKey("x25519", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	_, err = cm.CreateRootCA(x25519, ifcert.CertificateSpec{Validity: time.Hour})
+	assert.Error(t, err, "not a crypto.Signer")
+
+	key, err := gocrypto.NewECDSAPrivateKey("device", 256, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	_, err = cm.CreateRootCA(key, ifcert.CertificateSpec{})
+	assert.Error(t, err, "missing validity")
+
+	csr, err := cm.CreateCertificateRequest(key, ifcert.CertificateSpec{Subject: pkix.Name{CommonName: "device"}})
+	require.NoError(t, err)
+
+	_, err = ca.Issue(csr, ifcert.CertificateSpec{Validity: 2 * 365 * 24 * time.Hour})
+	assert.Error(t, err, "outlives the certificate authority")
+
+	_, err = ca.Issue(csr, ifcert.CertificateSpec{Validity: time.Hour, SignAlgorithm: ifcrypto.SignAlgorithmRsaPssSha256})
+	assert.Error(t, err, "sign algorithm do not match key")
+
+	_, err = cm.LoadCA(key, ca.GetChain()...)
+	assert.Error(t, err, "key do not match certificate")
+
+}
+
+func TestLoadCAFromPEMAndServeMutualTLS(t *testing.T) {
+
+	cm := NewCertificateManager()
+	root := newCA(t, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, cryptoutils.CertificatesToPEM(&buf, root.GetChain()...))
+
+	chain, err := cryptoutils.PEMToCertificates(buf.Bytes())
+	require.NoError(t, err)
+
+	ca, err := cm.LoadCA(root.(*GoCertificateAuthority).GetKey(), chain...)
+	require.NoError(t, err)
+	assert.True(t, ca.GetCertificate().Equal(root.GetCertificate()))
+
+	issue := func(id, dnsName string) tls.Certificate {
+
+		key, err := gocrypto.NewECDSAPrivateKey(id, 256, ifcrypto.KeyUsageSign)
+		require.NoError(t, err)
+
+		csr, err := cm.CreateCertificateRequest(key, ifcert.CertificateSpec{
+			Subject:  pkix.Name{CommonName: id},
+			DNSNames: []string{dnsName},
+		})
+		require.NoError(t, err)
+
+		buf.Reset()
+		require.NoError(t, cryptoutils.CertificateRequestToPEM(&buf, csr))
+
+		csr, err = cryptoutils.PEMToCertificateRequest(buf.Bytes())
+		require.NoError(t, err)
+
+		cert, err := ca.Issue(csr, ifcert.CertificateSpec{Validity: time.Hour})
+		require.NoError(t, err)
+
+		return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.GetCertificate())
+
+	serverConn, clientConn := net.Pipe()
+
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{issue("server", "orders.internal")},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+
+	client := tls.Client(clientConn, &tls.Config{
+		Certificates: []tls.Certificate{issue("client", "device.internal")},
+		RootCAs:      pool,
+		ServerName:   "orders.internal",
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- server.Handshake() }()
+
+	require.NoError(t, client.Handshake())
+	require.NoError(t, <-done)
+
+	assert.Equal(t, "client", server.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	client.Close()
+	server.Close()
+
+}
@@ -0,0 +1,285 @@
+package gocrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// GoCipher implements the `ifcrypto.Cipherable` interface using keys that resides
+// in process memory.
+//
+// The `ifcrypto.ChiperAES256` uses _AES-256-GCM_ with a random 96 bit nonce. The
+// encrypted output is the nonce followed by the ciphertext and the authentication tag.
+// Any `coremodel.MetaEncryptionContext` is authenticated as additional data and must
+// be the same when decrypting.
+//
+// The _RSA_ chiphers encrypts using a `RSAPublicKey`, or the public portion of a `RSAPrivateKey`,
+// and decrypts using a `RSAPrivateKey`.
+// The plaintext may not exceed the `ifcrypto.Chipher.GetMaxPlaintextSize` for the key size.
+//
+// The `ifcrypto.ChiperEciesHkdfSha256AES256` encrypts using a _NIST_ curve `ECDSAPublicKey` or
+// a `X25519PublicKey` and decrypts using the private key. Any `coremodel.MetaEncryptionContext`
+// is authenticated the same way as for `ifcrypto.ChiperAES256`.
+type GoCipher int
+
+// NewCipher creates a new `GoCipher`.
+func NewCipher() GoCipher {
+	return 0
+}
+
+// Encrypt implements the `ifcrypto.Cipherable` interface.
+func (gc GoCipher) Encrypt(
+	c ifctx.ServiceContext,
+	plaintext []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to encrypt with")
+	}
+
+	if !key.CanEncrypt(cipher) {
+		return nil, fmt.Errorf("key: %s can not encrypt using: %s", key.GetID(), cipher)
+	}
+
+	switch cipher {
+	case ifcrypto.ChiperAES256:
+		return encryptAESGCM(key, plaintext, tags...)
+	case ifcrypto.ChiperRsaOaepSha1, ifcrypto.ChiperRsaOaepSha256, ifcrypto.ChiperRsaPkcs1V15:
+		return encryptRSA(key, plaintext, cipher, tags...)
+	case ifcrypto.ChiperEciesHkdfSha256AES256:
+		return encryptECIES(key, plaintext, tags...)
+	}
+
+	return nil, fmt.Errorf("unsupported cipher: %s", cipher)
+}
+
+// Decrypt implements the `ifcrypto.Cipherable` interface.
+func (gc GoCipher) Decrypt(
+	c ifctx.ServiceContext,
+	encrypted []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to decrypt with")
+	}
+
+	if !key.CanDecrypt(cipher) {
+		return nil, fmt.Errorf("key: %s can not decrypt using: %s", key.GetID(), cipher)
+	}
+
+	switch cipher {
+	case ifcrypto.ChiperAES256:
+		return decryptAESGCM(key, encrypted, tags...)
+	case ifcrypto.ChiperRsaOaepSha1, ifcrypto.ChiperRsaOaepSha256, ifcrypto.ChiperRsaPkcs1V15:
+		return decryptRSA(key, encrypted, cipher, tags...)
+	case ifcrypto.ChiperEciesHkdfSha256AES256:
+		return decryptECIES(key, encrypted, tags...)
+	}
+
+	return nil, fmt.Errorf("unsupported cipher: %s", cipher)
+}
+
+// encryptAESGCM encrypts the _plaintext_ using _AES-256-GCM_ and returns nonce, ciphertext and tag.
+func encryptAESGCM(key ifcrypto.Key, plaintext []byte, tags ...coremodel.Meta) ([]byte, error) {
+
+	aead, err := aesGCMFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := encryptionContextAAD(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+
+}
+
+// decryptAESGCM decrypts the output of `encryptAESGCM`.
+func decryptAESGCM(key ifcrypto.Key, encrypted []byte, tags ...coremodel.Meta) ([]byte, error) {
+
+	aead, err := aesGCMFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := encryptionContextAAD(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	nonce := encrypted[:aead.NonceSize()]
+
+	return aead.Open(nil, nonce, encrypted[aead.NonceSize():], aad)
+
+}
+
+// encryptRSA encrypts the _plaintext_ using the _RSA_ public _key_. If _key_ is a private key,
+// its public portion is used.
+//
+// Encryption context is not supported, the same as _AWS KMS_ asymmetric keys.
+func encryptRSA(
+	key ifcrypto.Key,
+	plaintext []byte,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if len(coremodel.GetMetaValues(coremodel.MetaEncryptionContext, tags...)) > 0 {
+		return nil, fmt.Errorf("encryption context is not supported by: %s", cipher)
+	}
+
+	var pub *rsa.PublicKey
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PublicKey:
+		pub = k
+	case *rsa.PrivateKey:
+		pub = &k.PublicKey
+	default:
+		return nil, fmt.Errorf("key: %s must be a RSA key to encrypt, got: %T", key.GetID(), key.GetKey())
+	}
+
+	if limit := cipher.GetMaxPlaintextSize(pub.N.BitLen()); len(plaintext) > limit {
+
+		return nil, fmt.Errorf(
+			"plaintext of %d bytes exceeds the maximum of %d bytes for %s using a %d bit key",
+			len(plaintext), limit, cipher, pub.N.BitLen(),
+		)
+
+	}
+
+	switch cipher {
+	case ifcrypto.ChiperRsaOaepSha1:
+		return rsa.EncryptOAEP(sha1.New(), rand.Reader, pub, plaintext, nil)
+	case ifcrypto.ChiperRsaOaepSha256:
+		return rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, plaintext, nil)
+	}
+
+	return rsa.EncryptPKCS1v15(rand.Reader, pub, plaintext)
+
+}
+
+// decryptRSA decrypts the _encrypted_ using the _RSA_ private _key_.
+func decryptRSA(
+	key ifcrypto.Key,
+	encrypted []byte,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if len(coremodel.GetMetaValues(coremodel.MetaEncryptionContext, tags...)) > 0 {
+		return nil, fmt.Errorf("encryption context is not supported by: %s", cipher)
+	}
+
+	priv, ok := key.GetKey().(*rsa.PrivateKey)
+
+	if !ok {
+		return nil, fmt.Errorf("key: %s must be a RSA private key to decrypt, got: %T", key.GetID(), key.GetKey())
+	}
+
+	switch cipher {
+	case ifcrypto.ChiperRsaOaepSha1:
+		return rsa.DecryptOAEP(sha1.New(), rand.Reader, priv, encrypted, nil)
+	case ifcrypto.ChiperRsaOaepSha256:
+		return rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, encrypted, nil)
+	}
+
+	return rsa.DecryptPKCS1v15(rand.Reader, priv, encrypted)
+
+}
+
+// aesGCMFromKey creates a _AES-256-GCM_ `cipher.AEAD` from the raw bytes of the symmetric _key_.
+func aesGCMFromKey(key ifcrypto.Key) (cipher.AEAD, error) {
+
+	raw, ok := key.GetKey().([]byte)
+
+	if !ok || !key.IsSymmetric() {
+		return nil, fmt.Errorf("key: %s is not a symmetric key in memory", key.GetID())
+	}
+
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("key: %s must be 256 bits, got: %d", key.GetID(), len(raw)*8)
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+
+}
+
+// encryptionContextAAD renders the encryption context of _tags_ as additional data.
+//
+// The keys are sorted and each key and value is prefixed with its length, hence the
+// result is stable regardless of the map order. If no encryption context, `nil` is
+// returned.
+func encryptionContextAAD(tags ...coremodel.Meta) ([]byte, error) {
+
+	ec, err := coremodel.GetEncryptionContext(tags...)
+
+	if err != nil || len(ec) == 0 {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(ec))
+
+	for k := range ec {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	aad := []byte{}
+
+	for _, k := range keys {
+
+		aad = appendLengthPrefixed(aad, []byte(k))
+		aad = appendLengthPrefixed(aad, []byte(ec[k]))
+
+	}
+
+	return aad, nil
+
+}
+
+// appendLengthPrefixed appends the _data_ to _buf_, prefixed with a big endian uint32 length.
+func appendLengthPrefixed(buf []byte, data []byte) []byte {
+
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(data)))
+
+	buf = append(buf, l[:]...)
+	return append(buf, data...)
+
+}
@@ -0,0 +1,111 @@
+package gocrypto
+
+import (
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptAES256(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewSymmetricKey("aes", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperAES256}, key.GetSupportedChiphers())
+
+	ec := coremodel.Meta{
+		Name:  coremodel.MetaEncryptionContext,
+		Value: map[string]string{"tenant": "a", "purpose": "test"},
+	}
+
+	cipher := NewCipher()
+
+	encrypted, err := cipher.Encrypt(c, []byte("hello world"), key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+
+	again, err := cipher.Encrypt(c, []byte("hello world"), key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "nonce must be random")
+
+	plaintext, err := cipher.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(plaintext))
+
+	_, err = cipher.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256)
+	assert.Error(t, err, "encryption context is missing")
+
+	encrypted[len(encrypted)-1] ^= 0x01
+	_, err = cipher.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256, ec)
+	assert.Error(t, err, "tampered ciphertext")
+}
+
+func TestEncryptAES256RequiresUsageAndKeySize(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	decryptOnly, err := NewSymmetricKey("aes", 256, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	_, err = NewCipher().Encrypt(c, []byte("msg"), decryptOnly, ifcrypto.ChiperAES256)
+	assert.Error(t, err)
+
+	short, err := NewSymmetricKeyFromKey("aes", make([]byte, 16), ifcrypto.KeyUsageEncrypt)
+	require.NoError(t, err)
+	assert.Empty(t, short.GetSupportedChiphers())
+
+	_, err = NewCipher().Encrypt(c, []byte("msg"), short, ifcrypto.ChiperAES256)
+	assert.Error(t, err)
+}
+
+func TestEncryptDecryptRSA(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+	assert.Equal(t, 2048, key.GetKeySize())
+
+	cipher := NewCipher()
+
+	for _, chipher := range []ifcrypto.Chipher{
+		ifcrypto.ChiperRsaOaepSha1, ifcrypto.ChiperRsaOaepSha256, ifcrypto.ChiperRsaPkcs1V15,
+	} {
+
+		t.Run(string(chipher), func(t *testing.T) {
+
+			limit := chipher.GetMaxPlaintextSize(key.GetKeySize())
+			plaintext := make([]byte, limit)
+
+			encrypted, err := cipher.Encrypt(c, plaintext, key.GetPublic(), chipher)
+			require.NoError(t, err)
+
+			decrypted, err := cipher.Decrypt(c, encrypted, key, chipher)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+
+			_, err = cipher.Encrypt(c, make([]byte, limit+1), key.GetPublic(), chipher)
+			assert.Error(t, err, "plaintext exceeds the limit")
+
+			_, err = cipher.Decrypt(c, encrypted, key.GetPublic(), chipher)
+			assert.Error(t, err, "public key can not decrypt")
+
+			encrypted, err = cipher.Encrypt(c, plaintext, key, chipher)
+			require.NoError(t, err, "private key encrypts using the public portion")
+
+			decrypted, err = cipher.Decrypt(c, encrypted, key, chipher)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+
+		})
+
+	}
+
+	assert.Equal(t, 214, ifcrypto.ChiperRsaOaepSha1.GetMaxPlaintextSize(2048))
+	assert.Equal(t, 190, ifcrypto.ChiperRsaOaepSha256.GetMaxPlaintextSize(2048))
+	assert.Equal(t, 446, ifcrypto.ChiperRsaOaepSha256.GetMaxPlaintextSize(4096))
+}
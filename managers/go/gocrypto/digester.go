@@ -2,11 +2,17 @@ package gocrypto
 
 import (
 	"fmt"
+	"hash"
+	"io"
 
 	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
 	"github.com/mariotoffia/goservice/utils"
 )
 
+// DigestChunkSize is the number of bytes read from an `io.Reader` in each
+// chunk when doing a streaming digest.
+const DigestChunkSize = 32 * 1024
+
 type GoDigester int
 
 func NewDigester() GoDigester {
@@ -16,27 +22,117 @@ func NewDigester() GoDigester {
 // Digest implements the `ifcrypto.Digester` interface.
 func (d GoDigester) Digest(key, msg []byte, h ...ifcrypto.HashAlgorithm) ([]byte, error) {
 
-	l := len(h)
+	hsh, err := ifcrypto.ComposeHash(key, h...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.ByteWriter(hsh, msg, hsh.BlockSize()); err != nil {
+
+		return nil, err
 
-	if l == 0 || l > 2 {
-		return nil, fmt.Errorf("number of hash algorithms must be either one or two")
 	}
 
-	hsh := h[0].GetHasher()
+	return hsh.Sum(nil), nil
+}
+
+// DigestReader implements the `ifcrypto.StreamDigester` interface.
+func (d GoDigester) DigestReader(
+	key []byte,
+	r io.Reader,
+	progress ifcrypto.DigestProgress,
+	h ...ifcrypto.HashAlgorithm,
+) ([]byte, error) {
+
+	hsh, err := ifcrypto.ComposeHash(key, h...)
+	if err != nil {
+		return nil, err
+	}
 
-	if l == 2 {
-		hsh = h[1].GetHasherWithKey(key, hsh)
+	if err := copyWithProgress(hsh, r, progress); err != nil {
+		return nil, err
 	}
 
-	if hsh == nil {
-		return nil, fmt.Errorf("nil hasher")
+	return hsh.Sum(nil), nil
+}
+
+// MultiDigest implements the `ifcrypto.StreamDigester` interface.
+func (d GoDigester) MultiDigest(
+	r io.Reader,
+	progress ifcrypto.DigestProgress,
+	h ...ifcrypto.HashAlgorithm,
+) (map[ifcrypto.HashAlgorithm][]byte, error) {
+
+	if len(h) == 0 {
+		return nil, fmt.Errorf("at least one hash algorithm is required")
 	}
 
-	if err := utils.ByteWriter(hsh, msg, hsh.BlockSize()); err != nil {
+	hashers := make(map[ifcrypto.HashAlgorithm]hash.Hash, len(h))
+	writers := make([]io.Writer, 0, len(h))
+
+	for _, alg := range h {
+
+		if _, ok := hashers[alg]; ok {
+			return nil, fmt.Errorf("hash algorithm: %s is specified more than once", alg)
+		}
+
+		hsh, err := ifcrypto.ComposeHash(nil, alg)
+		if err != nil {
+			return nil, err
+		}
 
+		hashers[alg] = hsh
+		writers = append(writers, hsh)
+
+	}
+
+	if err := copyWithProgress(io.MultiWriter(writers...), r, progress); err != nil {
 		return nil, err
+	}
+
+	digests := make(map[ifcrypto.HashAlgorithm][]byte, len(hashers))
+
+	for alg, hsh := range hashers {
+		digests[alg] = hsh.Sum(nil)
+	}
+
+	return digests, nil
+}
+
+// copyWithProgress copies _r_ into _w_ in `DigestChunkSize` chunks and invokes
+// _progress_, if not nil, after each chunk.
+func copyWithProgress(w io.Writer, r io.Reader, progress ifcrypto.DigestProgress) error {
+
+	buf := make([]byte, DigestChunkSize)
+
+	var read int64
+
+	for {
+
+		n, err := r.Read(buf)
+
+		if n > 0 {
+
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+
+			read += int64(n)
+
+			if progress != nil {
+				progress(read)
+			}
+
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
 
 	}
 
-	return hsh.Sum(nil), nil
 }
@@ -0,0 +1,327 @@
+package gocrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	_ "crypto/sha1" // register SHA-1 with crypto.Hash
+	"crypto/sha3"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ifcrypto.Digester       = NewDigester()
+	_ ifcrypto.StreamDigester = NewDigester()
+)
+
+var abcDigests = map[ifcrypto.HashAlgorithm]string{
+	ifcrypto.HashSha224:     "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+	ifcrypto.HashSha256:     "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	ifcrypto.HashSha384:     "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+	ifcrypto.HashSha512:     "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+	ifcrypto.HashSha512_256: "53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23",
+	ifcrypto.HashSha3_256:   "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+	ifcrypto.HashSha3_384:   "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+	ifcrypto.HashSha3_512:   "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+}
+
+func TestDigestKnownAnswers(t *testing.T) {
+
+	for alg, expected := range abcDigests {
+
+		digest, err := NewDigester().Digest(nil, []byte("abc"), alg)
+		require.NoError(t, err, alg)
+		assert.Equal(t, expected, hex.EncodeToString(digest), alg)
+
+		digest, err = NewDigester().DigestReader(nil, bytes.NewReader([]byte("abc")), nil, alg)
+		require.NoError(t, err, alg)
+		assert.Equal(t, expected, hex.EncodeToString(digest), alg)
+
+	}
+
+}
+
+func TestDigestReaderMatchesDigest(t *testing.T) {
+
+	msg := bytes.Repeat([]byte("0123456789"), DigestChunkSize/4)
+	key := []byte("secret")
+
+	expected, err := NewDigester().Digest(key, msg, ifcrypto.HashSha256, ifcrypto.HashHMac)
+	require.NoError(t, err)
+
+	var calls []int64
+
+	digest, err := NewDigester().DigestReader(
+		key, bytes.NewReader(msg),
+		func(read int64) { calls = append(calls, read) },
+		ifcrypto.HashSha256, ifcrypto.HashHMac,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, digest)
+
+	require.Len(t, calls, 3)
+	assert.Equal(t, int64(DigestChunkSize), calls[0])
+	assert.Equal(t, int64(len(msg)), calls[len(calls)-1])
+
+}
+
+func TestHMacRFC4231(t *testing.T) {
+
+	for _, tc := range rfc4231 {
+
+		for alg, expected := range tc.digest {
+
+			digest, err := NewDigester().Digest(tc.key, tc.data, alg, ifcrypto.HashHMac)
+			require.NoError(t, err, "%s %s", tc.name, alg)
+			assert.Equal(t, expected, hex.EncodeToString(digest), "%s %s", tc.name, alg)
+
+			digest, err = NewDigester().DigestReader(
+				tc.key, bytes.NewReader(tc.data), nil, alg, ifcrypto.HashHMac,
+			)
+
+			require.NoError(t, err, "%s %s", tc.name, alg)
+			assert.Equal(t, expected, hex.EncodeToString(digest), "%s %s", tc.name, alg)
+
+		}
+
+	}
+
+}
+
+func TestHashChains(t *testing.T) {
+
+	d := NewDigester()
+	key := []byte("key")
+	msg := []byte("The quick brown fox jumps over the lazy dog")
+
+	sha3256 := func() hash.Hash { return sha3.New256() }
+
+	inner := hmac.New(sha3256, key)
+	inner.Write(msg)
+
+	digest, err := d.Digest(key, msg, ifcrypto.HashSha3_256, ifcrypto.HashHMac)
+	require.NoError(t, err)
+	assert.Equal(t, inner.Sum(nil), digest)
+
+	outer := hmac.New(func() hash.Hash { return hmac.New(sha3256, key) }, key)
+	outer.Write(msg)
+
+	digest, err = d.Digest(key, msg, ifcrypto.HashSha3_256, ifcrypto.HashHMac, ifcrypto.HashHMac)
+	require.NoError(t, err)
+	assert.Equal(t, outer.Sum(nil), digest)
+
+	_, err = d.Digest(key, msg, ifcrypto.HashAlgorithm("md6"))
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnknownHashAlgorithm))
+
+	_, err = d.Digest(key, msg, ifcrypto.HashSha256, ifcrypto.HashAlgorithm("md6"))
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnknownHashAlgorithm))
+
+	_, err = d.Digest(key, msg, ifcrypto.HashNone)
+	assert.Error(t, err)
+
+	_, err = d.Digest(key, msg, ifcrypto.HashHMac)
+	assert.Error(t, err, "hmac must wrap a hash")
+
+	_, err = d.Digest(key, msg, ifcrypto.HashSha256, ifcrypto.HashSha512)
+	assert.Error(t, err, "plain hash can not wrap a hash")
+
+	_, err = d.Digest(nil, msg, ifcrypto.HashSha256, ifcrypto.HashHMac)
+	assert.Error(t, err, "hmac needs a key")
+
+	_, err = d.Digest(key, msg)
+	assert.Error(t, err)
+
+}
+
+func TestRegisterHash(t *testing.T) {
+
+	const sha1 = ifcrypto.HashAlgorithm("test_sha1")
+	const prefixed = ifcrypto.HashAlgorithm("test_prefixed")
+
+	require.False(t, sha1.Available(), "must not leak from a previous run")
+
+	t.Cleanup(func() {
+		ifcrypto.UnregisterHash(sha1)
+		ifcrypto.UnregisterHash(prefixed)
+	})
+
+	ifcrypto.RegisterHash(sha1, crypto.SHA1.New)
+	ifcrypto.RegisterHashWrapper(prefixed, func(key []byte, parent func() hash.Hash) (hash.Hash, error) {
+
+		hsh := parent()
+		hsh.Write(key)
+
+		return hsh, nil
+
+	})
+
+	assert.True(t, sha1.Available())
+	assert.False(t, prefixed.Available())
+	assert.False(t, ifcrypto.HashHMac.Available())
+	assert.False(t, ifcrypto.HashAlgorithm("md6").Available())
+
+	expected := hmac.New(crypto.SHA1.New, []byte("key"))
+	expected.Write([]byte("msg"))
+
+	digest, err := NewDigester().Digest([]byte("key"), []byte("msg"), sha1, ifcrypto.HashHMac)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Sum(nil), digest)
+
+	plain := crypto.SHA1.New()
+	plain.Write([]byte("keymsg"))
+
+	digest, err = NewDigester().Digest([]byte("key"), []byte("msg"), sha1, prefixed)
+	require.NoError(t, err)
+	assert.Equal(t, plain.Sum(nil), digest)
+
+}
+
+func TestMultiDigestSinglePass(t *testing.T) {
+
+	var algs []ifcrypto.HashAlgorithm
+
+	for alg := range abcDigests {
+		algs = append(algs, alg)
+	}
+
+	var progress int64
+
+	digests, err := NewDigester().MultiDigest(
+		bytes.NewReader([]byte("abc")), func(read int64) { progress = read }, algs...,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), progress)
+	require.Len(t, digests, len(abcDigests))
+
+	for alg, expected := range abcDigests {
+		assert.Equal(t, expected, hex.EncodeToString(digests[alg]), alg)
+	}
+
+}
+
+func TestMultiDigestErrors(t *testing.T) {
+
+	d := NewDigester()
+
+	_, err := d.MultiDigest(bytes.NewReader(nil), nil)
+	assert.Error(t, err)
+
+	_, err = d.MultiDigest(bytes.NewReader(nil), nil, ifcrypto.HashSha256, ifcrypto.HashSha256)
+	assert.Error(t, err)
+
+	_, err = d.MultiDigest(bytes.NewReader(nil), nil, ifcrypto.HashHMac)
+	assert.Error(t, err)
+
+	failure := errors.New("disk failure")
+
+	_, err = d.MultiDigest(io.MultiReader(
+		bytes.NewReader([]byte("abc")), &failingReader{err: failure},
+	), nil, ifcrypto.HashSha256)
+
+	assert.True(t, errors.Is(err, failure))
+
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// rfc4231 is the _HMAC-SHA-224/256/384/512_ test vectors from RFC 4231, test case 5
+// is excluded since it tests truncated output.
+var rfc4231 = []struct {
+	name   string
+	key    []byte
+	data   []byte
+	digest map[ifcrypto.HashAlgorithm]string
+}{
+	{
+		name: "test case 1",
+		key:  bytes.Repeat([]byte{0x0b}, 20),
+		data: []byte("Hi There"),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "896fb1128abbdf196832107cd49df33f47b4b1169912ba4f53684b22",
+			ifcrypto.HashSha256: "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7",
+			ifcrypto.HashSha384: "afd03944d84895626b0825f4ab46907f15f9dadbe4101ec682aa034c7cebc59cfaea9ea9076ede7f4af152e8b2fa9cb6",
+			ifcrypto.HashSha512: "87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cdedaa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854",
+		},
+	},
+	{
+		name: "test case 2",
+		key:  []byte("Jefe"),
+		data: []byte("what do ya want for nothing?"),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "a30e01098bc6dbbf45690f3a7e9e6d0f8bbea2a39e6148008fd05e44",
+			ifcrypto.HashSha256: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			ifcrypto.HashSha384: "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+			ifcrypto.HashSha512: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+	},
+	{
+		name: "test case 3",
+		key:  bytes.Repeat([]byte{0xaa}, 20),
+		data: bytes.Repeat([]byte{0xdd}, 50),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "7fb3cb3588c6c1f6ffa9694d7d6ad2649365b0c1f65d69d1ec8333ea",
+			ifcrypto.HashSha256: "773ea91e36800e46854db8ebd09181a72959098b3ef8c122d9635514ced565fe",
+			ifcrypto.HashSha384: "88062608d3e6ad8a0aa2ace014c8a86f0aa635d947ac9febe83ef4e55966144b2a5ab39dc13814b94e3ab6e101a34f27",
+			ifcrypto.HashSha512: "fa73b0089d56a284efb0f0756c890be9b1b5dbdd8ee81a3655f83e33b2279d39bf3e848279a722c806b485a47e67c807b946a337bee8942674278859e13292fb",
+		},
+	},
+	{
+		name: "test case 4",
+		key:  mustHex("0102030405060708090a0b0c0d0e0f10111213141516171819"),
+		data: bytes.Repeat([]byte{0xcd}, 50),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "6c11506874013cac6a2abc1bb382627cec6a90d86efc012de7afec5a",
+			ifcrypto.HashSha256: "82558a389a443c0ea4cc819899f2083a85f0faa3e578f8077a2e3ff46729665b",
+			ifcrypto.HashSha384: "3e8a69b7783c25851933ab6290af6ca77a9981480850009cc5577c6e1f573b4e6801dd23c4a7d679ccf8a386c674cffb",
+			ifcrypto.HashSha512: "b0ba465637458c6990e5a8c5f61d4af7e576d97ff94b872de76f8050361ee3dba91ca5c11aa25eb4d679275cc5788063a5f19741120c4f2de2adebeb10a298dd",
+		},
+	},
+	{
+		name: "test case 6",
+		key:  bytes.Repeat([]byte{0xaa}, 131),
+		data: []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "95e9a0db962095adaebe9b2d6f0dbce2d499f112f2d2b7273fa6870e",
+			ifcrypto.HashSha256: "60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54",
+			ifcrypto.HashSha384: "4ece084485813e9088d2c63a041bc5b44f9ef1012a2b588f3cd11f05033ac4c60c2ef6ab4030fe8296248df163f44952",
+			ifcrypto.HashSha512: "80b24263c7c1a3ebb71493c1dd7be8b49b46d1f41b4aeec1121b013783f8f3526b56d037e05f2598bd0fd2215d6a1e5295e64f73f63f0aec8b915a985d786598",
+		},
+	},
+	{
+		name: "test case 7",
+		key:  bytes.Repeat([]byte{0xaa}, 131),
+		data: []byte("This is a test using a larger than block-size key and a larger than block-size data. The key needs to be hashed before being used by the HMAC algorithm."),
+		digest: map[ifcrypto.HashAlgorithm]string{
+			ifcrypto.HashSha224: "3a854166ac5d9f023f54d517d0b39dbd946770db9c2b95c9f6f565d1",
+			ifcrypto.HashSha256: "9b09ffa71b942fcb27635fbcd5b0e944bfdc63644f0713938a7f51535c3a35e2",
+			ifcrypto.HashSha384: "6617178e941f020d351e2f254e8fd32c602420feb0b8fb9adccebb82461e99c5a678cc31e799176d3860e6110c46523e",
+			ifcrypto.HashSha512: "e37b6a775dc87dbaa4dfa9f96e5e3ffddebd71f8867289865df5a32d20cdc944b6022cac3c4982b10d5eeb55c3e4de15134676fb6de0446065c97440fa8c6a58",
+		},
+	},
+}
+
+func mustHex(s string) []byte {
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
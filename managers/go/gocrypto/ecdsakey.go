@@ -5,16 +5,17 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/x509"
+	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 
 	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
 	"github.com/mariotoffia/goservice/utils/cryptoutils"
 )
 
-// ECDSAPrivateKey implements the `ifcrypto.KeyPair` interface for a `*rsa.PrivateKey`.
+// ECDSAPrivateKey implements the `ifcrypto.KeyPair` interface for a `*ecdsa.PrivateKey`.
 type ECDSAPrivateKey struct {
 	KeyBase
 	key    *ecdsa.PrivateKey
@@ -23,7 +24,9 @@ type ECDSAPrivateKey struct {
 
 // NewECDSAPrivateKeyFromKey creates a new `ECDSAPrivateKey`
 //
-// The public key portion derives the same usage as the private key
+// The public key portion derives the same usage as the private key. If the curve is
+// `cryptoutils.Secp256k1` the key type is `ifcrypto.KeyTypeEccSecgP256k1`, otherwise
+// `ifcrypto.KeyTypeEccNistP` that also supports `ifcrypto.ChiperEciesHkdfSha256AES256`.
 func NewECDSAPrivateKeyFromKey(
 	id string,
 	key *ecdsa.PrivateKey,
@@ -33,10 +36,10 @@ func NewECDSAPrivateKeyFromKey(
 	return &ECDSAPrivateKey{
 		KeyBase: KeyBase{
 			id:      id,
-			keyType: ifcrypto.KeyTypeRsa,
+			keyType: ecdsaKeyType(key.Curve),
 			keySize: key.Params().BitSize,
 			usage:   usage,
-			chiper:  []ifcrypto.Chipher{},
+			chiper:  ecdsaChiphers(key.Curve),
 		},
 		key:    key,
 		public: NewECDSAPublicKeyFromKey(id, &key.PublicKey, usage...),
@@ -52,25 +55,19 @@ func NewECDSAPrivateKeyFromPEM(
 ) (*ECDSAPrivateKey, error) {
 
 	if block.Type == "PRIVATE KEY" {
-		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 
+		key, err := cryptoutils.ParsePKCS8ECPrivateKey(block.Bytes)
 		if err != nil {
 			return nil, err
 		}
 
-		if ecdsakey, ok := key.(*ecdsa.PrivateKey); ok {
-
-			return NewECDSAPrivateKeyFromKey(id, ecdsakey, usage...), nil
-
-		}
-
-		return nil, fmt.Errorf("not a *ecdsa.PrivateKey: %T", key)
+		return NewECDSAPrivateKeyFromKey(id, key, usage...), nil
 
 	}
 
 	if block.Type == "EC PRIVATE KEY" {
 
-		key, err := x509.ParseECPrivateKey(block.Bytes)
+		key, err := cryptoutils.ParseECPrivateKey(block.Bytes)
 		if err != nil {
 			return nil, err
 		}
@@ -83,10 +80,31 @@ func NewECDSAPrivateKeyFromPEM(
 
 }
 
-// NewECDSAPrivateKey generates a new `ECDSAPrivateKey` using the `rand.Reader` as entropy.
+// NewECDSAPrivateKey generates a new `ECDSAPrivateKey` on the _NIST_ curve of _bits_ size
+// using the `rand.Reader` as entropy.
+//
+// The _bits_ must be one of the `ifcrypto.KeySizes` for `ifcrypto.KeyTypeEccNistP`, i.e. 256,
+// 384 or 521. Use `NewSecp256k1PrivateKey` for the _secp256k1_ curve.
 func NewECDSAPrivateKey(id string, bits int, usage ...ifcrypto.KeyUsage) (*ECDSAPrivateKey, error) {
 
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	curve, err := nistCurve(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewECDSAPrivateKeyFromKey(id, key, usage...), nil
+}
+
+// NewSecp256k1PrivateKey generates a new `ECDSAPrivateKey` on the `cryptoutils.Secp256k1` curve
+// using the `crypto/rand` reader as entropy.
+func NewSecp256k1PrivateKey(id string, usage ...ifcrypto.KeyUsage) (*ECDSAPrivateKey, error) {
+
+	key, err := generateSecp256k1()
 	if err != nil {
 		return nil, err
 	}
@@ -96,16 +114,28 @@ func NewECDSAPrivateKey(id string, bits int, usage ...ifcrypto.KeyUsage) (*ECDSA
 
 // Sign implements the `crypto.Signer` _interface_. The _opts_
 // arguments is not used.
+//
+// Signatures on the `cryptoutils.Secp256k1` curve are deterministic, as of _RFC 6979_, and low-S
+// where the _rand_ is not used.
 func (r *ECDSAPrivateKey) Sign(
 	rand io.Reader,
 	digest []byte,
 	opts crypto.SignerOpts,
 ) ([]byte, error) {
 
+	if cryptoutils.IsSecp256k1(r.key.Curve) {
+		return signSecp256k1(r.key, digest)
+	}
+
 	return r.key.Sign(rand, digest, opts)
 
 }
 
+// Public returns the underlying `*ecdsa.PublicKey` as of the `crypto.Signer` _interface_.
+func (r *ECDSAPrivateKey) Public() crypto.PublicKey {
+	return &r.key.PublicKey
+}
+
 // GetPublic returns the public portion of the key
 func (r *ECDSAPrivateKey) GetPublic() ifcrypto.PublicKey {
 	return r.public
@@ -150,7 +180,7 @@ func (r *ECDSAPrivateKey) IsRemoteKey() bool {
 	return false
 }
 
-// ECDSAPublicKey implements the `ifcrypto.PublicKey` interface for `*rsa.PublicKey`
+// ECDSAPublicKey implements the `ifcrypto.PublicKey` interface for `*ecdsa.PublicKey`
 type ECDSAPublicKey struct {
 	KeyBase
 	key *ecdsa.PublicKey
@@ -166,9 +196,10 @@ func NewECDSAPublicKeyFromKey(
 	return &ECDSAPublicKey{
 		KeyBase: KeyBase{
 			id:      id,
-			keyType: ifcrypto.KeyTypeRsa,
+			keyType: ecdsaKeyType(key.Curve),
 			keySize: key.Params().BitSize,
 			usage:   usage,
+			chiper:  ecdsaChiphers(key.Curve),
 		},
 		key: key,
 	}
@@ -184,19 +215,12 @@ func NewECDSAPublicKeyFromPEM(
 
 	if block.Type == "PUBLIC KEY" || block.Type == "EC PUBLIC KEY" {
 
-		key, err := x509.ParsePKIXPublicKey(block.Bytes)
-
+		key, err := cryptoutils.ParsePKIXECPublicKey(block.Bytes)
 		if err != nil {
 			return nil, err
 		}
 
-		if ecdsakey, ok := key.(*ecdsa.PublicKey); ok {
-
-			return NewECDSAPublicKeyFromKey(id, ecdsakey, usage...), nil
-
-		}
-
-		return nil, fmt.Errorf("not a *ecdsa.PublicKey: %T", key)
+		return NewECDSAPublicKeyFromKey(id, key, usage...), nil
 
 	}
 
@@ -232,7 +256,7 @@ func (r *ECDSAPublicKey) IsSymmetric() bool {
 //
 // If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
 func (r *ECDSAPublicKey) IsPrivate() bool {
-	return true
+	return false
 }
 
 // IsRemoteKey returns `true` if the key is not present in current process memory.
@@ -242,3 +266,60 @@ func (r *ECDSAPublicKey) IsPrivate() bool {
 func (r *ECDSAPublicKey) IsRemoteKey() bool {
 	return false
 }
+
+// NormalizeLowS normalizes the _ASN.1 DER_ encoded _ECDSA_ _signature_ to have a _s_ value
+// that is at most half of the _curve_ order.
+//
+// Both _(r, s)_ and _(r, n - s)_ are valid signatures, many blockchains do only accept the
+// low-S form. Signatures from e.g. _AWS KMS_ may be normalized using this function.
+func NormalizeLowS(curve elliptic.Curve, signature []byte) ([]byte, error) {
+
+	var sig struct {
+		R, S *big.Int
+	}
+
+	if rest, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data after ECDSA signature")
+	}
+
+	n := curve.Params().N
+	half := new(big.Int).Rsh(n, 1)
+
+	if sig.S.Cmp(half) <= 0 {
+		return signature, nil
+	}
+
+	sig.S = new(big.Int).Sub(n, sig.S)
+
+	return asn1.Marshal(sig)
+}
+
+// nistCurve returns the _NIST_ curve of _bits_ size.
+func nistCurve(bits int) (elliptic.Curve, error) {
+
+	switch bits {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	}
+
+	return nil, fmt.Errorf(
+		"%w: key type: %s do not support key size: %d, supported: %v", ifcrypto.ErrUnsupportedKeySize,
+		ifcrypto.KeyTypeEccNistP, bits, ifcrypto.KeySizes[ifcrypto.KeyTypeEccNistP],
+	)
+}
+
+// ecdsaKeyType returns the `ifcrypto.KeyType` of the _curve_.
+func ecdsaKeyType(curve elliptic.Curve) ifcrypto.KeyType {
+
+	if cryptoutils.IsSecp256k1(curve) {
+		return ifcrypto.KeyTypeEccSecgP256k1
+	}
+
+	return ifcrypto.KeyTypeEccNistP
+}
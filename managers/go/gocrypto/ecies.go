@@ -0,0 +1,118 @@
+package gocrypto
+
+import (
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// eciesInfo is the _HKDF_ info prefix, it is followed by the ephemeral and recipient public keys.
+const eciesInfo = "ecies-hkdf-sha256-aes256"
+
+// encryptECIES encrypts the _plaintext_ using `ifcrypto.ChiperEciesHkdfSha256AES256` to the public _key_.
+//
+// A ephemeral key is generated on the same curve as _key_ and the _ECDH_ shared secret is
+// passed through _HKDF-SHA256_ to derive a _AES-256-GCM_ key. The output is the ephemeral
+// public key followed by the output of `encryptAESGCM`.
+func encryptECIES(key ifcrypto.Key, plaintext []byte, tags ...coremodel.Meta) ([]byte, error) {
+
+	pub, err := ecdhPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := pub.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	aes, err := eciesKey(secret, ephemeral.PublicKey().Bytes(), pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encryptAESGCM(aes, plaintext, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ephemeral.PublicKey().Bytes(), encrypted...), nil
+}
+
+// decryptECIES decrypts the output of `encryptECIES` using the private _key_.
+func decryptECIES(key ifcrypto.Key, encrypted []byte, tags ...coremodel.Meta) ([]byte, error) {
+
+	priv, err := ecdhPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(priv.PublicKey().Bytes())
+
+	if len(encrypted) < size {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	ephemeral, err := priv.Curve().NewPublicKey(encrypted[:size])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+
+	secret, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	aes, err := eciesKey(secret, encrypted[:size], priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptAESGCM(aes, encrypted[size:], tags...)
+}
+
+// eciesKey derives the _AES-256_ key from the _ECDH_ shared _secret_, the secret is cleared
+// afterwards. The _HKDF_ info binds the _ephemeral_ and _recipient_ public keys to the key.
+func eciesKey(secret, ephemeral, recipient []byte) (*SymmetricKey, error) {
+
+	defer zero(secret)
+
+	info := make([]byte, 0, len(eciesInfo)+len(ephemeral)+len(recipient))
+	info = append(append(append(info, eciesInfo...), ephemeral...), recipient...)
+
+	raw, err := hkdf.Key(sha256.New, secret, nil, string(info), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zero(raw)
+
+	return NewSymmetricKeyFromKey("", raw, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+}
+
+// eciesChiphers returns the chiphers supported by `KeyTypeX25519` keys.
+func eciesChiphers() []ifcrypto.Chipher {
+	return []ifcrypto.Chipher{ifcrypto.ChiperEciesHkdfSha256AES256}
+}
+
+// ecdsaChiphers returns the chiphers that a _ECDSA_ key on _curve_ supports, only the
+// _NIST_ curves supports _ECIES_ since `crypto/ecdh` do not support _secp256k1_.
+func ecdsaChiphers(curve elliptic.Curve) []ifcrypto.Chipher {
+
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return eciesChiphers()
+	}
+
+	return []ifcrypto.Chipher{}
+}
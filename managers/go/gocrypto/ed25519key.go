@@ -0,0 +1,250 @@
+package gocrypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+)
+
+// Ed25519PrivateKey implements the `ifcrypto.KeyPair` interface for a `ed25519.PrivateKey`.
+type Ed25519PrivateKey struct {
+	KeyBase
+	key    ed25519.PrivateKey
+	public *Ed25519PublicKey
+}
+
+// NewEd25519PrivateKeyFromKey creates a new `Ed25519PrivateKey`
+//
+// The public key portion derives the same usage as the private key
+func NewEd25519PrivateKeyFromKey(
+	id string,
+	key ed25519.PrivateKey,
+	usage ...ifcrypto.KeyUsage,
+) (*Ed25519PrivateKey, error) {
+
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("must specify a ed25519 private key of %d bytes, got: %d", ed25519.PrivateKeySize, len(key))
+	}
+
+	public, err := NewEd25519PublicKeyFromKey(id, key.Public().(ed25519.PublicKey), usage...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ed25519PrivateKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeEd25519,
+			keySize: 256,
+			usage:   usage,
+			chiper:  []ifcrypto.Chipher{},
+		},
+		key:    key,
+		public: public,
+	}, nil
+
+}
+
+// NewEd25519PrivateKeyFromPEM initializes a new `ed25519.PrivateKey` from the underlying _PKCS#8_ _PEM_ block.
+func NewEd25519PrivateKeyFromPEM(
+	block pem.Block,
+	id string,
+	usage ...ifcrypto.KeyUsage,
+) (*Ed25519PrivateKey, error) {
+
+	if block.Type == "PRIVATE KEY" {
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if edkey, ok := key.(ed25519.PrivateKey); ok {
+
+			return NewEd25519PrivateKeyFromKey(id, edkey, usage...)
+
+		}
+
+		return nil, fmt.Errorf("not a ed25519.PrivateKey: %T", key)
+
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block: %s", block.Type)
+
+}
+
+// NewEd25519PrivateKey generates a new `Ed25519PrivateKey` using the `rand.Reader` as entropy.
+func NewEd25519PrivateKey(id string, usage ...ifcrypto.KeyUsage) (*Ed25519PrivateKey, error) {
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEd25519PrivateKeyFromKey(id, key, usage...)
+}
+
+// Sign implements the `crypto.Signer` _interface_.
+//
+// The _digest_ is the message itself and _opts_ must have a zero `crypto.Hash`.
+func (r *Ed25519PrivateKey) Sign(
+	rand io.Reader,
+	digest []byte,
+	opts crypto.SignerOpts,
+) ([]byte, error) {
+
+	return r.key.Sign(rand, digest, opts)
+
+}
+
+// Public returns the underlying `ed25519.PublicKey` as of the `crypto.Signer` _interface_.
+func (r *Ed25519PrivateKey) Public() crypto.PublicKey {
+	return r.key.Public()
+}
+
+// GetPublic returns the public portion of the key
+func (r *Ed25519PrivateKey) GetPublic() ifcrypto.PublicKey {
+	return r.public
+}
+
+// PEMWrite will write the key onto _w_.
+//
+// If private key, and _public_ is `true`, it will in addition write the public portion as well.
+func (r *Ed25519PrivateKey) PEMWrite(w io.Writer, public bool) error {
+
+	return cryptoutils.Ed25519PrivateKeyToPEM(w, r.key, public)
+
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+func (r *Ed25519PrivateKey) GetKey() interface{} {
+	return r.key
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *Ed25519PrivateKey) IsSymmetric() bool {
+	return false
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *Ed25519PrivateKey) IsPrivate() bool {
+	return true
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *Ed25519PrivateKey) IsRemoteKey() bool {
+	return false
+}
+
+// Ed25519PublicKey implements the `ifcrypto.PublicKey` interface for `ed25519.PublicKey`
+type Ed25519PublicKey struct {
+	KeyBase
+	key ed25519.PublicKey
+}
+
+// NewEd25519PublicKeyFromKey creates a instance based on a existing public key.
+func NewEd25519PublicKeyFromKey(
+	id string,
+	key ed25519.PublicKey,
+	usage ...ifcrypto.KeyUsage,
+) (*Ed25519PublicKey, error) {
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("must specify a ed25519 public key of %d bytes, got: %d", ed25519.PublicKeySize, len(key))
+	}
+
+	return &Ed25519PublicKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeEd25519,
+			keySize: 256,
+			usage:   usage,
+		},
+		key: key,
+	}, nil
+
+}
+
+// NewEd25519PublicKeyFromPEM initializes a new `ed25519.PublicKey` from the underlying _SPKI_ _PEM_ block.
+func NewEd25519PublicKeyFromPEM(
+	block pem.Block,
+	id string,
+	usage ...ifcrypto.KeyUsage,
+) (*Ed25519PublicKey, error) {
+
+	if block.Type == "PUBLIC KEY" {
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if edkey, ok := key.(ed25519.PublicKey); ok {
+
+			return NewEd25519PublicKeyFromKey(id, edkey, usage...)
+
+		}
+
+		return nil, fmt.Errorf("not a ed25519.PublicKey: %T", key)
+
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block: %s", block.Type)
+
+}
+
+// PEMWrite will write the key onto _w_.
+//
+// Since this is a public key, it will ignore the _public_ parameter.
+func (r *Ed25519PublicKey) PEMWrite(w io.Writer, public bool) error {
+
+	return cryptoutils.Ed25519PublicKeyToPEM(w, r.key)
+
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+func (r *Ed25519PublicKey) GetKey() interface{} {
+	return r.key
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *Ed25519PublicKey) IsSymmetric() bool {
+	return false
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *Ed25519PublicKey) IsPrivate() bool {
+	return false
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *Ed25519PublicKey) IsRemoteKey() bool {
+	return false
+}
@@ -0,0 +1,102 @@
+package gocrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/pem"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ifcrypto.KeyPair   = &Ed25519PrivateKey{}
+	_ ifcrypto.PublicKey = &Ed25519PublicKey{}
+	_ ifcrypto.PEMWriter = &Ed25519PrivateKey{}
+	_ ifcrypto.PEMWriter = &Ed25519PublicKey{}
+)
+
+func TestEd25519KeyProperties(t *testing.T) {
+
+	key, err := NewEd25519PrivateKey("ed", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	assert.Equal(t, ifcrypto.KeyTypeEd25519, key.GetKeyType())
+	assert.Equal(t, 256, key.GetKeySize())
+	assert.True(t, key.IsPrivate())
+	assert.False(t, key.GetPublic().IsPrivate())
+	assert.True(t, key.CanSign(ifcrypto.SignAlgorithmEd25519))
+	assert.False(t, key.CanSign(ifcrypto.SignAlgorithmEcdSha256))
+	assert.True(t, key.GetPublic().CanVerify(ifcrypto.SignAlgorithmEd25519))
+	assert.Equal(t, crypto.Hash(0), ifcrypto.SignAlgorithmEd25519.GetCryptoHash())
+
+}
+
+func TestEd25519FromKeyValidatesSize(t *testing.T) {
+
+	_, err := NewEd25519PrivateKeyFromKey("ed", nil, ifcrypto.KeyUsageSign)
+	assert.Error(t, err)
+
+	_, err = NewEd25519PrivateKeyFromKey("ed", make(ed25519.PrivateKey, ed25519.SeedSize), ifcrypto.KeyUsageSign)
+	assert.Error(t, err, "seed is not a private key")
+
+	_, err = NewEd25519PublicKeyFromKey("ed", make(ed25519.PublicKey, 31), ifcrypto.KeyUsageVerify)
+	assert.Error(t, err)
+
+	key, err := NewEd25519PrivateKeyFromKey(
+		"ed", ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)), ifcrypto.KeyUsageSign,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign}, key.GetPublic().GetKeyUsage())
+
+}
+
+func TestEd25519PEMRoundTrip(t *testing.T) {
+
+	key, err := NewEd25519PrivateKey("ed", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, key.PEMWrite(&buf, true))
+
+	data := buf.Bytes()
+
+	private, err := cryptoutils.PEMToEd25519PrivateKey(data)
+	require.NoError(t, err)
+	assert.Equal(t, key.GetKey(), private)
+
+	public, err := cryptoutils.PEMToEd25519PublicKey(data)
+	require.NoError(t, err)
+	assert.Equal(t, key.GetPublic().GetKey(), public)
+
+	block, rest := pem.Decode(data)
+	require.NotNil(t, block)
+	assert.Equal(t, "PRIVATE KEY", block.Type)
+
+	parsed, err := NewEd25519PrivateKeyFromPEM(*block, "parsed", ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+	assert.Equal(t, key.GetKey(), parsed.GetKey())
+
+	block, _ = pem.Decode(rest)
+	require.NotNil(t, block)
+	assert.Equal(t, "PUBLIC KEY", block.Type)
+
+	parsedPublic, err := NewEd25519PublicKeyFromPEM(*block, "parsed", ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+	assert.Equal(t, ed25519.PublicKey(public), parsedPublic.GetKey())
+
+	ecdsaKey, err := NewECDSAPrivateKey("ecdsa", 256)
+	require.NoError(t, err)
+
+	buf.Reset()
+	require.NoError(t, cryptoutils.ECDSAPrivateKeyToPEM(&buf, ecdsaKey.GetKey().(*ecdsa.PrivateKey), false))
+
+	_, err = cryptoutils.PEMToEd25519PrivateKey(buf.Bytes())
+	assert.Error(t, err)
+
+}
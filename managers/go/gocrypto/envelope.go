@@ -0,0 +1,275 @@
+package gocrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// envelopeVersion is the version of the serialized envelope.
+const envelopeVersion byte = 1
+
+// Envelope implements the `ifcrypto.Cipherable` interface using _envelope encryption_.
+//
+// When encrypting, a new data key is generated using the `ifcrypto.DataKeyGenerator` under the
+// key passed to `Encrypt`. The plaintext is encrypted locally, using the data key, and the
+// encrypted data key is stored along with the encrypted data in a self contained blob. The
+// plaintext data key is zeroed directly after use.
+//
+// When decrypting, the data key is decrypted using the `ifcrypto.Cipherable` and the
+// `ifcrypto.ChiperAES256` cipher, hence the key must be a symmetric key.
+//
+// The blob has the following layout, where all lengths are big endian uint32.
+//
+// .Blob Layout
+// [source]
+// ----
+// version (1 byte) | len | key id | len | cipher | len | encrypted data key | nonce | ciphertext | tag
+// ----
+//
+// Everything before the nonce, and any `coremodel.MetaEncryptionContext`, is authenticated. The
+// encryption context is also passed when generating and decrypting the data key.
+//
+// .Example
+// [source,go]
+// ----
+// env := gocrypto.NewEnvelope(km, km); blob, err := env.Encrypt(c, plaintext, key, ifcrypto.ChiperAES256)
+// ----
+type Envelope struct {
+	generator ifcrypto.DataKeyGenerator
+	unwrapper ifcrypto.Cipherable
+}
+
+// NewEnvelope creates a new `Envelope` that uses _generator_ to generate data keys and
+// _unwrapper_ to decrypt them.
+//
+// Both is usually the same _KMS_, e.g. `awskms.AwsKms` or `gokms.GoKms`.
+func NewEnvelope(generator ifcrypto.DataKeyGenerator, unwrapper ifcrypto.Cipherable) *Envelope {
+
+	return &Envelope{
+		generator: generator,
+		unwrapper: unwrapper,
+	}
+
+}
+
+// Encrypt implements the `ifcrypto.Cipherable` interface.
+//
+// The _key_ is the key to encrypt the data key under and the _cipher_ is the cipher used to
+// encrypt the _plaintext_ locally. Currently only `ifcrypto.ChiperAES256` is supported.
+func (e *Envelope) Encrypt(
+	c ifctx.ServiceContext,
+	plaintext []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key")
+	}
+
+	if cipher != ifcrypto.ChiperAES256 {
+		return nil, fmt.Errorf("unsupported envelope cipher: %s", cipher)
+	}
+
+	dataKey, encryptedKey, err := e.generator.GenerateDataKey(c, key, cipher, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zero(dataKey)
+
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []byte{envelopeVersion}
+	header = appendLengthPrefixed(header, []byte(key.GetID()))
+	header = appendLengthPrefixed(header, []byte(cipher))
+	header = appendLengthPrefixed(header, encryptedKey)
+
+	aad, err := envelopeAAD(header, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	blob := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	blob = append(blob, header...)
+	blob = append(blob, nonce...)
+
+	return aead.Seal(blob, nonce, plaintext, aad), nil
+}
+
+// Decrypt implements the `ifcrypto.Cipherable` interface.
+//
+// The _key_ is the key that the data key is encrypted under, see `GetEnvelopeKeyID`, and the
+// _cipher_ must be the same as when encrypted.
+func (e *Envelope) Decrypt(
+	c ifctx.ServiceContext,
+	encrypted []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key")
+	}
+
+	env, err := parseEnvelope(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if env.cipher != cipher {
+		return nil, fmt.Errorf("envelope is encrypted using: %s not: %s", env.cipher, cipher)
+	}
+
+	dataKey, err := e.unwrapper.Decrypt(c, env.encryptedKey, key, ifcrypto.ChiperAES256, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zero(dataKey)
+
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := envelopeAAD(env.header, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(env.data) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	nonce := env.data[:aead.NonceSize()]
+
+	return aead.Open(nil, nonce, env.data[aead.NonceSize():], aad)
+}
+
+// GetEnvelopeKeyID returns the id of the key that the data key in the _encrypted_ envelope
+// is encrypted under.
+func GetEnvelopeKeyID(encrypted []byte) (string, error) {
+
+	env, err := parseEnvelope(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	return env.keyID, nil
+}
+
+// envelope is a parsed envelope blob.
+type envelope struct {
+	// header is the raw header, including the version.
+	header       []byte
+	keyID        string
+	cipher       ifcrypto.Chipher
+	encryptedKey []byte
+	// data is the nonce, ciphertext and tag.
+	data []byte
+}
+
+// parseEnvelope parses the _blob_ produced by `Envelope.Encrypt`.
+func parseEnvelope(blob []byte) (*envelope, error) {
+
+	if len(blob) == 0 || blob[0] != envelopeVersion {
+		return nil, fmt.Errorf("not a envelope or unsupported envelope version")
+	}
+
+	rest := blob[1:]
+	fields := make([][]byte, 3)
+
+	for i := range fields {
+
+		field, remaining, err := readLengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[i] = field
+		rest = remaining
+
+	}
+
+	return &envelope{
+		header:       blob[:len(blob)-len(rest)],
+		keyID:        string(fields[0]),
+		cipher:       ifcrypto.Chipher(fields[1]),
+		encryptedKey: fields[2],
+		data:         rest,
+	}, nil
+
+}
+
+// readLengthPrefixed reads data written by `appendLengthPrefixed` and returns the data
+// and the remaining bytes.
+func readLengthPrefixed(buf []byte) ([]byte, []byte, error) {
+
+	if len(buf) < 4 {
+		return nil, nil, fmt.Errorf("envelope is truncated")
+	}
+
+	l := binary.BigEndian.Uint32(buf)
+
+	if uint64(l) > uint64(len(buf)-4) {
+		return nil, nil, fmt.Errorf("envelope is truncated")
+	}
+
+	return buf[4 : 4+l], buf[4+l:], nil
+
+}
+
+// envelopeAAD is the _header_ followed by the encryption context in _tags_.
+func envelopeAAD(header []byte, tags ...coremodel.Meta) ([]byte, error) {
+
+	ec, err := encryptionContextAAD(tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	aad := make([]byte, 0, len(header)+len(ec))
+	aad = append(aad, header...)
+
+	return append(aad, ec...), nil
+
+}
+
+// newAESGCM creates a _AES-GCM_ `cipher.AEAD` from the raw _key_.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+
+}
+
+// zero overwrites the _b_ with zeros.
+func zero(b []byte) {
+
+	for i := range b {
+		b[i] = 0
+	}
+
+}
@@ -0,0 +1,98 @@
+package gocrypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDataKeyGenerator generates data keys using `GoCipher` and remembers the
+// plaintext data keys it has handed out.
+type testDataKeyGenerator struct {
+	GoCipher
+	dataKeys [][]byte
+}
+
+func (g *testDataKeyGenerator) GenerateDataKey(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, []byte, error) {
+
+	plaintext := make([]byte, cipher.GetDataKeySize())
+
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, err
+	}
+
+	encrypted, err := g.Encrypt(c, plaintext, key, ifcrypto.ChiperAES256, tags...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g.dataKeys = append(g.dataKeys, plaintext)
+	return plaintext, encrypted, nil
+}
+
+func (g *testDataKeyGenerator) GenerateDataKeyWithoutPlaintext(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	_, encrypted, err := g.GenerateDataKey(c, key, cipher, tags...)
+	return encrypted, err
+}
+
+func TestEnvelopeEncryptDecrypt(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewSymmetricKey("master", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	generator := &testDataKeyGenerator{}
+	env := NewEnvelope(generator, NewCipher())
+
+	ec := coremodel.Meta{Name: coremodel.MetaEncryptionContext, Value: map[string]string{"tenant": "a"}}
+	plaintext := bytes.Repeat([]byte("large payload "), 10000)
+
+	blob, err := env.Encrypt(c, plaintext, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+
+	require.Len(t, generator.dataKeys, 1)
+	assert.Equal(t, make([]byte, 32), generator.dataKeys[0], "data key shall be zeroed")
+
+	id, err := GetEnvelopeKeyID(blob)
+	require.NoError(t, err)
+	assert.Equal(t, "master", id)
+
+	decrypted, err := env.Decrypt(c, blob, key, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = env.Decrypt(c, blob, key, ifcrypto.ChiperAES256)
+	assert.Error(t, err, "encryption context is missing")
+
+	tampered := append([]byte{}, blob...)
+	tampered[len(tampered)-1] ^= 1
+
+	_, err = env.Decrypt(c, tampered, key, ifcrypto.ChiperAES256, ec)
+	assert.Error(t, err)
+
+	_, err = env.Decrypt(c, blob[:20], key, ifcrypto.ChiperAES256, ec)
+	assert.Error(t, err)
+
+	_, err = env.Encrypt(c, plaintext, key, ifcrypto.ChiperRsaOaepSha256)
+	assert.Error(t, err)
+
+}
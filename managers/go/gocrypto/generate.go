@@ -0,0 +1,63 @@
+package gocrypto
+
+import (
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// GenerateKey generates a new key of _keyType_ and _keySize_ bits using the `rand.Reader` as entropy.
+//
+// The _keySize_ is validated using `ifcrypto.ValidateKeySize` and a descriptive error, wrapping
+// `ifcrypto.ErrUnsupportedKeyType` or `ifcrypto.ErrUnsupportedKeySize`, is returned if not supported.
+//
+// .Supported Key Types
+// |===
+// |Key Type |Key |Sizes
+//
+// |`ifcrypto.KeyTypeRsa` |`RSAPrivateKey` |2048, 3072, 4096
+// |`ifcrypto.KeyTypeEccNistP` |`ECDSAPrivateKey` |256, 384, 521
+// |`ifcrypto.KeyTypeEccSecgP256k1` |`ECDSAPrivateKey` |256
+// |`ifcrypto.KeyTypeEd25519` |`Ed25519PrivateKey` |256
+// |`ifcrypto.KeyTypeX25519` |`X25519PrivateKey` |256
+// |`ifcrypto.KeyTypeSymmetric` |`SymmetricKey` |multiple of 8
+// |===
+func GenerateKey(
+	id string,
+	keyType ifcrypto.KeyType,
+	keySize int,
+	usage ...ifcrypto.KeyUsage,
+) (ifcrypto.Key, error) {
+
+	if err := ifcrypto.ValidateKeySize(keyType, keySize); err != nil {
+		return nil, err
+	}
+
+	var (
+		key ifcrypto.Key
+		err error
+	)
+
+	switch keyType {
+	case ifcrypto.KeyTypeRsa:
+		key, err = NewRSAPrivateKey(id, keySize, usage...)
+	case ifcrypto.KeyTypeEccNistP:
+		key, err = NewECDSAPrivateKey(id, keySize, usage...)
+	case ifcrypto.KeyTypeEccSecgP256k1:
+		key, err = NewSecp256k1PrivateKey(id, usage...)
+	case ifcrypto.KeyTypeEd25519:
+		key, err = NewEd25519PrivateKey(id, usage...)
+	case ifcrypto.KeyTypeX25519:
+		key, err = NewX25519PrivateKey(id, usage...)
+	case ifcrypto.KeyTypeSymmetric:
+		key, err = NewSymmetricKey(id, keySize, usage...)
+	default:
+		return nil, fmt.Errorf("%w: %s", ifcrypto.ErrUnsupportedKeyType, keyType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,116 @@
+package gocrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+
+	tests := []struct {
+		keyType ifcrypto.KeyType
+		keySize int
+		curve   elliptic.Curve
+	}{
+		{ifcrypto.KeyTypeRsa, 2048, nil},
+		{ifcrypto.KeyTypeEccNistP, 256, elliptic.P256()},
+		{ifcrypto.KeyTypeEccNistP, 384, elliptic.P384()},
+		{ifcrypto.KeyTypeEccNistP, 521, elliptic.P521()},
+		{ifcrypto.KeyTypeEccSecgP256k1, 256, cryptoutils.Secp256k1()},
+		{ifcrypto.KeyTypeEd25519, 256, nil},
+		{ifcrypto.KeyTypeSymmetric, 128, nil},
+		{ifcrypto.KeyTypeSymmetric, 256, nil},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(string(tt.keyType), func(t *testing.T) {
+
+			key, err := GenerateKey("id", tt.keyType, tt.keySize, ifcrypto.KeyUsageSign)
+			require.NoError(t, err)
+
+			assert.Equal(t, "id", key.GetID())
+			assert.Equal(t, tt.keyType, key.GetKeyType())
+			assert.Equal(t, tt.keySize, key.GetKeySize())
+			assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign}, key.GetKeyUsage())
+
+			if tt.curve != nil {
+				assert.Equal(t, tt.curve, key.GetKey().(*ecdsa.PrivateKey).Curve)
+				assert.Equal(t, tt.keyType, key.(ifcrypto.KeyPair).GetPublic().GetKeyType())
+			}
+
+		})
+
+	}
+}
+
+func TestGenerateKeyRejectsInvalidTypeAndSize(t *testing.T) {
+
+	tests := []struct {
+		keyType ifcrypto.KeyType
+		keySize int
+		err     error
+	}{
+		{ifcrypto.KeyTypeRsa, 1024, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyTypeEccNistP, 224, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyTypeEccSecgP256k1, 384, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyTypeEd25519, 448, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyTypeSymmetric, 0, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyTypeSymmetric, 129, ifcrypto.ErrUnsupportedKeySize},
+		{ifcrypto.KeyType("dsa"), 1024, ifcrypto.ErrUnsupportedKeyType},
+	}
+
+	for _, tt := range tests {
+
+		_, err := GenerateKey("id", tt.keyType, tt.keySize)
+		assert.True(t, errors.Is(err, tt.err), "%s %d: %v", tt.keyType, tt.keySize, err)
+
+	}
+
+	_, err := NewECDSAPrivateKey("id", 255)
+	assert.True(t, errors.Is(err, ifcrypto.ErrUnsupportedKeySize))
+
+}
+
+func TestGoKeyGenerator(t *testing.T) {
+
+	var generator ifcrypto.KeyGenerator = NewKeyGenerator()
+
+	key, err := generator.GenerateKey(nil, ifcrypto.KeySpec{
+		KeyType:  ifcrypto.KeyTypeRsa,
+		KeySize:  2048,
+		Usage:    []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt},
+		Chiphers: []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256},
+	})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, key.GetID())
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256}, key.GetSupportedChiphers())
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256}, key.(ifcrypto.KeyPair).GetPublic().GetSupportedChiphers())
+
+	key, err = generator.GenerateKey(nil, ifcrypto.KeySpec{
+		ID:      "aes",
+		KeyType: ifcrypto.KeyTypeSymmetric,
+		KeySize: 256,
+		Usage:   []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "aes", key.GetID())
+	assert.True(t, key.IsSymmetric())
+
+	_, err = generator.GenerateKey(nil, ifcrypto.KeySpec{
+		KeyType:  ifcrypto.KeyTypeEccNistP,
+		KeySize:  256,
+		Usage:    []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign},
+		Chiphers: []ifcrypto.Chipher{ifcrypto.ChiperAES256},
+	})
+	assert.Error(t, err)
+
+}
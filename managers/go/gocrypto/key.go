@@ -1,8 +1,6 @@
 package gocrypto
 
 import (
-	"fmt"
-
 	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
 )
 
@@ -18,6 +16,31 @@ type KeyBase struct {
 	keySize int
 }
 
+// NewKeyBase creates a new `KeyBase` to be embedded in keys that are implemented
+// outside of this package, for example remote keys.
+func NewKeyBase(
+	id string,
+	keyType ifcrypto.KeyType,
+	keySize int,
+	usage []ifcrypto.KeyUsage,
+	chiphers []ifcrypto.Chipher,
+) KeyBase {
+
+	return KeyBase{
+		id:      id,
+		keyType: keyType,
+		keySize: keySize,
+		usage:   usage,
+		chiper:  chiphers,
+	}
+
+}
+
+// setChiphers replaces the supported chiphers of the key.
+func (b *KeyBase) setChiphers(chiphers []ifcrypto.Chipher) {
+	b.chiper = chiphers
+}
+
 // GetID returns a id of the key.
 //
 // This is always specific of the backing _KMS_ system. For example, in _AWS_ this is a _ARN_ to
@@ -44,7 +67,7 @@ func (b *KeyBase) GetSupportedChiphers() []ifcrypto.Chipher {
 // CanSign checks if the current _Key_ may participate in _alg_ `SignAlgorithm` to do sign operations with.
 func (b *KeyBase) CanSign(alg ifcrypto.SignAlgorithm) bool {
 
-	if !b.HasUsage(ifcrypto.KeyUsageVerify) {
+	if !b.HasUsage(ifcrypto.KeyUsageSign) {
 		return false
 	}
 
@@ -62,6 +85,39 @@ func (b *KeyBase) CanVerify(alg ifcrypto.SignAlgorithm) bool {
 	return b.matchSignAlgForKey(alg)
 }
 
+// CanMac checks if the current _Key_ may be used to generate and verify a _MAC_ using _alg_ `MacAlgorithm`.
+//
+// Only `ifcrypto.KeyTypeSymmetric` keys may be used for _MAC_ operations.
+func (b *KeyBase) CanMac(alg ifcrypto.MacAlgorithm) bool {
+
+	if !b.HasUsage(ifcrypto.KeyUsageMac) {
+		return false
+	}
+
+	return b.keyType == ifcrypto.KeyTypeSymmetric &&
+		alg.GetHashAlgorithm() != ifcrypto.HashNone
+}
+
+// CanEncrypt checks if the current _Key_ may be used to encrypt using the _cipher_.
+func (b *KeyBase) CanEncrypt(cipher ifcrypto.Chipher) bool {
+
+	if !b.HasUsage(ifcrypto.KeyUsageEncrypt) {
+		return false
+	}
+
+	return b.HasChipher(cipher)
+}
+
+// CanDecrypt checks if the current _Key_ may be used to decrypt using the _cipher_.
+func (b *KeyBase) CanDecrypt(cipher ifcrypto.Chipher) bool {
+
+	if !b.HasUsage(ifcrypto.KeyUsageDecrypt) {
+		return false
+	}
+
+	return b.HasChipher(cipher)
+}
+
 // GetKeySize returns the number of bits of the key
 func (b *KeyBase) GetKeySize() int {
 	return b.keySize
@@ -82,8 +138,25 @@ func (b *KeyBase) HasUsage(u ifcrypto.KeyUsage) bool {
 
 }
 
+// HasChipher checks if the _b_ supports the _c_ `ifcrypto.Chipher`.
+func (b *KeyBase) HasChipher(c ifcrypto.Chipher) bool {
+
+	for i := range b.chiper {
+
+		if b.chiper[i] == c {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
 // matchSignAlgForKey will ensure that the _alg_ do match the `ifcore.KeyType`
 // for this _b_.
+//
+// If the _alg_ is unknown, `false` is returned.
 func (b *KeyBase) matchSignAlgForKey(alg ifcrypto.SignAlgorithm) bool {
 
 	switch alg {
@@ -102,10 +175,12 @@ func (b *KeyBase) matchSignAlgForKey(alg ifcrypto.SignAlgorithm) bool {
 
 		return b.keyType == ifcrypto.KeyTypeEccNistP ||
 			b.keyType == ifcrypto.KeyTypeEccSecgP256k1
+
+	case ifcrypto.SignAlgorithmEd25519:
+
+		return b.keyType == ifcrypto.KeyTypeEd25519
 	}
 
-	panic(
-		fmt.Sprintf("can not handle SignAlgorithm: %s", alg),
-	)
+	return false
 
 }
@@ -0,0 +1,105 @@
+package gocrypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// GoKeyAgreement implements the `ifcrypto.KeyAgreement` interface using `crypto/ecdh`.
+//
+// It supports `ECDSAPrivateKey` on the _NIST P-256, P-384_ and _P-521_ curves and
+// `X25519PrivateKey`. The _secp256k1_ curve is not supported.
+type GoKeyAgreement int
+
+// NewKeyAgreement creates a new `GoKeyAgreement`.
+func NewKeyAgreement() GoKeyAgreement {
+	return 0
+}
+
+// DeriveSharedSecret implements the `ifcrypto.KeyAgreement` interface.
+func (a GoKeyAgreement) DeriveSharedSecret(
+	c ifctx.ServiceContext,
+	private ifcrypto.Key,
+	peer ifcrypto.PublicKey,
+	meta ...coremodel.Meta,
+) ([]byte, error) {
+
+	if private == nil || peer == nil {
+		return nil, fmt.Errorf("must specify both a private and a peer public key")
+	}
+
+	agreement := false
+
+	for _, u := range private.GetKeyUsage() {
+
+		if u == ifcrypto.KeyUsageKeyAgreement {
+			agreement = true
+		}
+
+	}
+
+	if !agreement {
+		return nil, fmt.Errorf("key: %s may not be used for key agreement", private.GetID())
+	}
+
+	priv, err := ecdhPrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := ecdhPublicKey(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	if priv.Curve() != pub.Curve() {
+		return nil, fmt.Errorf("key: %s and peer: %s are not on the same curve", private.GetID(), peer.GetID())
+	}
+
+	return priv.ECDH(pub)
+}
+
+// ecdhPrivateKey returns the _key_ as a `*ecdh.PrivateKey`.
+func ecdhPrivateKey(key ifcrypto.Key) (*ecdh.PrivateKey, error) {
+
+	switch k := key.GetKey().(type) {
+	case *ecdh.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+
+		priv, err := k.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("key: %s do not support ECDH: %w", key.GetID(), err)
+		}
+
+		return priv, nil
+
+	}
+
+	return nil, fmt.Errorf("key: %s is not a elliptic curve private key, got: %T", key.GetID(), key.GetKey())
+}
+
+// ecdhPublicKey returns the _key_ as a `*ecdh.PublicKey`.
+func ecdhPublicKey(key ifcrypto.Key) (*ecdh.PublicKey, error) {
+
+	switch k := key.GetKey().(type) {
+	case *ecdh.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+
+		pub, err := k.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("key: %s do not support ECDH: %w", key.GetID(), err)
+		}
+
+		return pub, nil
+
+	}
+
+	return nil, fmt.Errorf("key: %s is not a elliptic curve public key, got: %T", key.GetID(), key.GetKey())
+}
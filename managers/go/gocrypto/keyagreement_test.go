@@ -0,0 +1,208 @@
+package gocrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ifcrypto.KeyAgreement = NewKeyAgreement()
+	_ ifcrypto.KeyPair      = &X25519PrivateKey{}
+	_ ifcrypto.PEMWriter    = &X25519PrivateKey{}
+	_ ifcrypto.PEMWriter    = &X25519PublicKey{}
+)
+
+func TestX25519KeyAgreementRFC7748(t *testing.T) {
+
+	alicePriv, err := ecdh.X25519().NewPrivateKey(
+		mustHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"),
+	)
+	require.NoError(t, err)
+
+	bobPub, err := ecdh.X25519().NewPublicKey(
+		mustHex("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f"),
+	)
+	require.NoError(t, err)
+
+	alice, err := NewX25519PrivateKeyFromKey("alice", alicePriv, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	bob, err := NewX25519PublicKeyFromKey("bob", bobPub)
+	require.NoError(t, err)
+
+	secret, err := NewKeyAgreement().DeriveSharedSecret(nil, alice, bob)
+	require.NoError(t, err)
+
+	assert.Equal(t, "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742", hex.EncodeToString(secret))
+
+}
+
+func TestKeyAgreementBothParties(t *testing.T) {
+
+	keys := map[string]func(id string) (ifcrypto.KeyPair, error){
+		"x25519": func(id string) (ifcrypto.KeyPair, error) {
+			return NewX25519PrivateKey(id, ifcrypto.KeyUsageKeyAgreement)
+		},
+		"p256": func(id string) (ifcrypto.KeyPair, error) {
+			return NewECDSAPrivateKey(id, 256, ifcrypto.KeyUsageKeyAgreement)
+		},
+		"p521": func(id string) (ifcrypto.KeyPair, error) {
+			return NewECDSAPrivateKey(id, 521, ifcrypto.KeyUsageKeyAgreement)
+		},
+	}
+
+	for name, fn := range keys {
+
+		alice, err := fn("alice")
+		require.NoError(t, err, name)
+
+		bob, err := fn("bob")
+		require.NoError(t, err, name)
+
+		a, err := NewKeyAgreement().DeriveSharedSecret(nil, alice, bob.GetPublic())
+		require.NoError(t, err, name)
+
+		b, err := NewKeyAgreement().DeriveSharedSecret(nil, bob, alice.GetPublic())
+		require.NoError(t, err, name)
+
+		assert.Equal(t, a, b, name)
+
+	}
+
+}
+
+func TestKeyAgreementValidation(t *testing.T) {
+
+	agreement := NewKeyAgreement()
+
+	x25519, err := NewX25519PrivateKey("x25519", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	p256, err := NewECDSAPrivateKey("p256", 256, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	_, err = agreement.DeriveSharedSecret(nil, x25519, p256.GetPublic())
+	assert.Error(t, err, "different curves")
+
+	signing, err := NewECDSAPrivateKey("signing", 256, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	_, err = agreement.DeriveSharedSecret(nil, signing, p256.GetPublic())
+	assert.Error(t, err, "missing key agreement usage")
+
+	k1, err := NewSecp256k1PrivateKey("k1", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	_, err = agreement.DeriveSharedSecret(nil, k1, k1.GetPublic())
+	assert.Error(t, err, "secp256k1 is not supported by crypto/ecdh")
+
+	rsa, err := NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	_, err = agreement.DeriveSharedSecret(nil, rsa, p256.GetPublic())
+	assert.Error(t, err)
+
+}
+
+func TestEciesEncryptDecrypt(t *testing.T) {
+
+	keys := []func() (ifcrypto.KeyPair, error){
+		func() (ifcrypto.KeyPair, error) {
+			return NewX25519PrivateKey("x25519", ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+		},
+		func() (ifcrypto.KeyPair, error) {
+			return NewECDSAPrivateKey("p256", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+		},
+		func() (ifcrypto.KeyPair, error) {
+			return NewECDSAPrivateKey("p384", 384, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+		},
+		func() (ifcrypto.KeyPair, error) {
+			return NewECDSAPrivateKey("p521", 521, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+		},
+	}
+
+	config := []byte(`{"wifi":"secret","interval":30}`)
+	ec := coremodel.Meta{Name: coremodel.MetaEncryptionContext, Value: map[string]string{"device": "d-1"}}
+	cipher := ifcrypto.ChiperEciesHkdfSha256AES256
+
+	for _, fn := range keys {
+
+		key, err := fn()
+		require.NoError(t, err)
+
+		assert.True(t, key.GetPublic().CanEncrypt(cipher), key.GetID())
+		assert.True(t, key.CanDecrypt(cipher), key.GetID())
+
+		encrypted, err := NewCipher().Encrypt(nil, config, key.GetPublic(), cipher, ec)
+		require.NoError(t, err, key.GetID())
+
+		other, err := NewCipher().Encrypt(nil, config, key.GetPublic(), cipher, ec)
+		require.NoError(t, err, key.GetID())
+		assert.NotEqual(t, encrypted, other, "ephemeral key must differ for each encryption")
+
+		plaintext, err := NewCipher().Decrypt(nil, encrypted, key, cipher, ec)
+		require.NoError(t, err, key.GetID())
+		assert.Equal(t, config, plaintext)
+
+		_, err = NewCipher().Decrypt(nil, encrypted, key, cipher)
+		assert.Error(t, err, "encryption context must match")
+
+		tampered := append([]byte{}, encrypted...)
+		tampered[len(tampered)-1] ^= 1
+
+		_, err = NewCipher().Decrypt(nil, tampered, key, cipher, ec)
+		assert.Error(t, err)
+
+		_, err = NewCipher().Decrypt(nil, encrypted[:10], key, cipher, ec)
+		assert.Error(t, err)
+
+		wrong, err := fn()
+		require.NoError(t, err)
+
+		_, err = NewCipher().Decrypt(nil, encrypted, wrong, cipher, ec)
+		assert.Error(t, err, "other private key")
+
+	}
+
+	k1, err := NewSecp256k1PrivateKey("k1", ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+	assert.False(t, k1.GetPublic().CanEncrypt(cipher))
+
+}
+
+func TestX25519PEM(t *testing.T) {
+
+	key, err := NewX25519PrivateKey("x25519", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, key.PEMWrite(&buf, true))
+
+	block, rest := pem.Decode(buf.Bytes())
+	require.NotNil(t, block)
+
+	priv, err := NewX25519PrivateKeyFromPEM(*block, "x25519", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+	assert.True(t, key.GetKey().(*ecdh.PrivateKey).Equal(priv.GetKey()))
+
+	block, _ = pem.Decode(rest)
+	require.NotNil(t, block)
+
+	pub, err := NewX25519PublicKeyFromPEM(*block, "x25519")
+	require.NoError(t, err)
+	assert.True(t, key.GetPublic().GetKey().(*ecdh.PublicKey).Equal(pub.GetKey()))
+	assert.Equal(t, ifcrypto.KeyTypeX25519, pub.GetKeyType())
+
+	generated, err := GenerateKey("generated", ifcrypto.KeyTypeX25519, 256, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+	assert.IsType(t, &X25519PrivateKey{}, generated)
+
+}
@@ -0,0 +1,149 @@
+package gocrypto
+
+import (
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"fmt"
+	"hash"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+	"golang.org/x/crypto/scrypt"
+)
+
+// GoKeyDeriver implements the `ifcrypto.KeyDeriver` interface and derives
+// `SymmetricKey` keys in process memory.
+type GoKeyDeriver int
+
+// NewKeyDeriver creates a new `GoKeyDeriver`.
+func NewKeyDeriver() GoKeyDeriver {
+	return 0
+}
+
+// DeriveKey implements the `ifcrypto.KeyDeriver` interface.
+//
+// If the _spec_ has no _ID_ a _UUID_ is assigned. The derived key has the _spec_
+// _Usage_, the same as a key generated using `NewSymmetricKey`.
+func (d GoKeyDeriver) DeriveKey(
+	c ifctx.ServiceContext,
+	secret []byte,
+	spec ifcrypto.DeriveSpec,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("must specify a non empty secret to derive from")
+	}
+
+	if spec.Algorithm != ifcrypto.KdfHkdfExtract && (spec.KeySize <= 0 || spec.KeySize%8 != 0) {
+
+		return nil, fmt.Errorf(
+			"%w: derived key size must be a positive multiple of 8, got: %d",
+			ifcrypto.ErrUnsupportedKeySize, spec.KeySize,
+		)
+
+	}
+
+	derived, err := deriveKey(secret, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zero(derived)
+
+	id := spec.ID
+
+	if id == "" {
+		id = utils.NewUUID()
+	}
+
+	return NewSymmetricKeyFromKey(id, derived, spec.Usage...)
+}
+
+// deriveKey derives the raw key bytes from _secret_ using the _spec_.
+func deriveKey(secret []byte, spec ifcrypto.DeriveSpec) ([]byte, error) {
+
+	size := spec.KeySize / 8
+
+	switch spec.Algorithm {
+	case ifcrypto.KdfHkdf, ifcrypto.KdfHkdfExtract, ifcrypto.KdfHkdfExpand:
+
+		h, err := kdfHash(spec.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		switch spec.Algorithm {
+		case ifcrypto.KdfHkdfExtract:
+			return hkdf.Extract(h, secret, spec.Salt)
+		case ifcrypto.KdfHkdfExpand:
+			return hkdf.Expand(h, secret, string(spec.Info), size)
+		}
+
+		return hkdf.Key(h, secret, spec.Salt, string(spec.Info), size)
+
+	case ifcrypto.KdfPbkdf2:
+
+		h, err := kdfHash(spec.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(spec.Salt) == 0 {
+			return nil, fmt.Errorf("pbkdf2 requires a salt")
+		}
+
+		iterations := spec.Iterations
+
+		if iterations == 0 {
+			iterations = ifcrypto.DefaultPbkdf2Iterations
+		}
+
+		if iterations < 0 {
+			return nil, fmt.Errorf("pbkdf2 iterations must be positive, got: %d", iterations)
+		}
+
+		return pbkdf2.Key(h, string(secret), spec.Salt, iterations, size)
+
+	case ifcrypto.KdfScrypt:
+
+		if len(spec.Salt) == 0 {
+			return nil, fmt.Errorf("scrypt requires a salt")
+		}
+
+		n, r, p := spec.N, spec.R, spec.P
+
+		if n == 0 {
+			n = ifcrypto.DefaultScryptN
+		}
+
+		if r == 0 {
+			r = ifcrypto.DefaultScryptR
+		}
+
+		if p == 0 {
+			p = ifcrypto.DefaultScryptP
+		}
+
+		return scrypt.Key(secret, spec.Salt, n, r, p, size)
+
+	}
+
+	return nil, fmt.Errorf("unsupported key derivation function: %s", spec.Algorithm)
+}
+
+// kdfHash returns the constructor of the plain _alg_ hash, if empty `ifcrypto.HashSha256`.
+func kdfHash(alg ifcrypto.HashAlgorithm) (func() hash.Hash, error) {
+
+	if alg == "" {
+		alg = ifcrypto.HashSha256
+	}
+
+	if !alg.Available() {
+		return nil, fmt.Errorf("%w: %s can not be used to derive keys", ifcrypto.ErrUnknownHashAlgorithm, alg)
+	}
+
+	return ifcrypto.ComposeHashFunc(nil, alg)
+}
@@ -0,0 +1,145 @@
+package gocrypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ifcrypto.KeyDeriver = NewKeyDeriver()
+
+func TestDeriveKeyHkdfRFC5869(t *testing.T) {
+
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	salt := mustHex("000102030405060708090a0b0c")
+	info := mustHex("f0f1f2f3f4f5f6f7f8f9")
+
+	key, err := NewKeyDeriver().DeriveKey(nil, ikm, ifcrypto.DeriveSpec{
+		ID:        "okm",
+		Algorithm: ifcrypto.KdfHkdf,
+		Hash:      ifcrypto.HashSha256,
+		Salt:      salt,
+		Info:      info,
+		KeySize:   42 * 8,
+		Usage:     []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "okm", key.GetID())
+	assert.Equal(t, ifcrypto.KeyTypeSymmetric, key.GetKeyType())
+	assert.Equal(t, 336, key.GetKeySize())
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}, key.GetKeyUsage())
+	assert.Equal(
+		t, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		hex.EncodeToString(key.GetKey().([]byte)),
+	)
+
+	prk, err := NewKeyDeriver().DeriveKey(nil, ikm, ifcrypto.DeriveSpec{
+		Algorithm: ifcrypto.KdfHkdfExtract,
+		Salt:      salt,
+	})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, prk.GetID())
+	assert.Equal(
+		t, "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+		hex.EncodeToString(prk.GetKey().([]byte)),
+	)
+
+	okm, err := NewKeyDeriver().DeriveKey(nil, prk.GetKey().([]byte), ifcrypto.DeriveSpec{
+		Algorithm: ifcrypto.KdfHkdfExpand,
+		Info:      info,
+		KeySize:   42 * 8,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, key.GetKey(), okm.GetKey())
+
+}
+
+func TestDeriveKeyPbkdf2(t *testing.T) {
+
+	key, err := NewKeyDeriver().DeriveKey(nil, []byte("password"), ifcrypto.DeriveSpec{
+		Algorithm:  ifcrypto.KdfPbkdf2,
+		Salt:       []byte("salt"),
+		Iterations: 1,
+		KeySize:    256,
+		Usage:      []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b",
+		hex.EncodeToString(key.GetKey().([]byte)),
+	)
+
+	assert.True(t, key.CanEncrypt(ifcrypto.ChiperAES256), "derived 256 bit keys may be used with aes")
+
+	key, err = NewKeyDeriver().DeriveKey(nil, []byte("password"), ifcrypto.DeriveSpec{
+		Algorithm:  ifcrypto.KdfPbkdf2,
+		Hash:       ifcrypto.HashSha512,
+		Salt:       []byte("salt"),
+		Iterations: 2,
+		KeySize:    512,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t, "e1d9c16aa681708a45f5c7c4e215ceb66e011a2e9f0040713f18aefdb866d53c"+
+			"f76cab2868a39b9f7840edce4fef5a82be67335c77a6068e04112754f27ccf4e",
+		hex.EncodeToString(key.GetKey().([]byte)),
+	)
+
+}
+
+func TestDeriveKeyScryptRFC7914(t *testing.T) {
+
+	key, err := NewKeyDeriver().DeriveKey(nil, []byte("password"), ifcrypto.DeriveSpec{
+		Algorithm: ifcrypto.KdfScrypt,
+		Salt:      []byte("NaCl"),
+		N:         1024,
+		R:         8,
+		P:         16,
+		KeySize:   512,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162"+
+			"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		hex.EncodeToString(key.GetKey().([]byte)),
+	)
+
+}
+
+func TestDeriveKeyValidation(t *testing.T) {
+
+	d := NewKeyDeriver()
+	secret := []byte("secret")
+
+	tests := []ifcrypto.DeriveSpec{
+		{Algorithm: ifcrypto.KdfHkdf, KeySize: 0},
+		{Algorithm: ifcrypto.KdfHkdf, KeySize: 100},
+		{Algorithm: ifcrypto.KdfHkdf, KeySize: 256, Hash: ifcrypto.HashHMac},
+		{Algorithm: ifcrypto.KdfHkdf, KeySize: 256, Hash: "md6"},
+		{Algorithm: ifcrypto.KdfPbkdf2, KeySize: 256},
+		{Algorithm: ifcrypto.KdfPbkdf2, KeySize: 256, Salt: []byte("salt"), Iterations: -1},
+		{Algorithm: ifcrypto.KdfScrypt, KeySize: 256},
+		{Algorithm: ifcrypto.KdfScrypt, KeySize: 256, Salt: []byte("salt"), N: 1000},
+		{Algorithm: "argon2", KeySize: 256},
+	}
+
+	for _, spec := range tests {
+
+		_, err := d.DeriveKey(nil, secret, spec)
+		assert.Error(t, err, "%+v", spec)
+
+	}
+
+	_, err := d.DeriveKey(nil, nil, ifcrypto.DeriveSpec{Algorithm: ifcrypto.KdfHkdf, KeySize: 256})
+	assert.Error(t, err)
+
+}
@@ -0,0 +1,99 @@
+package gocrypto
+
+import (
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// GoKeyGenerator implements the `ifcrypto.KeyGenerator` interface by generating
+// keys in process memory using `GenerateKey`.
+//
+// The `ifcrypto.KeySpec` _Description_ and _Tags_ are not used since in memory keys
+// do not have any such.
+type GoKeyGenerator int
+
+// NewKeyGenerator creates a new `GoKeyGenerator`.
+func NewKeyGenerator() GoKeyGenerator {
+	return 0
+}
+
+// GenerateKey implements the `ifcrypto.KeyGenerator` interface.
+//
+// If the _spec_ has no _ID_ a _UUID_ is assigned. If the _spec_ has _Chiphers_, those
+// must be supported by the key and the key is restricted to only those.
+func (g GoKeyGenerator) GenerateKey(
+	c ifctx.ServiceContext,
+	spec ifcrypto.KeySpec,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	id := spec.ID
+
+	if id == "" {
+		id = utils.NewUUID()
+	}
+
+	key, err := GenerateKey(id, spec.KeyType, spec.KeySize, spec.Usage...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.Chiphers) == 0 {
+		return key, nil
+	}
+
+	if err := restrictChiphers(key, spec.Chiphers); err != nil {
+		return nil, err
+	}
+
+	if kp, ok := key.(ifcrypto.KeyPair); ok {
+
+		if err := restrictChiphers(kp.GetPublic(), spec.Chiphers); err != nil {
+			return nil, err
+		}
+
+	}
+
+	return key, nil
+}
+
+// restrictChiphers restricts the _key_ to only support the _chiphers_.
+//
+// All _chiphers_ must be supported by the _key_.
+func restrictChiphers(key ifcrypto.Key, chiphers []ifcrypto.Chipher) error {
+
+	for _, c := range chiphers {
+
+		if !hasChipher(key.GetSupportedChiphers(), c) {
+			return fmt.Errorf(
+				"key type: %s of size: %d do not support cipher: %s",
+				key.GetKeyType(), key.GetKeySize(), c,
+			)
+		}
+
+	}
+
+	if setter, ok := key.(interface{ setChiphers([]ifcrypto.Chipher) }); ok {
+		setter.setChiphers(append([]ifcrypto.Chipher{}, chiphers...))
+	}
+
+	return nil
+}
+
+// hasChipher checks if _c_ is in _chiphers_.
+func hasChipher(chiphers []ifcrypto.Chipher, c ifcrypto.Chipher) bool {
+
+	for i := range chiphers {
+
+		if chiphers[i] == c {
+			return true
+		}
+
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package gocrypto
+
+import (
+	"crypto/hmac"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// GoMac implements the `ifcrypto.Mac` interface using symmetric keys that resides
+// in process memory.
+//
+// The _HMAC_ is composed using `ifcrypto.ComposeHash` over the `ifcrypto.MacAlgorithm`
+// hash and `ifcrypto.HashHMac`.
+type GoMac int
+
+// NewMac creates a new `GoMac`.
+func NewMac() GoMac {
+	return 0
+}
+
+// GenerateMac implements the `ifcrypto.Mac` interface.
+func (m GoMac) GenerateMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to generate mac with")
+	}
+
+	if !key.CanMac(alg) {
+		return nil, fmt.Errorf("key: %s can not generate mac using: %s", key.GetID(), alg)
+	}
+
+	raw, ok := key.GetKey().([]byte)
+
+	if !ok {
+		return nil, fmt.Errorf("key: %s is not a symmetric key in memory", key.GetID())
+	}
+
+	hsh, err := ifcrypto.ComposeHash(raw, alg.GetHashAlgorithm(), ifcrypto.HashHMac)
+	if err != nil {
+		return nil, err
+	}
+
+	hsh.Write(msg)
+
+	return hsh.Sum(nil), nil
+}
+
+// VerifyMac implements the `ifcrypto.Mac` interface.
+func (m GoMac) VerifyMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	mac []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) error {
+
+	expected, err := m.GenerateMac(c, msg, key, alg, tags...)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(expected, mac) {
+		return ifcrypto.ErrInvalidMac
+	}
+
+	return nil
+}
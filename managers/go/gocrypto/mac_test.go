@@ -0,0 +1,90 @@
+package gocrypto
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ifcrypto.Mac = NewMac()
+
+func TestGenerateVerifyMacRFC4231(t *testing.T) {
+
+	algs := map[ifcrypto.HashAlgorithm]ifcrypto.MacAlgorithm{
+		ifcrypto.HashSha224: ifcrypto.MacAlgorithmHmacSha224,
+		ifcrypto.HashSha256: ifcrypto.MacAlgorithmHmacSha256,
+		ifcrypto.HashSha384: ifcrypto.MacAlgorithmHmacSha384,
+		ifcrypto.HashSha512: ifcrypto.MacAlgorithmHmacSha512,
+	}
+
+	for _, tc := range rfc4231 {
+
+		key, err := NewSymmetricKeyFromKey("hmac", tc.key, ifcrypto.KeyUsageMac)
+		require.NoError(t, err)
+
+		for hash, expected := range tc.digest {
+
+			alg := algs[hash]
+			assert.True(t, key.CanMac(alg))
+
+			mac, err := NewMac().GenerateMac(nil, tc.data, key, alg)
+			require.NoError(t, err, "%s %s", tc.name, alg)
+			assert.Equal(t, expected, hex.EncodeToString(mac), "%s %s", tc.name, alg)
+
+			assert.NoError(t, NewMac().VerifyMac(nil, tc.data, mac, key, alg))
+
+		}
+
+	}
+
+}
+
+func TestVerifyMacFailures(t *testing.T) {
+
+	key, err := NewSymmetricKey("hmac", 256, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	msg := []byte("hello world")
+
+	mac, err := NewMac().GenerateMac(nil, msg, key, ifcrypto.MacAlgorithmHmacSha256)
+	require.NoError(t, err)
+
+	err = NewMac().VerifyMac(nil, []byte("hello world!"), mac, key, ifcrypto.MacAlgorithmHmacSha256)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac))
+
+	err = NewMac().VerifyMac(nil, msg, mac[:16], key, ifcrypto.MacAlgorithmHmacSha256)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac))
+
+	err = NewMac().VerifyMac(nil, msg, mac, key, ifcrypto.MacAlgorithmHmacSha512)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac))
+
+}
+
+func TestCanMac(t *testing.T) {
+
+	encryptKey, err := NewSymmetricKey("aes", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	assert.False(t, encryptKey.CanMac(ifcrypto.MacAlgorithmHmacSha256))
+
+	_, err = NewMac().GenerateMac(nil, []byte("msg"), encryptKey, ifcrypto.MacAlgorithmHmacSha256)
+	assert.Error(t, err)
+
+	macKey, err := NewSymmetricKey("hmac", 512, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	assert.True(t, macKey.CanMac(ifcrypto.MacAlgorithmHmacSha512))
+	assert.False(t, macKey.CanMac(ifcrypto.MacAlgorithm("hmac-md5")))
+	assert.False(t, macKey.CanEncrypt(ifcrypto.ChiperAES256))
+	assert.False(t, macKey.CanSign(ifcrypto.SignAlgorithmEcdSha256))
+
+	rsaKey, err := NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	assert.False(t, rsaKey.CanMac(ifcrypto.MacAlgorithmHmacSha256))
+
+}
@@ -33,9 +33,9 @@ func NewRSAPrivateKeyFromKey(
 		KeyBase: KeyBase{
 			id:      id,
 			keyType: ifcrypto.KeyTypeRsa,
-			keySize: key.Size(),
+			keySize: key.N.BitLen(),
 			usage:   usage,
-			chiper:  []ifcrypto.Chipher{},
+			chiper:  rsaChiphers(),
 		},
 		key:    key,
 		public: NewRSAPublicKeyFromKey(id, &key.PublicKey, usage...),
@@ -119,6 +119,11 @@ func (r *RSAPrivateKey) Decrypt(
 
 }
 
+// Public returns the underlying `*rsa.PublicKey` as of the `crypto.Signer` _interface_.
+func (r *RSAPrivateKey) Public() crypto.PublicKey {
+	return &r.key.PublicKey
+}
+
 // GetPublic returns the public portion of the key
 func (r *RSAPrivateKey) GetPublic() ifcrypto.PublicKey {
 	return r.public
@@ -180,8 +185,9 @@ func NewRSAPublicKeyFromKey(
 		KeyBase: KeyBase{
 			id:      id,
 			keyType: ifcrypto.KeyTypeRsa,
-			keySize: key.Size(),
+			keySize: key.N.BitLen(),
 			usage:   usage,
+			chiper:  rsaChiphers(),
 		},
 		key: key,
 	}
@@ -245,7 +251,7 @@ func (r *RSAPublicKey) IsSymmetric() bool {
 //
 // If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
 func (r *RSAPublicKey) IsPrivate() bool {
-	return true
+	return false
 }
 
 // IsRemoteKey returns `true` if the key is not present in current process memory.
@@ -255,3 +261,14 @@ func (r *RSAPublicKey) IsPrivate() bool {
 func (r *RSAPublicKey) IsRemoteKey() bool {
 	return false
 }
+
+// rsaChiphers returns the chiphers that a _RSA_ key supports.
+func rsaChiphers() []ifcrypto.Chipher {
+
+	return []ifcrypto.Chipher{
+		ifcrypto.ChiperRsaOaepSha1,
+		ifcrypto.ChiperRsaOaepSha256,
+		ifcrypto.ChiperRsaPkcs1V15,
+	}
+
+}
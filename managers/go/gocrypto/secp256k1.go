@@ -0,0 +1,76 @@
+package gocrypto
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// generateSecp256k1 generates a _secp256k1_ key using the `crypto/rand` reader.
+//
+// The `crypto/ecdsa` do not support _secp256k1_ and the `elliptic.Curve` arithmetic of the
+// `cryptoutils.Secp256k1` curve is not constant time. Hence, all _secp256k1_ private key
+// operations are done using the _decred_ `secp256k1` and `ecdsa` packages.
+func generateSecp256k1() (*ecdsa.PrivateKey, error) {
+
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	defer key.Zero()
+
+	return key.ToECDSA(), nil
+}
+
+// signSecp256k1 signs the _digest_ using the _secp256k1_ _key_.
+//
+// The signature is deterministic as of _RFC 6979_, low-S and _ASN.1 DER_ encoded.
+func signSecp256k1(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+
+	if key.D == nil || key.D.Sign() <= 0 || key.D.Cmp(key.Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid secp256k1 private key")
+	}
+
+	d := key.D.FillBytes(make([]byte, 32))
+
+	private := secp256k1.PrivKeyFromBytes(d)
+	defer private.Zero()
+
+	for i := range d {
+		d[i] = 0
+	}
+
+	return secp256k1ecdsa.Sign(private, digest).Serialize(), nil
+}
+
+// verifySecp256k1 verifies the _ASN.1 DER_ encoded _signature_ of the _digest_ using the
+// _secp256k1_ _key_. Both low-S and high-S signatures are accepted.
+func verifySecp256k1(key *ecdsa.PublicKey, digest, signature []byte) bool {
+
+	if key.X == nil || key.Y == nil || key.X.Sign() < 0 || key.Y.Sign() < 0 ||
+		key.X.BitLen() > 256 || key.Y.BitLen() > 256 {
+		return false
+	}
+
+	var x, y secp256k1.FieldVal
+
+	if x.SetByteSlice(key.X.Bytes()) || y.SetByteSlice(key.Y.Bytes()) {
+		return false
+	}
+
+	public := secp256k1.NewPublicKey(&x, &y)
+
+	if !public.IsOnCurve() {
+		return false
+	}
+
+	sig, err := secp256k1ecdsa.ParseDERSignature(signature)
+	if err != nil {
+		return false
+	}
+
+	return sig.Verify(digest, public)
+}
@@ -0,0 +1,199 @@
+package gocrypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecp256k1KnownPoints(t *testing.T) {
+
+	tests := []struct {
+		d    byte
+		x, y string
+	}{
+		{
+			2,
+			"c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+			"1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a",
+		},
+		{
+			3,
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"388f7b0f632de8140fe337e62a37f3566500a99934c2231b6cb9fd7584b8e672",
+		},
+	}
+
+	for _, tt := range tests {
+
+		der, err := asn1.Marshal(struct {
+			Version    int
+			PrivateKey []byte
+		}{1, []byte{tt.d}})
+		require.NoError(t, err)
+
+		key, err := cryptoutils.ParseSecp256k1PrivateKey(der)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.x, key.X.Text(16))
+		assert.Equal(t, tt.y, key.Y.Text(16))
+		assert.True(t, cryptoutils.IsSecp256k1(key.Curve))
+
+	}
+
+	n := cryptoutils.Secp256k1().Params().N
+
+	der, err := asn1.Marshal(struct {
+		Version    int
+		PrivateKey []byte
+	}{1, n.Bytes()})
+	require.NoError(t, err)
+
+	_, err = cryptoutils.ParseSecp256k1PrivateKey(der)
+	assert.Error(t, err, "private scalar must be less than n")
+
+}
+
+func TestSecp256k1SignAndVerify(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewSecp256k1PrivateKey("k1", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, key.GetKeyType())
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, key.GetPublic().GetKeyType())
+	assert.Equal(t, 256, key.GetKeySize())
+
+	signer := NewSigner()
+	half := new(big.Int).Rsh(cryptoutils.Secp256k1().Params().N, 1)
+
+	for i := 0; i < 10; i++ {
+
+		msg := []byte{byte(i)}
+
+		signature, err := signer.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256)
+		require.NoError(t, err)
+
+		var sig struct{ R, S *big.Int }
+		_, err = asn1.Unmarshal(signature, &sig)
+		require.NoError(t, err)
+		assert.True(t, sig.S.Cmp(half) <= 0, "signature must be low-S")
+
+		assert.NoError(t, signer.Verify(c, msg, signature, key.GetPublic(), ifcrypto.SignAlgorithmEcdSha256))
+
+		err = signer.Verify(c, []byte("other"), signature, key.GetPublic(), ifcrypto.SignAlgorithmEcdSha256)
+		assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+	}
+
+	digest := sha256.Sum256([]byte("digest"))
+
+	signature, err := key.Sign(rand.Reader, digest[:], nil)
+	require.NoError(t, err)
+
+	again, err := key.Sign(rand.Reader, digest[:], nil)
+	require.NoError(t, err)
+	assert.Equal(t, signature, again, "RFC 6979 signatures are deterministic")
+
+	assert.True(t, verifySecp256k1(key.GetPublic().GetKey().(*ecdsa.PublicKey), digest[:], signature))
+
+}
+
+func TestNormalizeLowS(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	curve := cryptoutils.Secp256k1()
+	n := curve.Params().N
+
+	key, err := NewSecp256k1PrivateKey("k1", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	msg := []byte("hello world")
+
+	low, err := NewSigner().Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	var sig struct{ R, S *big.Int }
+	_, err = asn1.Unmarshal(low, &sig)
+	require.NoError(t, err)
+
+	sig.S.Sub(n, sig.S)
+
+	high, err := asn1.Marshal(sig)
+	require.NoError(t, err)
+	assert.NoError(t, NewSigner().Verify(c, msg, high, key, ifcrypto.SignAlgorithmEcdSha256))
+
+	normalized, err := NormalizeLowS(curve, high)
+	require.NoError(t, err)
+	assert.Equal(t, low, normalized)
+
+	again, err := NormalizeLowS(curve, low)
+	require.NoError(t, err)
+	assert.Equal(t, low, again)
+
+	_, err = NormalizeLowS(curve, []byte("garbage"))
+	assert.Error(t, err)
+
+}
+
+func TestSecp256k1PEMRoundTrip(t *testing.T) {
+
+	key, err := NewSecp256k1PrivateKey("k1", ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	private := key.GetKey().(*ecdsa.PrivateKey)
+
+	var buf bytes.Buffer
+	require.NoError(t, key.PEMWrite(&buf, true))
+
+	parsed, err := cryptoutils.PEMToECDSAPrivateKey(buf.Bytes())
+	require.NoError(t, err)
+	assert.True(t, private.Equal(parsed))
+
+	public, err := cryptoutils.PEMToECDSAPublicKey(buf.Bytes())
+	require.NoError(t, err)
+	assert.True(t, private.PublicKey.Equal(public))
+
+	block, _ := pem.Decode(buf.Bytes())
+	require.NotNil(t, block)
+	assert.Equal(t, "EC PRIVATE KEY", block.Type)
+
+	fromPEM, err := NewECDSAPrivateKeyFromPEM(*block, "k1", ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, fromPEM.GetKeyType())
+
+	der, err := cryptoutils.MarshalSecp256k1PKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	fromPKCS8, err := NewECDSAPrivateKeyFromPEM(pem.Block{Type: "PRIVATE KEY", Bytes: der}, "k1")
+	require.NoError(t, err)
+	assert.True(t, private.Equal(fromPKCS8.GetKey()))
+
+	der, err = cryptoutils.MarshalSecp256k1PublicKey(&private.PublicKey)
+	require.NoError(t, err)
+
+	fromSPKI, err := NewECDSAPublicKeyFromPEM(pem.Block{Type: "PUBLIC KEY", Bytes: der}, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, fromSPKI.GetKeyType())
+
+	nist, err := NewECDSAPrivateKey("p256", 256)
+	require.NoError(t, err)
+
+	_, err = cryptoutils.MarshalSecp256k1PrivateKey(nist.GetKey().(*ecdsa.PrivateKey))
+	assert.Error(t, err)
+
+}
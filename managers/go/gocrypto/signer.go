@@ -0,0 +1,225 @@
+package gocrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 with crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 with crypto.Hash
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+)
+
+// GoSigner implements the `ifcrypto.Signer` and `ifcrypto.Verifier` interfaces
+// using keys that resides in process memory, such as `RSAPrivateKey` and `ECDSAPrivateKey`.
+//
+// The _RSASSA-PSS_ algorithms uses a salt length equal to the hash length, i.e. the
+// same as _AWS KMS_ does. _ECDSA_ signatures are _ASN.1 DER_ encoded and _secp256k1_
+// signatures are deterministic and low-S. _Ed25519_ signs the message as is, without any pre-hashing.
+type GoSigner int
+
+// NewSigner creates a new `GoSigner`.
+func NewSigner() GoSigner {
+	return 0
+}
+
+// Sign implements the `ifcrypto.Signer` interface.
+//
+// The _key_ must be a private key that can sign using the _signAlgorithm_. The
+// _tags_ are not used.
+func (s GoSigner) Sign(
+	c ifctx.ServiceContext,
+	msg []byte,
+	key ifcrypto.Key,
+	signAlgorithm ifcrypto.SignAlgorithm,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key to sign with")
+	}
+
+	if key.IsSymmetric() || !key.IsPrivate() {
+		return nil, fmt.Errorf("key: %s is not a private key", key.GetID())
+	}
+
+	if !key.CanSign(signAlgorithm) {
+		return nil, fmt.Errorf("key: %s can not sign using: %s", key.GetID(), signAlgorithm)
+	}
+
+	if k, ok := key.GetKey().(ed25519.PrivateKey); ok {
+		return ed25519.Sign(k, msg), nil
+	}
+
+	digest, err := digestForSignAlgorithm(msg, signAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := signAlgorithm.GetCryptoHash()
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PrivateKey:
+
+		if signAlgorithm.IsRsaPss() {
+
+			return rsa.SignPSS(
+				rand.Reader, k, hash, digest,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash},
+			)
+
+		}
+
+		return rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+
+	case *ecdsa.PrivateKey:
+
+		if cryptoutils.IsSecp256k1(k.Curve) {
+			return signSecp256k1(k, digest)
+		}
+
+		return ecdsa.SignASN1(rand.Reader, k, digest)
+
+	}
+
+	return nil, fmt.Errorf("key: %s has a unsupported underlying key: %T", key.GetID(), key.GetKey())
+}
+
+// Verify implements the `ifcrypto.Verifier` interface.
+//
+// The _key_ may either be a public or a private key. When a `ifcrypto.KeyPair` is
+// passed, where the private portion is not present in memory, the public portion
+// is used to verify. The _tags_ are not used.
+func (s GoSigner) Verify(
+	c ifctx.ServiceContext,
+	msg []byte,
+	signature []byte,
+	key ifcrypto.Key,
+	signAlgorithm ifcrypto.SignAlgorithm,
+	tags ...coremodel.Meta,
+) error {
+
+	if key == nil {
+		return fmt.Errorf("must specify a key to verify with")
+	}
+
+	if !key.CanVerify(signAlgorithm) {
+		return fmt.Errorf("key: %s can not verify using: %s", key.GetID(), signAlgorithm)
+	}
+
+	if k, ok := publicKeyOf(key).(ed25519.PublicKey); ok {
+
+		if !ed25519.Verify(k, msg, signature) {
+			return ifcrypto.ErrInvalidSignature
+		}
+
+		return nil
+
+	}
+
+	digest, err := digestForSignAlgorithm(msg, signAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	hash := signAlgorithm.GetCryptoHash()
+
+	switch k := publicKeyOf(key).(type) {
+	case *rsa.PublicKey:
+
+		if signAlgorithm.IsRsaPss() {
+
+			err = rsa.VerifyPSS(
+				k, hash, digest, signature,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash},
+			)
+
+		} else {
+
+			err = rsa.VerifyPKCS1v15(k, hash, digest, signature)
+
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %s", ifcrypto.ErrInvalidSignature, err.Error())
+		}
+
+		return nil
+
+	case *ecdsa.PublicKey:
+
+		if cryptoutils.IsSecp256k1(k.Curve) {
+
+			if !verifySecp256k1(k, digest, signature) {
+				return ifcrypto.ErrInvalidSignature
+			}
+
+			return nil
+
+		}
+
+		if !ecdsa.VerifyASN1(k, digest, signature) {
+			return ifcrypto.ErrInvalidSignature
+		}
+
+		return nil
+
+	}
+
+	return fmt.Errorf("key: %s has a unsupported underlying key: %T", key.GetID(), key.GetKey())
+}
+
+// digestForSignAlgorithm hashes the _msg_ using the hash of the _alg_.
+func digestForSignAlgorithm(msg []byte, alg ifcrypto.SignAlgorithm) ([]byte, error) {
+
+	hash := alg.GetCryptoHash()
+
+	if hash == 0 {
+		return nil, fmt.Errorf("unsupported sign algorithm: %s", alg)
+	}
+
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash: %s is not available for sign algorithm: %s", hash, alg)
+	}
+
+	h := hash.New()
+	h.Write(msg)
+
+	return h.Sum(nil), nil
+
+}
+
+// publicKeyOf returns the underlying public key of _key_.
+//
+// If _key_ is a private key in memory, the public portion is returned. If it
+// is a `ifcrypto.KeyPair` where the private key is not in memory, the underlying
+// key of the `ifcrypto.KeyPair.GetPublic()` is returned.
+func publicKeyOf(key ifcrypto.Key) interface{} {
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PrivateKey:
+		return &k.PublicKey
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return k
+	}
+
+	if kp, ok := key.(ifcrypto.KeyPair); ok {
+
+		if pub := kp.GetPublic(); pub != nil {
+			return pub.GetKey()
+		}
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,96 @@
+package gocrypto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerifyAllAlgorithms(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	msg := []byte("hello world")
+
+	rsaKey, err := NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecdsaKey, err := NewECDSAPrivateKey("ecdsa", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	edKey, err := NewEd25519PrivateKey("ed25519", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg ifcrypto.SignAlgorithm
+		key ifcrypto.KeyPair
+	}{
+		{ifcrypto.SignAlgorithmRsaPssSha256, rsaKey},
+		{ifcrypto.SignAlgorithmRsaPssSha384, rsaKey},
+		{ifcrypto.SignAlgorithmRsaPssSha512, rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha384, rsaKey},
+		{ifcrypto.SignAlgorithmRsaPkcs1V15Sha512, rsaKey},
+		{ifcrypto.SignAlgorithmEcdSha256, ecdsaKey},
+		{ifcrypto.SignAlgorithmEcdSha384, ecdsaKey},
+		{ifcrypto.SignAlgorithmEcdSha512, ecdsaKey},
+		{ifcrypto.SignAlgorithmEd25519, edKey},
+	}
+
+	signer := NewSigner()
+
+	for _, tt := range tests {
+
+		t.Run(string(tt.alg), func(t *testing.T) {
+
+			signature, err := signer.Sign(c, msg, tt.key, tt.alg)
+			require.NoError(t, err)
+			assert.NotEmpty(t, signature)
+
+			assert.NoError(t, signer.Verify(c, msg, signature, tt.key, tt.alg))
+			assert.NoError(t, signer.Verify(c, msg, signature, tt.key.GetPublic(), tt.alg))
+
+			err = signer.Verify(c, []byte("tampered"), signature, tt.key.GetPublic(), tt.alg)
+			assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+		})
+
+	}
+}
+
+func TestSignRejectsMismatchingKeyAndAlgorithm(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewECDSAPrivateKey("ecdsa", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	_, err = NewSigner().Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmRsaPssSha256)
+	assert.Error(t, err)
+
+	_, err = NewSigner().Sign(c, []byte("msg"), key.GetPublic(), ifcrypto.SignAlgorithmEcdSha256)
+	assert.Error(t, err)
+
+	_, err = NewSigner().Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEd25519)
+	assert.Error(t, err)
+
+	edKey, err := NewEd25519PrivateKey("ed25519", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	_, err = NewSigner().Sign(c, []byte("msg"), edKey, ifcrypto.SignAlgorithmEcdSha256)
+	assert.Error(t, err)
+}
+
+func TestSignRequiresSignUsage(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := NewECDSAPrivateKey("ecdsa", 256, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	_, err = NewSigner().Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEcdSha256)
+	assert.Error(t, err)
+}
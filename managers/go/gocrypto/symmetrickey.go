@@ -0,0 +1,116 @@
+package gocrypto
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// SymmetricKey implements the `ifcrypto.Key` interface for a in memory symmetric key.
+//
+// If the key is 256 bits, it supports the `ifcrypto.ChiperAES256`.
+type SymmetricKey struct {
+	KeyBase
+	key []byte
+}
+
+// NewSymmetricKeyFromKey creates a new `SymmetricKey` from the raw _key_ bytes.
+//
+// The _key_ is copied, hence it is safe to clear it after this call.
+func NewSymmetricKeyFromKey(
+	id string,
+	key []byte,
+	usage ...ifcrypto.KeyUsage,
+) (*SymmetricKey, error) {
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("must specify a non empty symmetric key")
+	}
+
+	k := make([]byte, len(key))
+	copy(k, key)
+
+	return &SymmetricKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeSymmetric,
+			keySize: len(k) * 8,
+			usage:   usage,
+			chiper:  symmetricChiphers(len(k) * 8),
+		},
+		key: k,
+	}, nil
+
+}
+
+// NewSymmetricKey generates a new `SymmetricKey` of _bits_ size using the `rand.Reader` as entropy.
+//
+// The _bits_ must be a multiple of eight.
+func NewSymmetricKey(id string, bits int, usage ...ifcrypto.KeyUsage) (*SymmetricKey, error) {
+
+	if bits <= 0 || bits%8 != 0 {
+		return nil, fmt.Errorf("symmetric key size must be a positive multiple of 8, got: %d", bits)
+	}
+
+	key := make([]byte, bits/8)
+
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return &SymmetricKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeSymmetric,
+			keySize: bits,
+			usage:   usage,
+			chiper:  symmetricChiphers(bits),
+		},
+		key: key,
+	}, nil
+
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+//
+// This returns the raw key as `[]byte`.
+func (r *SymmetricKey) GetKey() interface{} {
+	return r.key
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *SymmetricKey) IsSymmetric() bool {
+	return true
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *SymmetricKey) IsPrivate() bool {
+	return true
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *SymmetricKey) IsRemoteKey() bool {
+	return false
+}
+
+// symmetricChiphers returns the chiphers that a symmetric key of _bits_ size supports.
+func symmetricChiphers(bits int) []ifcrypto.Chipher {
+
+	if bits == 256 {
+		return []ifcrypto.Chipher{ifcrypto.ChiperAES256}
+	}
+
+	return []ifcrypto.Chipher{}
+
+}
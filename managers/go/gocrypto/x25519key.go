@@ -0,0 +1,254 @@
+package gocrypto
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// X25519PrivateKey implements the `ifcrypto.KeyPair` interface for a _X25519_ `*ecdh.PrivateKey`.
+//
+// It may be used for `ifcrypto.KeyUsageKeyAgreement` and to decrypt `ifcrypto.ChiperEciesHkdfSha256AES256`.
+type X25519PrivateKey struct {
+	KeyBase
+	key    *ecdh.PrivateKey
+	public *X25519PublicKey
+}
+
+// NewX25519PrivateKeyFromKey creates a new `X25519PrivateKey`
+//
+// The public key portion derives the same usage as the private key. The _key_ must
+// be a `ecdh.X25519` key.
+func NewX25519PrivateKeyFromKey(
+	id string,
+	key *ecdh.PrivateKey,
+	usage ...ifcrypto.KeyUsage,
+) (*X25519PrivateKey, error) {
+
+	if key == nil || key.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("must specify a X25519 private key")
+	}
+
+	public, err := NewX25519PublicKeyFromKey(id, key.PublicKey(), usage...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &X25519PrivateKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeX25519,
+			keySize: 256,
+			usage:   usage,
+			chiper:  eciesChiphers(),
+		},
+		key:    key,
+		public: public,
+	}, nil
+
+}
+
+// NewX25519PrivateKeyFromPEM initializes a new `X25519PrivateKey` from the underlying _PKCS#8_ _PEM_ block.
+func NewX25519PrivateKeyFromPEM(
+	block pem.Block,
+	id string,
+	usage ...ifcrypto.KeyUsage,
+) (*X25519PrivateKey, error) {
+
+	if block.Type == "PRIVATE KEY" {
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if xkey, ok := key.(*ecdh.PrivateKey); ok {
+
+			return NewX25519PrivateKeyFromKey(id, xkey, usage...)
+
+		}
+
+		return nil, fmt.Errorf("not a *ecdh.PrivateKey: %T", key)
+
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block: %s", block.Type)
+
+}
+
+// NewX25519PrivateKey generates a new `X25519PrivateKey` using the `rand.Reader` as entropy.
+func NewX25519PrivateKey(id string, usage ...ifcrypto.KeyUsage) (*X25519PrivateKey, error) {
+
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewX25519PrivateKeyFromKey(id, key, usage...)
+}
+
+// GetPublic returns the public portion of the key
+func (r *X25519PrivateKey) GetPublic() ifcrypto.PublicKey {
+	return r.public
+}
+
+// PEMWrite will write the key onto _w_ using the _PKCS#8_ PEM format.
+//
+// If private key, and _public_ is `true`, it will in addition write the public portion as well.
+func (r *X25519PrivateKey) PEMWrite(w io.Writer, public bool) error {
+
+	der, err := x509.MarshalPKCS8PrivateKey(r.key)
+	if err != nil {
+		return err
+	}
+
+	if err := pem.Encode(w, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return err
+	}
+
+	if public {
+		return r.public.PEMWrite(w, true)
+	}
+
+	return nil
+
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+func (r *X25519PrivateKey) GetKey() interface{} {
+	return r.key
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *X25519PrivateKey) IsSymmetric() bool {
+	return false
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *X25519PrivateKey) IsPrivate() bool {
+	return true
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *X25519PrivateKey) IsRemoteKey() bool {
+	return false
+}
+
+// X25519PublicKey implements the `ifcrypto.PublicKey` interface for a _X25519_ `*ecdh.PublicKey`
+type X25519PublicKey struct {
+	KeyBase
+	key *ecdh.PublicKey
+}
+
+// NewX25519PublicKeyFromKey creates a instance based on a existing public key.
+//
+// The _key_ must be a `ecdh.X25519` key.
+func NewX25519PublicKeyFromKey(
+	id string,
+	key *ecdh.PublicKey,
+	usage ...ifcrypto.KeyUsage,
+) (*X25519PublicKey, error) {
+
+	if key == nil || key.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("must specify a X25519 public key")
+	}
+
+	return &X25519PublicKey{
+		KeyBase: KeyBase{
+			id:      id,
+			keyType: ifcrypto.KeyTypeX25519,
+			keySize: 256,
+			usage:   usage,
+			chiper:  eciesChiphers(),
+		},
+		key: key,
+	}, nil
+
+}
+
+// NewX25519PublicKeyFromPEM initializes a new `X25519PublicKey` from the underlying _SPKI_ _PEM_ block.
+func NewX25519PublicKeyFromPEM(
+	block pem.Block,
+	id string,
+	usage ...ifcrypto.KeyUsage,
+) (*X25519PublicKey, error) {
+
+	if block.Type == "PUBLIC KEY" {
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if xkey, ok := key.(*ecdh.PublicKey); ok {
+
+			return NewX25519PublicKeyFromKey(id, xkey, usage...)
+
+		}
+
+		return nil, fmt.Errorf("not a *ecdh.PublicKey: %T", key)
+
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block: %s", block.Type)
+
+}
+
+// PEMWrite will write the key onto _w_ using the _SPKI_ PEM format.
+//
+// Since this is a public key, it will ignore the _public_ parameter.
+func (r *X25519PublicKey) PEMWrite(w io.Writer, public bool) error {
+
+	der, err := x509.MarshalPKIXPublicKey(r.key)
+	if err != nil {
+		return err
+	}
+
+	return pem.Encode(w, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+}
+
+// GetKey gets the underlying key, if any.
+//
+// Some keys are remote and not possible to fetch. In such situations the function returns a remote id,
+// most often the same as GetID() returns.
+func (r *X25519PublicKey) GetKey() interface{} {
+	return r.key
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *X25519PublicKey) IsSymmetric() bool {
+	return false
+}
+
+// IsPrivate returns `true` if this is a `KeyType` other than `KeyTypeSymmetric` and is a private key.
+//
+// If `KeyTypeSymmetric` it will return `true` since all symmetric keys are considered as private.
+func (r *X25519PublicKey) IsPrivate() bool {
+	return false
+}
+
+// IsRemoteKey returns `true` if the key is not present in current process memory.
+//
+// Typically hardware units or remote services will not reveal their private key. In such case, this
+// method returns `true`. If present in memory such as a `*rsa.PrivateKey` it returns `false`.
+func (r *X25519PublicKey) IsRemoteKey() bool {
+	return false
+}
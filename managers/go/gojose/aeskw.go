@@ -0,0 +1,104 @@
+package gojose
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+// aesKeyWrapIV is the default initial value of _RFC 3394_ section 2.2.3.1.
+var aesKeyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// aesKeyWrap wraps _key_ using the _kek_ as of _RFC 3394_.
+//
+// The _key_ must be a multiple of eight bytes and at least 16 bytes.
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, fmt.Errorf("key to wrap must be a multiple of 8 and at least 16 bytes, got: %d", len(key))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(key) / 8
+
+	a := make([]byte, 8)
+	copy(a, aesKeyWrapIV)
+
+	r := make([]byte, len(key))
+	copy(r, key)
+
+	b := make([]byte, 16)
+
+	for j := 0; j < 6; j++ {
+
+		for i := 0; i < n; i++ {
+
+			copy(b, a)
+			copy(b[8:], r[i*8:i*8+8])
+
+			block.Encrypt(b, b)
+
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+
+			copy(r[i*8:], b[8:])
+
+		}
+
+	}
+
+	return append(a, r...), nil
+}
+
+// aesKeyUnwrap unwraps the _wrapped_ key using _kek_ as of _RFC 3394_.
+//
+// If the integrity check fails, a error is returned.
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("wrapped key must be a multiple of 8 and at least 24 bytes, got: %d", len(wrapped))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+
+	r := make([]byte, n*8)
+	copy(r, wrapped[8:])
+
+	b := make([]byte, 16)
+
+	for j := 5; j >= 0; j-- {
+
+		for i := n - 1; i >= 0; i-- {
+
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:i*8+8])
+
+			block.Decrypt(b, b)
+
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+
+		}
+
+	}
+
+	if subtle.ConstantTimeCompare(a, aesKeyWrapIV) != 1 {
+		return nil, fmt.Errorf("key unwrap integrity check failed")
+	}
+
+	return r, nil
+}
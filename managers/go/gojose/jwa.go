@@ -0,0 +1,258 @@
+package gojose
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// ErrUnsupportedAlgorithm is returned when a _JOSE_ `Algorithm` is not supported or do not
+// match the key it is used with.
+var ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+
+// Algorithm is a _JSON Web Algorithm_ as of _RFC 7518_, _RFC 8037_ and _RFC 8812_.
+//
+// It is used as the `alg` member of a `JWK` and of a _JOSE_ header.
+type Algorithm string
+
+const (
+	// AlgorithmRS256 is _RSASSA-PKCS1-v1_5_ using _SHA-256_.
+	AlgorithmRS256 Algorithm = "RS256"
+	// AlgorithmRS384 is _RSASSA-PKCS1-v1_5_ using _SHA-384_.
+	AlgorithmRS384 Algorithm = "RS384"
+	// AlgorithmRS512 is _RSASSA-PKCS1-v1_5_ using _SHA-512_.
+	AlgorithmRS512 Algorithm = "RS512"
+	// AlgorithmPS256 is _RSASSA-PSS_ using _SHA-256_.
+	AlgorithmPS256 Algorithm = "PS256"
+	// AlgorithmPS384 is _RSASSA-PSS_ using _SHA-384_.
+	AlgorithmPS384 Algorithm = "PS384"
+	// AlgorithmPS512 is _RSASSA-PSS_ using _SHA-512_.
+	AlgorithmPS512 Algorithm = "PS512"
+	// AlgorithmES256 is _ECDSA_ using _P-256_ and _SHA-256_.
+	AlgorithmES256 Algorithm = "ES256"
+	// AlgorithmES384 is _ECDSA_ using _P-384_ and _SHA-384_.
+	AlgorithmES384 Algorithm = "ES384"
+	// AlgorithmES512 is _ECDSA_ using _P-521_ and _SHA-512_.
+	AlgorithmES512 Algorithm = "ES512"
+	// AlgorithmES256K is _ECDSA_ using _secp256k1_ and _SHA-256_ (_RFC 8812_).
+	AlgorithmES256K Algorithm = "ES256K"
+	// AlgorithmEdDSA is _EdDSA_ (_RFC 8037_), only _Ed25519_ is supported.
+	AlgorithmEdDSA Algorithm = "EdDSA"
+	// AlgorithmHS256 is _HMAC_ using _SHA-256_.
+	AlgorithmHS256 Algorithm = "HS256"
+	// AlgorithmHS384 is _HMAC_ using _SHA-384_.
+	AlgorithmHS384 Algorithm = "HS384"
+	// AlgorithmHS512 is _HMAC_ using _SHA-512_.
+	AlgorithmHS512 Algorithm = "HS512"
+	// AlgorithmRsaOaep256 is the _JWE_ key management _RSAES-OAEP_ using _SHA-256_.
+	AlgorithmRsaOaep256 Algorithm = "RSA-OAEP-256"
+	// AlgorithmEcdhEs is the _JWE_ key management _ECDH-ES_ where the agreed key is the
+	// content encryption key.
+	AlgorithmEcdhEs Algorithm = "ECDH-ES"
+	// AlgorithmEcdhEsA256KW is the _JWE_ key management _ECDH-ES_ where the agreed key
+	// wraps the content encryption key using _AES-256_ key wrap.
+	AlgorithmEcdhEsA256KW Algorithm = "ECDH-ES+A256KW"
+	// AlgorithmDir is the _JWE_ key management where a shared symmetric key is the
+	// content encryption key.
+	AlgorithmDir Algorithm = "dir"
+)
+
+// ContentEncryption is a _JWE_ content encryption algorithm, i.e. the `enc` header parameter.
+type ContentEncryption string
+
+const (
+	// ContentEncryptionA256GCM is _AES-256_ in _GCM_ mode.
+	ContentEncryptionA256GCM ContentEncryption = "A256GCM"
+)
+
+// GetKeySize returns the size, in bytes, of the content encryption key or -1 if not supported.
+func (enc ContentEncryption) GetKeySize() int {
+
+	if enc == ContentEncryptionA256GCM {
+		return 32
+	}
+
+	return -1
+}
+
+// IsSignature returns `true` if the _alg_ is a asymmetric signature algorithm.
+func (alg Algorithm) IsSignature() bool {
+
+	_, err := alg.SignAlgorithm()
+	return err == nil
+
+}
+
+// IsMac returns `true` if the _alg_ is a _HMAC_ algorithm.
+func (alg Algorithm) IsMac() bool {
+
+	_, err := alg.MacAlgorithm()
+	return err == nil
+
+}
+
+// SignAlgorithm returns the `ifcrypto.SignAlgorithm` for this asymmetric signature algorithm.
+//
+// If not a asymmetric signature algorithm, a error wrapping `ErrUnsupportedAlgorithm` is returned.
+func (alg Algorithm) SignAlgorithm() (ifcrypto.SignAlgorithm, error) {
+
+	switch alg {
+	case AlgorithmRS256:
+		return ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, nil
+	case AlgorithmRS384:
+		return ifcrypto.SignAlgorithmRsaPkcs1V15Sha384, nil
+	case AlgorithmRS512:
+		return ifcrypto.SignAlgorithmRsaPkcs1V15Sha512, nil
+	case AlgorithmPS256:
+		return ifcrypto.SignAlgorithmRsaPssSha256, nil
+	case AlgorithmPS384:
+		return ifcrypto.SignAlgorithmRsaPssSha384, nil
+	case AlgorithmPS512:
+		return ifcrypto.SignAlgorithmRsaPssSha512, nil
+	case AlgorithmES256, AlgorithmES256K:
+		return ifcrypto.SignAlgorithmEcdSha256, nil
+	case AlgorithmES384:
+		return ifcrypto.SignAlgorithmEcdSha384, nil
+	case AlgorithmES512:
+		return ifcrypto.SignAlgorithmEcdSha512, nil
+	case AlgorithmEdDSA:
+		return ifcrypto.SignAlgorithmEd25519, nil
+	}
+
+	return "", fmt.Errorf("%w: %s is not a signature algorithm", ErrUnsupportedAlgorithm, alg)
+}
+
+// MacAlgorithm returns the `ifcrypto.MacAlgorithm` for this _HMAC_ algorithm.
+//
+// If not a _HMAC_ algorithm, a error wrapping `ErrUnsupportedAlgorithm` is returned.
+func (alg Algorithm) MacAlgorithm() (ifcrypto.MacAlgorithm, error) {
+
+	switch alg {
+	case AlgorithmHS256:
+		return ifcrypto.MacAlgorithmHmacSha256, nil
+	case AlgorithmHS384:
+		return ifcrypto.MacAlgorithmHmacSha384, nil
+	case AlgorithmHS512:
+		return ifcrypto.MacAlgorithmHmacSha512, nil
+	}
+
+	return "", fmt.Errorf("%w: %s is not a mac algorithm", ErrUnsupportedAlgorithm, alg)
+}
+
+// AlgorithmFromSignAlgorithm returns the signature `Algorithm` for _alg_ when used with _key_.
+//
+// _JOSE_ binds the hash to the curve, hence e.g. `ifcrypto.SignAlgorithmEcdSha384` is only
+// possible on a _P-384_ key. A `ifcrypto.KeyTypeEccSecgP256k1` key maps onto `AlgorithmES256K`.
+func AlgorithmFromSignAlgorithm(key ifcrypto.Key, alg ifcrypto.SignAlgorithm) (Algorithm, error) {
+
+	if key == nil {
+		return "", fmt.Errorf("must specify a key")
+	}
+
+	var jalg Algorithm
+
+	switch alg {
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha256:
+		jalg = AlgorithmRS256
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha384:
+		jalg = AlgorithmRS384
+	case ifcrypto.SignAlgorithmRsaPkcs1V15Sha512:
+		jalg = AlgorithmRS512
+	case ifcrypto.SignAlgorithmRsaPssSha256:
+		jalg = AlgorithmPS256
+	case ifcrypto.SignAlgorithmRsaPssSha384:
+		jalg = AlgorithmPS384
+	case ifcrypto.SignAlgorithmRsaPssSha512:
+		jalg = AlgorithmPS512
+	case ifcrypto.SignAlgorithmEcdSha256:
+
+		if key.GetKeyType() == ifcrypto.KeyTypeEccSecgP256k1 {
+			jalg = AlgorithmES256K
+		} else {
+			jalg = AlgorithmES256
+		}
+
+	case ifcrypto.SignAlgorithmEcdSha384:
+		jalg = AlgorithmES384
+	case ifcrypto.SignAlgorithmEcdSha512:
+		jalg = AlgorithmES512
+	case ifcrypto.SignAlgorithmEd25519:
+		jalg = AlgorithmEdDSA
+	default:
+		return "", fmt.Errorf("%w: sign algorithm: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	if err := jalg.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	return jalg, nil
+}
+
+// AlgorithmFromMacAlgorithm returns the _HMAC_ `Algorithm` for _alg_.
+//
+// NOTE: `ifcrypto.MacAlgorithmHmacSha224` has no _JOSE_ counterpart.
+func AlgorithmFromMacAlgorithm(alg ifcrypto.MacAlgorithm) (Algorithm, error) {
+
+	switch alg {
+	case ifcrypto.MacAlgorithmHmacSha256:
+		return AlgorithmHS256, nil
+	case ifcrypto.MacAlgorithmHmacSha384:
+		return AlgorithmHS384, nil
+	case ifcrypto.MacAlgorithmHmacSha512:
+		return AlgorithmHS512, nil
+	}
+
+	return "", fmt.Errorf("%w: mac algorithm: %s", ErrUnsupportedAlgorithm, alg)
+}
+
+// ValidateKey ensures that the _key_ type, and for elliptic curves the curve, may be used
+// with this `Algorithm`.
+//
+// Algorithms that are not known by this package are not validated.
+func (alg Algorithm) ValidateKey(key ifcrypto.Key) error {
+
+	if key == nil {
+		return fmt.Errorf("must specify a key")
+	}
+
+	keyType, keySize := key.GetKeyType(), key.GetKeySize()
+
+	ok := true
+
+	switch alg {
+	case AlgorithmRS256, AlgorithmRS384, AlgorithmRS512,
+		AlgorithmPS256, AlgorithmPS384, AlgorithmPS512:
+		ok = keyType == ifcrypto.KeyTypeRsa
+	case AlgorithmES256:
+		ok = keyType == ifcrypto.KeyTypeEccNistP && keySize == 256
+	case AlgorithmES384:
+		ok = keyType == ifcrypto.KeyTypeEccNistP && keySize == 384
+	case AlgorithmES512:
+		ok = keyType == ifcrypto.KeyTypeEccNistP && keySize == 521
+	case AlgorithmES256K:
+		ok = keyType == ifcrypto.KeyTypeEccSecgP256k1
+	case AlgorithmEdDSA:
+		ok = keyType == ifcrypto.KeyTypeEd25519
+	case AlgorithmHS256, AlgorithmHS384, AlgorithmHS512:
+		ok = keyType == ifcrypto.KeyTypeSymmetric
+	case AlgorithmRsaOaep256:
+		ok = keyType == ifcrypto.KeyTypeRsa
+	case AlgorithmEcdhEs, AlgorithmEcdhEsA256KW:
+		ok = keyType == ifcrypto.KeyTypeEccNistP || keyType == ifcrypto.KeyTypeX25519
+	case AlgorithmDir:
+		ok = keyType == ifcrypto.KeyTypeSymmetric && keySize == 256
+	}
+
+	if !ok {
+
+		return fmt.Errorf(
+			"%w: %s can not be used with key: %s of type: %s and size: %d",
+			ErrUnsupportedAlgorithm, alg, key.GetID(), keyType, keySize,
+		)
+
+	}
+
+	return nil
+}
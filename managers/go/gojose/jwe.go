@@ -0,0 +1,579 @@
+package gojose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// ErrInvalidJWE is returned when a _JWE_ is malformed or not possible to decrypt.
+var ErrInvalidJWE = errors.New("invalid JWE")
+
+// JWEHeader is the protected header of a _JSON Web Encryption_ as of _RFC 7516_.
+//
+// Since no extensions nor compression is supported, a header with `crit` or `zip` is rejected.
+type JWEHeader struct {
+	Algorithm   Algorithm         `json:"alg"`
+	Encryption  ContentEncryption `json:"enc"`
+	KeyID       string            `json:"kid,omitempty"`
+	Type        string            `json:"typ,omitempty"`
+	ContentType string            `json:"cty,omitempty"`
+	// EphemeralPublicKey is the `epk` of the _ECDH-ES_ key management algorithms.
+	EphemeralPublicKey *JWK `json:"epk,omitempty"`
+	// AgreementPartyUInfo is the _base64url_ encoded `apu` of the _ECDH-ES_ algorithms.
+	AgreementPartyUInfo string `json:"apu,omitempty"`
+	// AgreementPartyVInfo is the _base64url_ encoded `apv` of the _ECDH-ES_ algorithms.
+	AgreementPartyVInfo string   `json:"apv,omitempty"`
+	Compression         string   `json:"zip,omitempty"`
+	Critical            []string `json:"crit,omitempty"`
+}
+
+// JWE encrypts and decrypts _JWE Compact Serialization_ tokens using `ContentEncryptionA256GCM`.
+//
+// The key management algorithms are:
+//
+// * `AlgorithmRsaOaep256` - The content encryption key is encrypted, and decrypted, using the
+// `ifcrypto.Cipherable` with `ifcrypto.ChiperRsaOaepSha256`. Hence, the key may be a remote key
+// where the decryption is done in e.g. _AWS KMS_. A in memory private key encrypts using its
+// public portion.
+// * `AlgorithmEcdhEs` and `AlgorithmEcdhEsA256KW` - The key is agreed using the `ifcrypto.KeyAgreement`
+// on a ephemeral key and _NIST_ or `ifcrypto.KeyTypeX25519` recipient keys.
+// * `AlgorithmDir` - A in memory 256 bits symmetric key is the content encryption key.
+//
+// .Example
+// [source,go]
+// ----
+// jwe := gojose.NewJWE(km, gocrypto.NewKeyAgreement()); token, err := jwe.EncryptCompact(c, claims, key, gojose.AlgorithmRsaOaep256, nil)
+// ----
+type JWE struct {
+	cipher    ifcrypto.Cipherable
+	agreement ifcrypto.KeyAgreement
+}
+
+// NewJWE creates a new `JWE` that uses _cipher_ for _RSA_ key management and _agreement_
+// for _ECDH-ES_ key management.
+//
+// Either may be `nil` if the corresponding key management algorithms are not used.
+func NewJWE(cipher ifcrypto.Cipherable, agreement ifcrypto.KeyAgreement) *JWE {
+
+	return &JWE{cipher: cipher, agreement: agreement}
+
+}
+
+// EncryptCompact encrypts the _plaintext_ to the recipient _key_ using the _alg_ key management
+// and returns the _JWE Compact Serialization_.
+//
+// The _header_ is optional and is used as the protected header where `alg` and `epk` is always
+// set. The `enc` defaults to `ContentEncryptionA256GCM` and `kid` to `ifcrypto.Key.GetID`. The
+// _tags_ are passed to the `ifcrypto.Cipherable` and `ifcrypto.KeyAgreement`.
+func (j *JWE) EncryptCompact(
+	c ifctx.ServiceContext,
+	plaintext []byte,
+	key ifcrypto.Key,
+	alg Algorithm,
+	header *JWEHeader,
+	tags ...coremodel.Meta,
+) (string, error) {
+
+	if err := alg.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	hdr := JWEHeader{}
+
+	if header != nil {
+		hdr = *header
+	}
+
+	hdr.Algorithm = alg
+
+	if hdr.Encryption == "" {
+		hdr.Encryption = ContentEncryptionA256GCM
+	}
+
+	if hdr.KeyID == "" {
+		hdr.KeyID = key.GetID()
+	}
+
+	if err := hdr.validate(); err != nil {
+		return "", err
+	}
+
+	var (
+		cek, encryptedKey []byte
+		err               error
+	)
+
+	switch alg {
+	case AlgorithmRsaOaep256:
+
+		if j.cipher == nil {
+			return "", fmt.Errorf("no cipher is configured")
+		}
+
+		recipient := key
+
+		if !key.IsRemoteKey() {
+
+			if recipient, err = publicKeyOf(key); err != nil {
+				return "", err
+			}
+
+		}
+
+		if cek, err = randomBytes(hdr.Encryption.GetKeySize()); err != nil {
+			return "", err
+		}
+
+		if encryptedKey, err = j.cipher.Encrypt(c, cek, recipient, ifcrypto.ChiperRsaOaepSha256, tags...); err != nil {
+			return "", err
+		}
+
+	case AlgorithmDir:
+
+		if cek, err = directKey(key, key.CanEncrypt); err != nil {
+			return "", err
+		}
+
+	case AlgorithmEcdhEs, AlgorithmEcdhEsA256KW:
+
+		if cek, encryptedKey, err = j.encryptECDH(c, key, &hdr, tags...); err != nil {
+			return "", err
+		}
+
+	default:
+		return "", fmt.Errorf("%w: key management: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	data, err := json.Marshal(hdr)
+	if err != nil {
+		return "", err
+	}
+
+	protected := encode(data)
+
+	gcm, err := aesGCM(cek)
+	if err != nil {
+		return "", err
+	}
+
+	iv, err := randomBytes(gcm.NonceSize())
+	if err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	split := len(sealed) - gcm.Overhead()
+
+	return strings.Join([]string{
+		protected, encode(encryptedKey), encode(iv), encode(sealed[:split]), encode(sealed[split:]),
+	}, "."), nil
+}
+
+// DecryptCompact decrypts the _JWE Compact Serialization_ _token_ using the private _key_ and
+// returns the plaintext and the protected header.
+//
+// If the `kid` is present, it must match `ifcrypto.Key.GetID`. Use `ParseJWEHeader` to select
+// the _key_ before decrypting. The _tags_ are passed to the `ifcrypto.Cipherable` and
+// `ifcrypto.KeyAgreement`.
+func (j *JWE) DecryptCompact(
+	c ifctx.ServiceContext,
+	token string,
+	key ifcrypto.Key,
+	tags ...coremodel.Meta,
+) ([]byte, *JWEHeader, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 5 {
+		return nil, nil, fmt.Errorf("%w: compact serialization must have five parts", ErrInvalidJWE)
+	}
+
+	hdr, err := parseJWEHeader(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := hdr.Algorithm.ValidateKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	if hdr.KeyID != "" && key.GetID() != "" && hdr.KeyID != key.GetID() {
+		return nil, nil, fmt.Errorf("%w: encrypted to key: %s, not: %s", ErrInvalidJWE, hdr.KeyID, key.GetID())
+	}
+
+	var decoded [4][]byte
+
+	for i, name := range []string{"encrypted key", "iv", "ciphertext", "tag"} {
+
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(parts[i+1]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidJWE, name, err)
+		}
+
+	}
+
+	encryptedKey, iv, ciphertext, tag := decoded[0], decoded[1], decoded[2], decoded[3]
+
+	var cek []byte
+
+	switch hdr.Algorithm {
+	case AlgorithmRsaOaep256:
+
+		if j.cipher == nil {
+			return nil, nil, fmt.Errorf("no cipher is configured")
+		}
+
+		if cek, err = j.cipher.Decrypt(c, encryptedKey, key, ifcrypto.ChiperRsaOaepSha256, tags...); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWE, err)
+		}
+
+	case AlgorithmDir:
+
+		if len(encryptedKey) > 0 {
+			return nil, nil, fmt.Errorf("%w: dir must not have a encrypted key", ErrInvalidJWE)
+		}
+
+		if cek, err = directKey(key, key.CanDecrypt); err != nil {
+			return nil, nil, err
+		}
+
+	case AlgorithmEcdhEs, AlgorithmEcdhEsA256KW:
+
+		if cek, err = j.decryptECDH(c, key, hdr, encryptedKey, tags...); err != nil {
+			return nil, nil, err
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("%w: key management: %s", ErrUnsupportedAlgorithm, hdr.Algorithm)
+	}
+
+	if len(cek) != hdr.Encryption.GetKeySize() {
+		return nil, nil, fmt.Errorf("%w: content encryption key has wrong size", ErrInvalidJWE)
+	}
+
+	gcm, err := aesGCM(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(iv) != gcm.NonceSize() || len(tag) != gcm.Overhead() {
+		return nil, nil, fmt.Errorf("%w: invalid iv or tag size", ErrInvalidJWE)
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidJWE, err)
+	}
+
+	return plaintext, hdr, nil
+}
+
+// ParseJWEHeader returns the protected header of the _JWE Compact Serialization_ _token_
+// without decrypting it, e.g. to select the key using the `kid`.
+func ParseJWEHeader(token string) (*JWEHeader, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: compact serialization must have five parts", ErrInvalidJWE)
+	}
+
+	return parseJWEHeader(parts[0])
+}
+
+// encryptECDH agrees on a key with the recipient _key_ using a ephemeral key that is set as
+// `epk` in _hdr_. It returns the content encryption key and, if key wrapping, the wrapped key.
+func (j *JWE) encryptECDH(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	hdr *JWEHeader,
+	tags ...coremodel.Meta,
+) ([]byte, []byte, error) {
+
+	if j.agreement == nil {
+		return nil, nil, fmt.Errorf("no key agreement is configured")
+	}
+
+	peer, err := publicKeyOf(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ephemeral ifcrypto.KeyPair
+
+	if key.GetKeyType() == ifcrypto.KeyTypeX25519 {
+		ephemeral, err = gocrypto.NewX25519PrivateKey("", ifcrypto.KeyUsageKeyAgreement)
+	} else {
+		ephemeral, err = gocrypto.NewECDSAPrivateKey("", key.GetKeySize(), ifcrypto.KeyUsageKeyAgreement)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	epk, err := NewJWKFromKey(ephemeral.GetPublic(), "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	epk.Use, epk.KeyOps = "", nil
+	hdr.EphemeralPublicKey = epk
+
+	z, err := j.agreement.DeriveSharedSecret(c, ephemeral, peer, tags...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kek, err := hdr.agreedKey(z)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hdr.Algorithm == AlgorithmEcdhEs {
+		return kek, nil, nil
+	}
+
+	cek, err := randomBytes(hdr.Encryption.GetKeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, wrapped, nil
+}
+
+// decryptECDH agrees on a key using the private _key_ and the `epk` of _hdr_ and returns
+// the, possibly unwrapped, content encryption key.
+func (j *JWE) decryptECDH(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	hdr *JWEHeader,
+	encryptedKey []byte,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	if j.agreement == nil {
+		return nil, fmt.Errorf("no key agreement is configured")
+	}
+
+	if hdr.EphemeralPublicKey == nil || hdr.EphemeralPublicKey.IsPrivate() {
+		return nil, fmt.Errorf("%w: missing or invalid header parameter: epk", ErrInvalidJWE)
+	}
+
+	epk, err := hdr.EphemeralPublicKey.Key()
+	if err != nil {
+		return nil, fmt.Errorf("%w: epk: %w", ErrInvalidJWE, err)
+	}
+
+	if epk.GetKeyType() != key.GetKeyType() || epk.GetKeySize() != key.GetKeySize() {
+		return nil, fmt.Errorf("%w: epk is not on the same curve as key: %s", ErrInvalidJWE, key.GetID())
+	}
+
+	z, err := j.agreement.DeriveSharedSecret(c, key, epk.(ifcrypto.PublicKey), tags...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWE, err)
+	}
+
+	kek, err := hdr.agreedKey(z)
+	if err != nil {
+		return nil, err
+	}
+
+	if hdr.Algorithm == AlgorithmEcdhEs {
+
+		if len(encryptedKey) > 0 {
+			return nil, fmt.Errorf("%w: ECDH-ES must not have a encrypted key", ErrInvalidJWE)
+		}
+
+		return kek, nil
+
+	}
+
+	cek, err := aesKeyUnwrap(kek, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWE, err)
+	}
+
+	return cek, nil
+}
+
+// agreedKey derives the key from the shared secret _z_ using the _Concat KDF_ as of
+// _RFC 7518_ section 4.6.2.
+//
+// For `AlgorithmEcdhEs` the key is the content encryption key, otherwise it is the 256
+// bits key encryption key.
+func (h *JWEHeader) agreedKey(z []byte) ([]byte, error) {
+
+	apu, err := decodeOptional("apu", h.AgreementPartyUInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	apv, err := decodeOptional("apv", h.AgreementPartyVInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Algorithm == AlgorithmEcdhEs {
+		return concatKDF(z, string(h.Encryption), apu, apv, h.Encryption.GetKeySize()*8), nil
+	}
+
+	return concatKDF(z, string(h.Algorithm), apu, apv, 256), nil
+}
+
+// validate ensures that the header only uses supported features.
+func (h *JWEHeader) validate() error {
+
+	if h.Encryption.GetKeySize() < 0 {
+		return fmt.Errorf("%w: content encryption: %s", ErrUnsupportedAlgorithm, h.Encryption)
+	}
+
+	if h.Compression != "" {
+		return fmt.Errorf("%w: compression: %s", ErrUnsupportedAlgorithm, h.Compression)
+	}
+
+	if len(h.Critical) > 0 {
+		return fmt.Errorf("%w: crit is not supported", ErrUnsupportedAlgorithm)
+	}
+
+	return nil
+}
+
+// parseJWEHeader decodes and validates the _protected_ header.
+func parseJWEHeader(protected string) (*JWEHeader, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return nil, fmt.Errorf("%w: protected header: %v", ErrInvalidJWE, err)
+	}
+
+	var hdr JWEHeader
+
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		return nil, fmt.Errorf("%w: protected header: %v", ErrInvalidJWE, err)
+	}
+
+	if err := hdr.validate(); err != nil {
+		return nil, err
+	}
+
+	return &hdr, nil
+}
+
+// directKey returns the in memory symmetric _key_ if _allowed_ for `ifcrypto.ChiperAES256`.
+func directKey(key ifcrypto.Key, allowed func(ifcrypto.Chipher) bool) ([]byte, error) {
+
+	raw, ok := key.GetKey().([]byte)
+	if !ok {
+		return nil, fmt.Errorf("key: %s is not a in memory symmetric key", key.GetID())
+	}
+
+	if !allowed(ifcrypto.ChiperAES256) {
+		return nil, fmt.Errorf("key: %s may not be used with: %s", key.GetID(), ifcrypto.ChiperAES256)
+	}
+
+	return raw, nil
+}
+
+// publicKeyOf returns _key_ if a public key, otherwise the public portion of the key pair.
+func publicKeyOf(key ifcrypto.Key) (ifcrypto.PublicKey, error) {
+
+	if kp, ok := key.(ifcrypto.KeyPair); ok && kp.GetPublic() != nil {
+		return kp.GetPublic(), nil
+	}
+
+	if pk, ok := key.(ifcrypto.PublicKey); ok && !key.IsPrivate() {
+		return pk, nil
+	}
+
+	return nil, fmt.Errorf("key: %s has no public portion", key.GetID())
+}
+
+// concatKDF is the _Concat KDF_ of _NIST SP 800-56A_ using _SHA-256_ with the _OtherInfo_
+// as of _RFC 7518_ section 4.6.2. It returns _keyBits_ bits of key material.
+func concatKDF(z []byte, algID string, apu, apv []byte, keyBits int) []byte {
+
+	lengthPrefixed := func(buf, data []byte) []byte {
+
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+		return append(buf, data...)
+
+	}
+
+	var info []byte
+
+	info = lengthPrefixed(info, []byte(algID))
+	info = lengthPrefixed(info, apu)
+	info = lengthPrefixed(info, apv)
+	info = binary.BigEndian.AppendUint32(info, uint32(keyBits))
+
+	size := keyBits / 8
+	out := make([]byte, 0, size+sha256.Size)
+
+	for counter := uint32(1); len(out) < size; counter++ {
+
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(z)
+		h.Write(info)
+
+		out = h.Sum(out)
+
+	}
+
+	return out[:size]
+}
+
+// aesGCM creates a _AES-GCM_ for the content encryption key _cek_.
+func aesGCM(cek []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// randomBytes returns _n_ bytes from `rand.Reader`.
+func randomBytes(n int) ([]byte, error) {
+
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// decodeOptional decodes the _base64url_ _value_ of member _name_, if not empty.
+func decodeOptional(name, value string) ([]byte, error) {
+
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidJWE, name, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,210 @@
+package gojose
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESKeyWrapRFC3394(t *testing.T) {
+
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+
+	tests := []struct {
+		key     string
+		wrapped string
+	}{
+		{
+			"00112233445566778899AABBCCDDEEFF",
+			"64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+		},
+		{
+			"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	}
+
+	for _, tt := range tests {
+
+		key, _ := hex.DecodeString(tt.key)
+
+		wrapped, err := aesKeyWrap(kek, key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.wrapped, strings.ToUpper(hex.EncodeToString(wrapped)))
+
+		unwrapped, err := aesKeyUnwrap(kek, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, key, unwrapped)
+
+		wrapped[len(wrapped)-1] ^= 1
+
+		_, err = aesKeyUnwrap(kek, wrapped)
+		assert.Error(t, err)
+
+	}
+
+}
+
+func TestConcatKDFRFC7518(t *testing.T) {
+
+	alice, err := ParseJWK([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0",
+		"y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps"}`))
+	require.NoError(t, err)
+
+	bob, err := ParseJWK([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",
+		"y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",
+		"d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw","key_ops":["deriveBits"]}`))
+	require.NoError(t, err)
+
+	epk, err := alice.Key()
+	require.NoError(t, err)
+
+	private, err := bob.Key()
+	require.NoError(t, err)
+
+	z, err := gocrypto.NewKeyAgreement().DeriveSharedSecret(nil, private, epk.(ifcrypto.PublicKey))
+	require.NoError(t, err)
+
+	key := concatKDF(z, "A128GCM", []byte("Alice"), []byte("Bob"), 128)
+	assert.Equal(t, "VqqN6vgjbSBcIijNcacQGg", encode(key))
+
+}
+
+func TestJWERoundTrip(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	p256, err := gocrypto.NewECDSAPrivateKey("p256", 256, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	p521, err := gocrypto.NewECDSAPrivateKey("p521", 521, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	x25519, err := gocrypto.NewX25519PrivateKey("x25519", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	aesKey, err := gocrypto.NewSymmetricKey("aes", 256, ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		public  ifcrypto.Key
+		private ifcrypto.Key
+		alg     Algorithm
+		wrapped bool
+	}{
+		{"rsa-oaep-256", rsaKey.GetPublic(), rsaKey, AlgorithmRsaOaep256, true},
+		{"rsa-oaep-256-private", rsaKey, rsaKey, AlgorithmRsaOaep256, true},
+		{"ecdh-es-p256", p256.GetPublic(), p256, AlgorithmEcdhEs, false},
+		{"ecdh-es-a256kw-p521", p521.GetPublic(), p521, AlgorithmEcdhEsA256KW, true},
+		{"ecdh-es-x25519", x25519.GetPublic(), x25519, AlgorithmEcdhEs, false},
+		{"ecdh-es-a256kw-x25519", x25519, x25519, AlgorithmEcdhEsA256KW, true},
+		{"dir", aesKey, aesKey, AlgorithmDir, false},
+	}
+
+	jwe := NewJWE(gocrypto.NewCipher(), gocrypto.NewKeyAgreement())
+
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+
+			token, err := jwe.EncryptCompact(
+				c, []byte(`{"secret":true}`), tt.public, tt.alg,
+				&JWEHeader{Type: "JWT", AgreementPartyUInfo: encode([]byte("alice"))},
+			)
+			require.NoError(t, err)
+
+			parts := strings.Split(token, ".")
+			require.Len(t, parts, 5)
+			assert.Equal(t, tt.wrapped, parts[1] != "")
+
+			hdr, err := ParseJWEHeader(token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, hdr.Algorithm)
+			assert.Equal(t, ContentEncryptionA256GCM, hdr.Encryption)
+			assert.Equal(t, tt.private.GetID(), hdr.KeyID)
+
+			plaintext, hdr, err := jwe.DecryptCompact(c, token, tt.private)
+			require.NoError(t, err)
+			assert.Equal(t, `{"secret":true}`, string(plaintext))
+			assert.Equal(t, "JWT", hdr.Type)
+
+			tampered := strings.Join([]string{parts[0], parts[1], parts[2], parts[3][1:] + "A", parts[4]}, ".")
+
+			_, _, err = jwe.DecryptCompact(c, tampered, tt.private)
+			assert.True(t, errors.Is(err, ErrInvalidJWE), "got: %v", err)
+
+		})
+
+	}
+
+}
+
+func TestJWERejectsInvalidTokens(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := gocrypto.NewECDSAPrivateKey("ec", 256, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	other, err := gocrypto.NewECDSAPrivateKey("other", 256, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	p384, err := gocrypto.NewECDSAPrivateKey("ec", 384, ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	jwe := NewJWE(gocrypto.NewCipher(), gocrypto.NewKeyAgreement())
+
+	token, err := jwe.EncryptCompact(c, []byte("hello"), key.GetPublic(), AlgorithmEcdhEsA256KW, nil)
+	require.NoError(t, err)
+
+	_, _, err = jwe.DecryptCompact(c, token, other)
+	assert.True(t, errors.Is(err, ErrInvalidJWE), "kid do not match")
+
+	_, _, err = jwe.DecryptCompact(c, token, p384)
+	assert.True(t, errors.Is(err, ErrInvalidJWE), "epk on other curve")
+
+	_, _, err = jwe.DecryptCompact(c, "a.b.c", key)
+	assert.True(t, errors.Is(err, ErrInvalidJWE))
+
+	_, err = jwe.EncryptCompact(c, []byte("hello"), key.GetPublic(), AlgorithmEcdhEs, &JWEHeader{Compression: "DEF"})
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	_, err = jwe.EncryptCompact(c, []byte("hello"), key.GetPublic(), AlgorithmEcdhEs, &JWEHeader{Encryption: "A128GCM"})
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	_, err = jwe.EncryptCompact(c, []byte("hello"), key.GetPublic(), AlgorithmRsaOaep256, nil)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	k256, err := gocrypto.NewSecp256k1PrivateKey("k256", ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	_, err = jwe.EncryptCompact(c, []byte("hello"), k256, AlgorithmEcdhEs, nil)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	// a ephemeral key that is a private key must be rejected
+	hdr, err := ParseJWEHeader(token)
+	require.NoError(t, err)
+
+	epk, err := NewJWKFromKey(other, "")
+	require.NoError(t, err)
+	assert.True(t, epk.IsPrivate())
+
+	hdr.EphemeralPublicKey = epk
+
+	_, err = jwe.decryptECDH(c, key, hdr, nil)
+	assert.True(t, errors.Is(err, ErrInvalidJWE))
+
+}
@@ -0,0 +1,852 @@
+package gojose
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/utils/cryptoutils"
+)
+
+// ErrInvalidJWK is returned when a `JWK` is malformed or not possible to convert.
+var ErrInvalidJWK = errors.New("invalid JWK")
+
+// JwkKeyType is the `kty` member of a `JWK`.
+type JwkKeyType string
+
+const (
+	// JwkKeyTypeRSA is a _RSA_ key, i.e. `ifcrypto.KeyTypeRsa`.
+	JwkKeyTypeRSA JwkKeyType = "RSA"
+	// JwkKeyTypeEC is a elliptic curve key, i.e. `ifcrypto.KeyTypeEccNistP` or
+	// `ifcrypto.KeyTypeEccSecgP256k1`.
+	JwkKeyTypeEC JwkKeyType = "EC"
+	// JwkKeyTypeOKP is a _Octet Key Pair_ (_RFC 8037_), i.e. `ifcrypto.KeyTypeEd25519` or
+	// `ifcrypto.KeyTypeX25519`.
+	JwkKeyTypeOKP JwkKeyType = "OKP"
+	// JwkKeyTypeOct is a symmetric key, i.e. `ifcrypto.KeyTypeSymmetric`.
+	JwkKeyTypeOct JwkKeyType = "oct"
+)
+
+const (
+	// JwkUseSignature is the `use` of a key that signs, verifies or generates _MAC_.
+	JwkUseSignature = "sig"
+	// JwkUseEncryption is the `use` of a key that encrypts, decrypts or do key agreement.
+	JwkUseEncryption = "enc"
+)
+
+// Key operations of the `key_ops` member of a `JWK`.
+const (
+	JwkKeyOpSign       = "sign"
+	JwkKeyOpVerify     = "verify"
+	JwkKeyOpEncrypt    = "encrypt"
+	JwkKeyOpDecrypt    = "decrypt"
+	JwkKeyOpWrapKey    = "wrapKey"
+	JwkKeyOpUnwrapKey  = "unwrapKey"
+	JwkKeyOpDeriveKey  = "deriveKey"
+	JwkKeyOpDeriveBits = "deriveBits"
+)
+
+// JWK is a _JSON Web Key_ as of _RFC 7517_.
+//
+// The `kid` maps onto `ifcrypto.Key.GetID`, the `use` and `key_ops` onto `ifcrypto.KeyUsage`
+// and the `alg` onto `ifcrypto.SignAlgorithm` or `ifcrypto.MacAlgorithm`. All binary members are
+// _base64url_ encoded without padding.
+//
+// .Example
+// [source,go]
+// ----
+// jwk, err := gojose.NewJWKFromKey(key, gojose.AlgorithmES256); data, err := json.Marshal(jwk)
+// ----
+type JWK struct {
+	KeyType   JwkKeyType `json:"kty"`
+	KeyID     string     `json:"kid,omitempty"`
+	Use       string     `json:"use,omitempty"`
+	KeyOps    []string   `json:"key_ops,omitempty"`
+	Algorithm Algorithm  `json:"alg,omitempty"`
+	// Curve is the `crv` of a `JwkKeyTypeEC` or `JwkKeyTypeOKP` key.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+	// D is the private scalar, or seed, of a `JwkKeyTypeEC`, `JwkKeyTypeOKP` key. For a
+	// `JwkKeyTypeRSA` key it is the private exponent.
+	D  string `json:"d,omitempty"`
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+	// K is the symmetric key of a `JwkKeyTypeOct` key.
+	K string `json:"k,omitempty"`
+}
+
+// NewJWKFromKey encodes the _key_ as a `JWK` with the optional _alg_.
+//
+// In memory private keys are encoded including the private members, use `JWK.Public` to
+// get the public portion. A remote key, e.g. in _AWS KMS_, that is a `ifcrypto.KeyPair`
+// encodes its public portion, any other remote key is not possible to encode.
+//
+// The _alg_ is validated against the _key_, see `Algorithm.ValidateKey`. Both `key_ops` and,
+// when all usages is either signing or encryption, `use` are set from the key usage.
+func NewJWKFromKey(key ifcrypto.Key, alg Algorithm) (*JWK, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key")
+	}
+
+	if key.IsRemoteKey() {
+
+		kp, ok := key.(ifcrypto.KeyPair)
+
+		if !ok || kp.GetPublic() == nil {
+			return nil, fmt.Errorf("%w: remote key: %s has no public portion", ErrInvalidJWK, key.GetID())
+		}
+
+		key = kp.GetPublic()
+
+	}
+
+	if alg != "" {
+
+		if err := alg.ValidateKey(key); err != nil {
+			return nil, err
+		}
+
+	}
+
+	jwk := &JWK{
+		KeyID:     key.GetID(),
+		Algorithm: alg,
+		KeyOps:    keyOpsFromUsage(key.GetKeyUsage()),
+	}
+
+	jwk.Use = useFromKeyOps(jwk.KeyOps)
+
+	switch k := key.GetKey().(type) {
+	case *rsa.PrivateKey:
+
+		if len(k.Primes) != 2 {
+			return nil, fmt.Errorf("%w: multi-prime RSA keys are not supported", ErrInvalidJWK)
+		}
+
+		dp, dq, qi, err := rsaCRTValues(k)
+		if err != nil {
+			return nil, err
+		}
+
+		jwk.setRSAPublic(&k.PublicKey)
+		jwk.D = encodeBigInt(k.D, 0)
+		jwk.P = encodeBigInt(k.Primes[0], 0)
+		jwk.Q = encodeBigInt(k.Primes[1], 0)
+		jwk.DP = encodeBigInt(dp, 0)
+		jwk.DQ = encodeBigInt(dq, 0)
+		jwk.QI = encodeBigInt(qi, 0)
+
+	case *rsa.PublicKey:
+
+		jwk.setRSAPublic(k)
+
+	case *ecdsa.PrivateKey:
+
+		if err := jwk.setECPublic(&k.PublicKey); err != nil {
+			return nil, err
+		}
+
+		jwk.D = encodeBigInt(k.D, coordinateSize(k.Curve))
+
+	case *ecdsa.PublicKey:
+
+		if err := jwk.setECPublic(k); err != nil {
+			return nil, err
+		}
+
+	case ed25519.PrivateKey:
+
+		jwk.KeyType, jwk.Curve = JwkKeyTypeOKP, "Ed25519"
+		jwk.X = encode(k.Public().(ed25519.PublicKey))
+		jwk.D = encode(k.Seed())
+
+	case ed25519.PublicKey:
+
+		jwk.KeyType, jwk.Curve = JwkKeyTypeOKP, "Ed25519"
+		jwk.X = encode(k)
+
+	case *ecdh.PrivateKey:
+
+		if k.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: only X25519 ecdh keys are supported", ErrInvalidJWK)
+		}
+
+		jwk.KeyType, jwk.Curve = JwkKeyTypeOKP, "X25519"
+		jwk.X = encode(k.PublicKey().Bytes())
+		jwk.D = encode(k.Bytes())
+
+	case *ecdh.PublicKey:
+
+		if k.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: only X25519 ecdh keys are supported", ErrInvalidJWK)
+		}
+
+		jwk.KeyType, jwk.Curve = JwkKeyTypeOKP, "X25519"
+		jwk.X = encode(k.Bytes())
+
+	case []byte:
+
+		jwk.KeyType = JwkKeyTypeOct
+		jwk.K = encode(k)
+
+	default:
+		return nil, fmt.Errorf("%w: key: %s of type: %T is not supported", ErrInvalidJWK, key.GetID(), k)
+	}
+
+	return jwk, nil
+}
+
+// ParseJWK parses a single `JWK` from _JSON_ _data_ and validates that it may be converted
+// to a `ifcrypto.Key`.
+func ParseJWK(data []byte) (*JWK, error) {
+
+	var jwk JWK
+
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+	}
+
+	if _, err := jwk.Key(); err != nil {
+		return nil, err
+	}
+
+	return &jwk, nil
+}
+
+// IsPrivate returns `true` if the `JWK` holds private members. A `JwkKeyTypeOct` is always private.
+func (k *JWK) IsPrivate() bool {
+	return k.KeyType == JwkKeyTypeOct || k.D != ""
+}
+
+// GetKeyUsage returns the `ifcrypto.KeyUsage` from `key_ops` or, if not set, from `use`.
+//
+// A `JwkKeyTypeOct` key that signs or verifies maps onto `ifcrypto.KeyUsageMac` and `deriveKey`
+// or `deriveBits` onto `ifcrypto.KeyUsageKeyAgreement`. When only `use` is set, a elliptic
+// curve key for encryption is a `ifcrypto.KeyUsageKeyAgreement` key, as in _ECDH-ES_.
+//
+// When neither `key_ops` nor `use` is set, as allowed by _RFC 7517_, the `use` is derived from
+// `alg`, see `impliedUse`. Hence, a public signature key may verify and a private may both sign
+// and verify.
+func (k *JWK) GetKeyUsage() []ifcrypto.KeyUsage {
+
+	if len(k.KeyOps) > 0 {
+		return usageFromKeyOps(k.KeyType, k.KeyOps)
+	}
+
+	use := k.Use
+
+	if use == "" {
+		use = k.impliedUse()
+	}
+
+	switch use {
+	case JwkUseSignature:
+
+		if k.KeyType == JwkKeyTypeOct {
+			return []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}
+		}
+
+		if k.IsPrivate() {
+			return []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+		}
+
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageVerify}
+
+	case JwkUseEncryption:
+
+		if k.KeyType == JwkKeyTypeEC || k.KeyType == JwkKeyTypeOKP {
+			return []ifcrypto.KeyUsage{ifcrypto.KeyUsageKeyAgreement}
+		}
+
+		if k.IsPrivate() {
+			return []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt}
+		}
+
+		return []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt}
+
+	}
+
+	return nil
+}
+
+// impliedUse returns the `use` implied by `alg` or, if not set, by `kty` and `crv`.
+//
+// The key management algorithms and _X25519_ keys implies `JwkUseEncryption` whereas all other
+// keys are assumed to be signature keys, as in a _JWKS_ published to validate tokens. If `alg` is
+// not supported, no `use` is implied.
+func (k *JWK) impliedUse() string {
+
+	switch {
+	case k.Algorithm.IsSignature() || k.Algorithm.IsMac():
+		return JwkUseSignature
+	case k.Algorithm == AlgorithmRsaOaep256 || k.Algorithm == AlgorithmEcdhEs ||
+		k.Algorithm == AlgorithmEcdhEsA256KW || k.Algorithm == AlgorithmDir:
+		return JwkUseEncryption
+	case k.Algorithm != "":
+		return ""
+	case k.KeyType == JwkKeyTypeOKP && k.Curve == "X25519":
+		return JwkUseEncryption
+	}
+
+	return JwkUseSignature
+}
+
+// SignAlgorithm returns the `ifcrypto.SignAlgorithm` of the `alg` member.
+//
+// If the `alg` is not set or not a asymmetric signature algorithm, a error wrapping
+// `ErrUnsupportedAlgorithm` is returned.
+func (k *JWK) SignAlgorithm() (ifcrypto.SignAlgorithm, error) {
+	return k.Algorithm.SignAlgorithm()
+}
+
+// Public returns a copy of the `JWK` without any private members.
+//
+// Only the `verify`, `encrypt` and `wrapKey` operations are kept. Since a `JwkKeyTypeOct`
+// has no public portion, a error is returned.
+func (k *JWK) Public() (*JWK, error) {
+
+	if k.KeyType == JwkKeyTypeOct {
+		return nil, fmt.Errorf("%w: symmetric key: %s has no public portion", ErrInvalidJWK, k.KeyID)
+	}
+
+	public := *k
+	public.D, public.P, public.Q, public.DP, public.DQ, public.QI = "", "", "", "", "", ""
+	public.KeyOps = nil
+
+	for _, op := range k.KeyOps {
+
+		if op == JwkKeyOpVerify || op == JwkKeyOpEncrypt || op == JwkKeyOpWrapKey {
+			public.KeyOps = append(public.KeyOps, op)
+		}
+
+	}
+
+	return &public, nil
+}
+
+// Key converts the `JWK` into a in memory `ifcrypto.Key` with `kid` as id and the usage
+// from `GetKeyUsage`.
+//
+// A private key is returned as a `ifcrypto.KeyPair`, e.g. `*gocrypto.RSAPrivateKey`, otherwise
+// as a `ifcrypto.PublicKey`, e.g. `*gocrypto.RSAPublicKey`. A `JwkKeyTypeOct` is returned as
+// a `*gocrypto.SymmetricKey`.
+func (k *JWK) Key() (ifcrypto.Key, error) {
+
+	usage := k.GetKeyUsage()
+
+	var (
+		key ifcrypto.Key
+		err error
+	)
+
+	switch k.KeyType {
+	case JwkKeyTypeRSA:
+		key, err = k.rsaKey(usage)
+	case JwkKeyTypeEC:
+		key, err = k.ecKey(usage)
+	case JwkKeyTypeOKP:
+		key, err = k.okpKey(usage)
+	case JwkKeyTypeOct:
+
+		var raw []byte
+
+		if raw, err = decode("k", k.K, 0); err == nil {
+			key, err = gocrypto.NewSymmetricKeyFromKey(k.KeyID, raw, usage...)
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported kty: %s", ErrInvalidJWK, k.KeyType)
+	}
+
+	if err != nil {
+
+		if errors.Is(err, ErrInvalidJWK) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+
+	}
+
+	if k.Algorithm != "" {
+
+		if err := k.Algorithm.ValidateKey(key); err != nil {
+			return nil, err
+		}
+
+	}
+
+	return key, nil
+}
+
+// setRSAPublic sets the public members from _key_.
+func (k *JWK) setRSAPublic(key *rsa.PublicKey) {
+
+	k.KeyType = JwkKeyTypeRSA
+	k.N = encodeBigInt(key.N, 0)
+	k.E = encodeBigInt(big.NewInt(int64(key.E)), 0)
+
+}
+
+// setECPublic sets the public members from _key_.
+func (k *JWK) setECPublic(key *ecdsa.PublicKey) error {
+
+	crv, err := curveName(key.Curve)
+	if err != nil {
+		return err
+	}
+
+	size := coordinateSize(key.Curve)
+
+	k.KeyType, k.Curve = JwkKeyTypeEC, crv
+	k.X = encodeBigInt(key.X, size)
+	k.Y = encodeBigInt(key.Y, size)
+
+	return nil
+}
+
+// rsaKey converts a `JwkKeyTypeRSA` key.
+//
+// A private key must have the `p` and `q` members, the _CRT_ members are always recomputed.
+func (k *JWK) rsaKey(usage []ifcrypto.KeyUsage) (ifcrypto.Key, error) {
+
+	n, err := decodeBigInt("n", k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := decodeBigInt("e", k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("%w: rsa exponent is too large", ErrInvalidJWK)
+	}
+
+	public := rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	if k.D == "" {
+		return gocrypto.NewRSAPublicKeyFromKey(k.KeyID, &public, usage...), nil
+	}
+
+	d, err := decodeBigInt("d", k.D)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decodeBigInt("p", k.P)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := decodeBigInt("q", k.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &rsa.PrivateKey{PublicKey: public, D: d, Primes: []*big.Int{p, q}}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	key.Precompute()
+
+	return gocrypto.NewRSAPrivateKeyFromKey(k.KeyID, key, usage...), nil
+}
+
+// ecKey converts a `JwkKeyTypeEC` key.
+func (k *JWK) ecKey(usage []ifcrypto.KeyUsage) (ifcrypto.Key, error) {
+
+	curve, err := curveFromName(k.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	size := coordinateSize(curve)
+
+	x, err := decode("x", k.X, size)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := decode("y", k.Y, size)
+	if err != nil {
+		return nil, err
+	}
+
+	public := ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+
+	if !curve.IsOnCurve(public.X, public.Y) {
+		return nil, fmt.Errorf("%w: point is not on curve: %s", ErrInvalidJWK, k.Curve)
+	}
+
+	if k.D == "" {
+		return gocrypto.NewECDSAPublicKeyFromKey(k.KeyID, &public, usage...), nil
+	}
+
+	d, err := decode("d", k.D, size)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PrivateKey{PublicKey: public, D: new(big.Int).SetBytes(d)}
+
+	if key.D.Sign() == 0 || key.D.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("%w: private scalar is out of range", ErrInvalidJWK)
+	}
+
+	if !publicFromScalar(curve, d).Equal(&public) {
+		return nil, fmt.Errorf("%w: private key do not match the public key", ErrInvalidJWK)
+	}
+
+	return gocrypto.NewECDSAPrivateKeyFromKey(k.KeyID, key, usage...), nil
+}
+
+// okpKey converts a `JwkKeyTypeOKP` key.
+func (k *JWK) okpKey(usage []ifcrypto.KeyUsage) (ifcrypto.Key, error) {
+
+	x, err := decode("x", k.X, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k.Curve {
+	case "Ed25519":
+
+		if k.D == "" {
+			return gocrypto.NewEd25519PublicKeyFromKey(k.KeyID, ed25519.PublicKey(x), usage...)
+		}
+
+		d, err := decode("d", k.D, ed25519.SeedSize)
+		if err != nil {
+			return nil, err
+		}
+
+		key := ed25519.NewKeyFromSeed(d)
+
+		if !bytes.Equal(key.Public().(ed25519.PublicKey), x) {
+			return nil, fmt.Errorf("%w: private key do not match the public key", ErrInvalidJWK)
+		}
+
+		return gocrypto.NewEd25519PrivateKeyFromKey(k.KeyID, key, usage...)
+
+	case "X25519":
+
+		if k.D == "" {
+
+			public, err := ecdh.X25519().NewPublicKey(x)
+			if err != nil {
+				return nil, err
+			}
+
+			return gocrypto.NewX25519PublicKeyFromKey(k.KeyID, public, usage...)
+
+		}
+
+		d, err := decode("d", k.D, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ecdh.X25519().NewPrivateKey(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(key.PublicKey().Bytes(), x) {
+			return nil, fmt.Errorf("%w: private key do not match the public key", ErrInvalidJWK)
+		}
+
+		return gocrypto.NewX25519PrivateKeyFromKey(k.KeyID, key, usage...)
+
+	}
+
+	return nil, fmt.Errorf("%w: unsupported crv: %s", ErrInvalidJWK, k.Curve)
+}
+
+// keyOpsFromUsage maps the _usage_ onto `key_ops`.
+func keyOpsFromUsage(usage []ifcrypto.KeyUsage) []string {
+
+	var ops []string
+
+	add := func(op ...string) {
+
+		for _, o := range op {
+
+			if !containsString(ops, o) {
+				ops = append(ops, o)
+			}
+
+		}
+
+	}
+
+	for _, u := range usage {
+
+		switch u {
+		case ifcrypto.KeyUsageSign:
+			add(JwkKeyOpSign)
+		case ifcrypto.KeyUsageVerify:
+			add(JwkKeyOpVerify)
+		case ifcrypto.KeyUsageEncrypt:
+			add(JwkKeyOpEncrypt)
+		case ifcrypto.KeyUsageDecrypt:
+			add(JwkKeyOpDecrypt)
+		case ifcrypto.KeyUsageMac:
+			add(JwkKeyOpSign, JwkKeyOpVerify)
+		case ifcrypto.KeyUsageKeyAgreement:
+			add(JwkKeyOpDeriveKey, JwkKeyOpDeriveBits)
+		}
+
+	}
+
+	return ops
+}
+
+// usageFromKeyOps maps the `key_ops` _ops_ onto `ifcrypto.KeyUsage` for a key of _kty_.
+func usageFromKeyOps(kty JwkKeyType, ops []string) []ifcrypto.KeyUsage {
+
+	var usage []ifcrypto.KeyUsage
+
+	add := func(u ifcrypto.KeyUsage) {
+
+		for _, e := range usage {
+
+			if e == u {
+				return
+			}
+
+		}
+
+		usage = append(usage, u)
+
+	}
+
+	for _, op := range ops {
+
+		switch op {
+		case JwkKeyOpSign:
+
+			if kty == JwkKeyTypeOct {
+				add(ifcrypto.KeyUsageMac)
+			} else {
+				add(ifcrypto.KeyUsageSign)
+			}
+
+		case JwkKeyOpVerify:
+
+			if kty == JwkKeyTypeOct {
+				add(ifcrypto.KeyUsageMac)
+			} else {
+				add(ifcrypto.KeyUsageVerify)
+			}
+
+		case JwkKeyOpEncrypt, JwkKeyOpWrapKey:
+			add(ifcrypto.KeyUsageEncrypt)
+		case JwkKeyOpDecrypt, JwkKeyOpUnwrapKey:
+			add(ifcrypto.KeyUsageDecrypt)
+		case JwkKeyOpDeriveKey, JwkKeyOpDeriveBits:
+			add(ifcrypto.KeyUsageKeyAgreement)
+		}
+
+	}
+
+	return usage
+}
+
+// useFromKeyOps returns `JwkUseSignature` or `JwkUseEncryption` when all _ops_ are of the
+// same kind, otherwise a empty string.
+func useFromKeyOps(ops []string) string {
+
+	sig, enc := 0, 0
+
+	for _, op := range ops {
+
+		switch op {
+		case JwkKeyOpSign, JwkKeyOpVerify:
+			sig++
+		default:
+			enc++
+		}
+
+	}
+
+	switch {
+	case sig > 0 && enc == 0:
+		return JwkUseSignature
+	case enc > 0 && sig == 0:
+		return JwkUseEncryption
+	}
+
+	return ""
+}
+
+// rsaCRTValues computes the _CRT_ members _dp_, _dq_ and _qi_ of the two prime _key_.
+//
+// The `rsa.PrivateKey.Precompute` is not used since it mutates the _key_ that may be in use
+// by other go routines.
+func rsaCRTValues(key *rsa.PrivateKey) (dp, dq, qi *big.Int, err error) {
+
+	p, q := key.Primes[0], key.Primes[1]
+	one := big.NewInt(1)
+
+	dp = new(big.Int).Mod(key.D, new(big.Int).Sub(p, one))
+	dq = new(big.Int).Mod(key.D, new(big.Int).Sub(q, one))
+
+	if qi = new(big.Int).ModInverse(q, p); qi == nil {
+		return nil, nil, nil, fmt.Errorf("%w: invalid rsa primes", ErrInvalidJWK)
+	}
+
+	return dp, dq, qi, nil
+}
+
+// curveName returns the `crv` name of _curve_.
+func curveName(curve elliptic.Curve) (string, error) {
+
+	if cryptoutils.IsSecp256k1(curve) {
+		return "secp256k1", nil
+	}
+
+	switch curve {
+	case elliptic.P256():
+		return "P-256", nil
+	case elliptic.P384():
+		return "P-384", nil
+	case elliptic.P521():
+		return "P-521", nil
+	}
+
+	return "", fmt.Errorf("%w: unsupported curve: %s", ErrInvalidJWK, curve.Params().Name)
+}
+
+// curveFromName returns the curve of the `crv` _name_.
+func curveFromName(name string) (elliptic.Curve, error) {
+
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	case "secp256k1":
+		return cryptoutils.Secp256k1(), nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported crv: %s", ErrInvalidJWK, name)
+}
+
+// publicFromScalar derives the public key of the private scalar _d_ on _curve_.
+//
+// The _secp256k1_ public key is derived using the _decred_ `secp256k1` package, see
+// `cryptoutils.Secp256k1` why.
+func publicFromScalar(curve elliptic.Curve, d []byte) *ecdsa.PublicKey {
+
+	if cryptoutils.IsSecp256k1(curve) {
+
+		key := secp256k1.PrivKeyFromBytes(d)
+		defer key.Zero()
+
+		return key.PubKey().ToECDSA()
+
+	}
+
+	x, y := curve.ScalarBaseMult(d)
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+}
+
+// coordinateSize returns the number of bytes of a coordinate, or private scalar, on _curve_.
+func coordinateSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+// encode _base64url_ encodes _b_ without padding.
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeBigInt encodes _n_ as a big endian, _base64url_, value of _size_ bytes. If _size_
+// is zero, the minimal number of bytes is used.
+func encodeBigInt(n *big.Int, size int) string {
+
+	if size == 0 {
+
+		if n.Sign() == 0 {
+			return encode([]byte{0})
+		}
+
+		return encode(n.Bytes())
+
+	}
+
+	return encode(n.FillBytes(make([]byte, size)))
+}
+
+// decode decodes the _base64url_ _value_ of member _name_. If _size_ is non zero, the
+// decoded value must be exactly _size_ bytes.
+func decode(name, value string, size int) ([]byte, error) {
+
+	if value == "" {
+		return nil, fmt.Errorf("%w: missing member: %s", ErrInvalidJWK, name)
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: member: %s: %v", ErrInvalidJWK, name, err)
+	}
+
+	if size > 0 && len(b) != size {
+
+		return nil, fmt.Errorf(
+			"%w: member: %s must be %d bytes, got: %d", ErrInvalidJWK, name, size, len(b),
+		)
+
+	}
+
+	return b, nil
+}
+
+// decodeBigInt decodes the _base64url_ _value_ of member _name_ as a big endian integer.
+func decodeBigInt(name, value string) (*big.Int, error) {
+
+	b, err := decode(name, value, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// containsString returns `true` if _s_ is in _list_.
+func containsString(list []string, s string) bool {
+
+	for _, e := range list {
+
+		if e == s {
+			return true
+		}
+
+	}
+
+	return false
+}
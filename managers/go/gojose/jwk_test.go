@@ -0,0 +1,333 @@
+package gojose
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc7517EC is the private elliptic curve key of _RFC 7517_ appendix A.2.
+const rfc7517EC = `{"kty":"EC","crv":"P-256",
+	"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+	"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+	"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+	"use":"enc","kid":"1"}`
+
+// rfc8037Ed25519 is the private _Ed25519_ key of _RFC 8037_ appendix A.1.
+const rfc8037Ed25519 = `{"kty":"OKP","crv":"Ed25519",
+	"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+	"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+func TestParseRFC7517ECKey(t *testing.T) {
+
+	jwk, err := ParseJWK([]byte(rfc7517EC))
+	require.NoError(t, err)
+
+	key, err := jwk.Key()
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", key.GetID())
+	assert.Equal(t, ifcrypto.KeyTypeEccNistP, key.GetKeyType())
+	assert.Equal(t, 256, key.GetKeySize())
+	assert.True(t, key.IsPrivate())
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageKeyAgreement}, key.GetKeyUsage())
+	assert.IsType(t, &ecdsa.PrivateKey{}, key.GetKey())
+
+	public, err := jwk.Public()
+	require.NoError(t, err)
+	assert.Empty(t, public.D)
+	assert.Equal(t, jwk.X, public.X)
+
+	pkey, err := public.Key()
+	require.NoError(t, err)
+	assert.False(t, pkey.IsPrivate())
+
+}
+
+func TestParseRFC8037Ed25519Key(t *testing.T) {
+
+	jwk, err := ParseJWK([]byte(rfc8037Ed25519))
+	require.NoError(t, err)
+
+	key, err := jwk.Key()
+	require.NoError(t, err)
+
+	assert.Equal(t, ifcrypto.KeyTypeEd25519, key.GetKeyType())
+	assert.IsType(t, ed25519.PrivateKey{}, key.GetKey())
+
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+
+	sign := []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, sign...)
+	require.NoError(t, err)
+
+	p256, err := gocrypto.NewECDSAPrivateKey("p256", 256, sign...)
+	require.NoError(t, err)
+
+	p521, err := gocrypto.NewECDSAPrivateKey("p521", 521, sign...)
+	require.NoError(t, err)
+
+	k256, err := gocrypto.NewSecp256k1PrivateKey("k256", sign...)
+	require.NoError(t, err)
+
+	ed, err := gocrypto.NewEd25519PrivateKey("ed", sign...)
+	require.NoError(t, err)
+
+	x, err := gocrypto.NewX25519PrivateKey("x", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	hmac, err := gocrypto.NewSymmetricKey("hmac", 256, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	tests := []struct {
+		key ifcrypto.Key
+		alg Algorithm
+		kty JwkKeyType
+		crv string
+		use string
+	}{
+		{rsaKey, AlgorithmPS256, JwkKeyTypeRSA, "", JwkUseSignature},
+		{rsaKey.GetPublic(), AlgorithmRS256, JwkKeyTypeRSA, "", JwkUseSignature},
+		{p256, AlgorithmES256, JwkKeyTypeEC, "P-256", JwkUseSignature},
+		{p521.GetPublic(), AlgorithmES512, JwkKeyTypeEC, "P-521", JwkUseSignature},
+		{k256, AlgorithmES256K, JwkKeyTypeEC, "secp256k1", JwkUseSignature},
+		{ed, AlgorithmEdDSA, JwkKeyTypeOKP, "Ed25519", JwkUseSignature},
+		{x, "", JwkKeyTypeOKP, "X25519", JwkUseEncryption},
+		{hmac, AlgorithmHS256, JwkKeyTypeOct, "", JwkUseSignature},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(tt.key.GetID(), func(t *testing.T) {
+
+			jwk, err := NewJWKFromKey(tt.key, tt.alg)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.kty, jwk.KeyType)
+			assert.Equal(t, tt.crv, jwk.Curve)
+			assert.Equal(t, tt.use, jwk.Use)
+			assert.Equal(t, tt.key.GetID(), jwk.KeyID)
+			assert.Equal(t, tt.key.IsPrivate(), jwk.IsPrivate())
+
+			data, err := json.Marshal(jwk)
+			require.NoError(t, err)
+
+			parsed, err := ParseJWK(data)
+			require.NoError(t, err)
+
+			key, err := parsed.Key()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.key.GetID(), key.GetID())
+			assert.Equal(t, tt.key.GetKeyType(), key.GetKeyType())
+			assert.Equal(t, tt.key.GetKeySize(), key.GetKeySize())
+			assert.Equal(t, tt.key.GetKeyUsage(), key.GetKeyUsage())
+			assert.Equal(t, tt.key.IsPrivate(), key.IsPrivate())
+			assert.Equal(t, tt.key.GetKey(), key.GetKey())
+
+		})
+
+	}
+
+}
+
+func TestJWKSignAlgorithm(t *testing.T) {
+
+	key, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	jwk, err := NewJWKFromKey(key, AlgorithmPS384)
+	require.NoError(t, err)
+
+	alg, err := jwk.SignAlgorithm()
+	require.NoError(t, err)
+	assert.Equal(t, ifcrypto.SignAlgorithmRsaPssSha384, alg)
+
+	jwk.Algorithm = AlgorithmHS256
+
+	_, err = jwk.SignAlgorithm()
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+}
+
+func TestJWKAlgorithmMustMatchKey(t *testing.T) {
+
+	key, err := gocrypto.NewECDSAPrivateKey("p256", 256, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	_, err = NewJWKFromKey(key, AlgorithmES384)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	_, err = NewJWKFromKey(key, AlgorithmRS256)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+	jwk, err := NewJWKFromKey(key, AlgorithmES256)
+	require.NoError(t, err)
+
+	jwk.Algorithm = AlgorithmEdDSA
+
+	_, err = jwk.Key()
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+
+}
+
+func TestJWKUsageFromUse(t *testing.T) {
+
+	key, err := gocrypto.NewRSAPrivateKey("rsa", 2048)
+	require.NoError(t, err)
+
+	jwk, err := NewJWKFromKey(key, "")
+	require.NoError(t, err)
+
+	jwk.Use = JwkUseEncryption
+
+	assert.Equal(
+		t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt}, jwk.GetKeyUsage(),
+	)
+
+	public, err := jwk.Public()
+	require.NoError(t, err)
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt}, public.GetKeyUsage())
+
+	jwk.KeyOps = []string{JwkKeyOpUnwrapKey}
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageDecrypt}, jwk.GetKeyUsage())
+
+}
+
+func TestJWKUsageWithoutUse(t *testing.T) {
+
+	tests := []struct {
+		jwk   JWK
+		usage []ifcrypto.KeyUsage
+	}{
+		{JWK{KeyType: JwkKeyTypeEC, Algorithm: AlgorithmES256}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageVerify}},
+		{JWK{KeyType: JwkKeyTypeEC, Algorithm: AlgorithmES256, D: "d"}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}},
+		{JWK{KeyType: JwkKeyTypeRSA}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageVerify}},
+		{JWK{KeyType: JwkKeyTypeOct, Algorithm: AlgorithmHS256}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}},
+		{JWK{KeyType: JwkKeyTypeRSA, Algorithm: AlgorithmRsaOaep256}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt}},
+		{JWK{KeyType: JwkKeyTypeOKP, Curve: "X25519"}, []ifcrypto.KeyUsage{ifcrypto.KeyUsageKeyAgreement}},
+		{JWK{KeyType: JwkKeyTypeRSA, Algorithm: "RSA1_5"}, nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.usage, tt.jwk.GetKeyUsage(), "kty: %s, alg: %s", tt.jwk.KeyType, tt.jwk.Algorithm)
+	}
+
+}
+
+func TestNewJWKFromKeyDoNotMutateKey(t *testing.T) {
+
+	key, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	private := key.GetKey().(*rsa.PrivateKey)
+	expected := private.Precomputed
+
+	private.Precomputed = rsa.PrecomputedValues{}
+
+	jwk, err := NewJWKFromKey(key, AlgorithmRS256)
+	require.NoError(t, err)
+
+	assert.Nil(t, private.Precomputed.Dp, "the key must not be precomputed")
+	assert.Equal(t, encodeBigInt(expected.Dp, 0), jwk.DP)
+	assert.Equal(t, encodeBigInt(expected.Dq, 0), jwk.DQ)
+	assert.Equal(t, encodeBigInt(expected.Qinv, 0), jwk.QI)
+
+}
+
+func TestParseInvalidJWK(t *testing.T) {
+
+	tests := map[string]string{
+		"not json":      `{`,
+		"unknown kty":   `{"kty":"foo"}`,
+		"missing n":     `{"kty":"RSA","e":"AQAB"}`,
+		"unknown crv":   `{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}`,
+		"short x":       `{"kty":"EC","crv":"P-256","x":"AA","y":"AA"}`,
+		"padded base64": `{"kty":"oct","k":"AAAA=="}`,
+		"off curve": `{"kty":"EC","crv":"P-256",
+			"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+			"y":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4"}`,
+		"wrong d": `{"kty":"OKP","crv":"Ed25519",
+			"d":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+			"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+	}
+
+	for name, data := range tests {
+
+		t.Run(name, func(t *testing.T) {
+
+			_, err := ParseJWK([]byte(data))
+			assert.True(t, errors.Is(err, ErrInvalidJWK), "got: %v", err)
+
+		})
+
+	}
+
+}
+
+func TestJWKSet(t *testing.T) {
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecKey, err := gocrypto.NewECDSAPrivateKey("ec", 384, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	hmac, err := gocrypto.NewSymmetricKey("hmac", 512, ifcrypto.KeyUsageMac)
+	require.NoError(t, err)
+
+	set := NewJWKSet()
+	require.NoError(t, set.Add(rsaKey, AlgorithmRS256))
+	require.NoError(t, set.Add(ecKey, AlgorithmES384))
+	require.NoError(t, set.Add(hmac, AlgorithmHS512))
+
+	public, err := set.Public()
+	require.NoError(t, err)
+	require.Len(t, public.Keys, 2)
+
+	data, err := json.Marshal(public)
+	require.NoError(t, err)
+
+	parsed, err := ParseJWKSet(data)
+	require.NoError(t, err)
+	require.Len(t, parsed.Keys, 2)
+
+	jwk, ok := parsed.LookupKeyID("rsa")
+	require.True(t, ok)
+
+	key, err := jwk.Key()
+	require.NoError(t, err)
+	assert.False(t, key.IsPrivate())
+	assert.Equal(t, []ifcrypto.KeyUsage{ifcrypto.KeyUsageVerify}, key.GetKeyUsage())
+	assert.Equal(t, &rsaKey.GetKey().(*rsa.PrivateKey).PublicKey, key.GetKey())
+
+	_, ok = parsed.LookupKeyID("hmac")
+	assert.False(t, ok)
+
+}
+
+func TestParseJWKSetIgnoresUnknownKeyTypes(t *testing.T) {
+
+	set, err := ParseJWKSet([]byte(`{"keys":[{"kty":"foo","kid":"a"},` + rfc7517EC + `]}`))
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, "1", set.Keys[0].KeyID)
+
+	_, err = ParseJWKSet([]byte(`{"keys":[{"kty":"EC","kid":"b"}]}`))
+	assert.True(t, errors.Is(err, ErrInvalidJWK))
+
+	_, err = ParseJWKSet([]byte(`{}`))
+	assert.True(t, errors.Is(err, ErrInvalidJWK))
+
+}
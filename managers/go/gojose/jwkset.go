@@ -0,0 +1,126 @@
+package gojose
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// JWKSet is a _JSON Web Key Set_ as of _RFC 7517_ section 5.
+//
+// .Example
+// [source,go]
+// ----
+// set, err := gojose.ParseJWKSet(data); jwk, ok := set.LookupKeyID("my-key"); key, err := jwk.Key()
+// ----
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// NewJWKSet creates a new `JWKSet` of _keys_.
+func NewJWKSet(keys ...*JWK) *JWKSet {
+
+	if keys == nil {
+		keys = []*JWK{}
+	}
+
+	return &JWKSet{Keys: keys}
+}
+
+// ParseJWKSet parses a `JWKSet` from _JSON_ _data_.
+//
+// As recommended by _RFC 7517_, keys with a `kty` that is not supported are ignored. Any
+// other invalid key fails the parsing.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+
+	var raw struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+	}
+
+	if raw.Keys == nil {
+		return nil, fmt.Errorf("%w: missing member: keys", ErrInvalidJWK)
+	}
+
+	set := NewJWKSet()
+
+	for _, data := range raw.Keys {
+
+		var jwk JWK
+
+		if err := json.Unmarshal(data, &jwk); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+		}
+
+		switch jwk.KeyType {
+		case JwkKeyTypeRSA, JwkKeyTypeEC, JwkKeyTypeOKP, JwkKeyTypeOct:
+		default:
+			continue
+		}
+
+		if _, err := jwk.Key(); err != nil {
+			return nil, fmt.Errorf("key: %s: %w", jwk.KeyID, err)
+		}
+
+		set.Keys = append(set.Keys, &jwk)
+
+	}
+
+	return set, nil
+}
+
+// Add encodes _key_, see `NewJWKFromKey`, and adds it to the set.
+func (s *JWKSet) Add(key ifcrypto.Key, alg Algorithm) error {
+
+	jwk, err := NewJWKFromKey(key, alg)
+	if err != nil {
+		return err
+	}
+
+	s.Keys = append(s.Keys, jwk)
+
+	return nil
+}
+
+// LookupKeyID returns the first `JWK` with `kid` _kid_.
+func (s *JWKSet) LookupKeyID(kid string) (*JWK, bool) {
+
+	for _, jwk := range s.Keys {
+
+		if jwk.KeyID == kid {
+			return jwk, true
+		}
+
+	}
+
+	return nil, false
+}
+
+// Public returns a new `JWKSet` with the public portion of all keys, suitable to publish.
+//
+// Symmetric keys are not included since they have no public portion.
+func (s *JWKSet) Public() (*JWKSet, error) {
+
+	set := NewJWKSet()
+
+	for _, jwk := range s.Keys {
+
+		if jwk.KeyType == JwkKeyTypeOct {
+			continue
+		}
+
+		public, err := jwk.Public()
+		if err != nil {
+			return nil, err
+		}
+
+		set.Keys = append(set.Keys, public)
+
+	}
+
+	return set, nil
+}
@@ -0,0 +1,643 @@
+package gojose
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// JWSHeader is the _JOSE_ header of a _JSON Web Signature_ as of _RFC 7515_.
+//
+// Since no extensions are supported, a protected header with `crit` is always rejected.
+type JWSHeader struct {
+	Algorithm   Algorithm `json:"alg,omitempty"`
+	KeyID       string    `json:"kid,omitempty"`
+	Type        string    `json:"typ,omitempty"`
+	ContentType string    `json:"cty,omitempty"`
+	Critical    []string  `json:"crit,omitempty"`
+}
+
+// JWSSignature is a single signature of a `JWSMessage`.
+type JWSSignature struct {
+	// Protected is the integrity protected header.
+	Protected JWSHeader
+	// Header is the optional unprotected header, it is not part of the signature.
+	Header *JWSHeader
+	// Signature is the raw signature, e.g. _R || S_ for _ECDSA_.
+	Signature []byte
+	// protected is the encoded protected header as it was signed.
+	protected string
+}
+
+// JWSMessage is a payload and its signatures.
+//
+// When _Detached_ is `true` the payload is not part of the serialization, as of _RFC 7515_
+// appendix F, and the _Payload_ must be set before verifying.
+//
+// .Example
+// [source,go]
+// ----
+// msg := gojose.NewJWSMessage(payload); err := jws.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256, nil); token, err := msg.Compact()
+// ----
+type JWSMessage struct {
+	Payload    []byte
+	Detached   bool
+	Signatures []*JWSSignature
+}
+
+// JWS signs and verifies `JWSMessage` using a `ifcrypto.Signer` and `ifcrypto.Verifier`.
+//
+// Since it only relies on the interfaces it works with in memory keys, e.g. `gocrypto.NewSigner`,
+// and remote keys such as `awskms.AwsKms`. The _ASN.1 DER_ encoded _ECDSA_ signatures of the
+// `ifcrypto.Signer` are converted to, and from, the fixed size _R || S_ form that _JWS_ mandates.
+type JWS struct {
+	signer   ifcrypto.Signer
+	verifier ifcrypto.Verifier
+}
+
+// NewJWS creates a new `JWS` that signs using _signer_ and verifies using _verifier_.
+//
+// Either may be `nil` if only signing or verification is done.
+func NewJWS(signer ifcrypto.Signer, verifier ifcrypto.Verifier) *JWS {
+
+	return &JWS{signer: signer, verifier: verifier}
+
+}
+
+// NewJWSMessage creates a new, unsigned, `JWSMessage` for _payload_.
+func NewJWSMessage(payload []byte) *JWSMessage {
+	return &JWSMessage{Payload: payload}
+}
+
+// Sign signs _msg_ using _key_ and _alg_ and adds the signature to _msg_.
+//
+// The _alg_ is mapped onto a `Algorithm`, see `AlgorithmFromSignAlgorithm`. The _header_ is
+// optional and is used as the protected header where `alg` is always set and `kid` defaults
+// to `ifcrypto.Key.GetID`. The _tags_ are passed to the `ifcrypto.Signer`.
+func (j *JWS) Sign(
+	c ifctx.ServiceContext,
+	msg *JWSMessage,
+	key ifcrypto.Key,
+	alg ifcrypto.SignAlgorithm,
+	header *JWSHeader,
+	tags ...coremodel.Meta,
+) error {
+
+	if j.signer == nil {
+		return fmt.Errorf("no signer is configured")
+	}
+
+	if msg == nil {
+		return fmt.Errorf("must specify a message to sign")
+	}
+
+	jalg, err := AlgorithmFromSignAlgorithm(key, alg)
+	if err != nil {
+		return err
+	}
+
+	protected := JWSHeader{}
+
+	if header != nil {
+		protected = *header
+	}
+
+	if len(protected.Critical) > 0 {
+		return fmt.Errorf("%w: crit is not supported", ErrUnsupportedAlgorithm)
+	}
+
+	protected.Algorithm = jalg
+
+	if protected.KeyID == "" {
+		protected.KeyID = key.GetID()
+	}
+
+	data, err := json.Marshal(protected)
+	if err != nil {
+		return err
+	}
+
+	encoded := encode(data)
+
+	signature, err := j.signer.Sign(c, signingInput(encoded, msg.Payload), key, alg, tags...)
+	if err != nil {
+		return err
+	}
+
+	if alg.IsEcdsa() {
+
+		if signature, err = ecdsaSignatureToRaw(signature, key.GetKeySize()); err != nil {
+			return err
+		}
+
+	}
+
+	msg.Signatures = append(msg.Signatures, &JWSSignature{
+		Protected: protected,
+		Signature: signature,
+		protected: encoded,
+	})
+
+	return nil
+}
+
+// SignCompact signs the _payload_ and returns the _JWS Compact Serialization_.
+//
+// The _header_ is optional, see `Sign`.
+func (j *JWS) SignCompact(
+	c ifctx.ServiceContext,
+	payload []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.SignAlgorithm,
+	header *JWSHeader,
+	tags ...coremodel.Meta,
+) (string, error) {
+
+	msg := NewJWSMessage(payload)
+
+	if err := j.Sign(c, msg, key, alg, header, tags...); err != nil {
+		return "", err
+	}
+
+	return msg.Compact()
+}
+
+// Verify verifies that _msg_ has a valid signature made by _key_ and returns that signature.
+//
+// Signatures with a `kid` that differs from `ifcrypto.Key.GetID` are skipped. The `alg` must
+// be supported and match the _key_. If no signature verifies, a error wrapping
+// `ifcrypto.ErrInvalidSignature` is returned. The _tags_ are passed to the `ifcrypto.Verifier`.
+func (j *JWS) Verify(
+	c ifctx.ServiceContext,
+	msg *JWSMessage,
+	key ifcrypto.Key,
+	tags ...coremodel.Meta,
+) (*JWSSignature, error) {
+
+	if j.verifier == nil {
+		return nil, fmt.Errorf("no verifier is configured")
+	}
+
+	if msg == nil || key == nil {
+		return nil, fmt.Errorf("must specify a message and a key")
+	}
+
+	var last error
+
+	for _, sig := range msg.Signatures {
+
+		header := sig.GetHeader()
+
+		if header.KeyID != "" && header.KeyID != key.GetID() {
+			continue
+		}
+
+		if last = j.verifySignature(c, msg.Payload, sig, key, tags...); last == nil {
+			return sig, nil
+		}
+
+	}
+
+	if last == nil {
+
+		return nil, fmt.Errorf(
+			"%w: no signature made by key: %s", ifcrypto.ErrInvalidSignature, key.GetID(),
+		)
+
+	}
+
+	return nil, last
+}
+
+// VerifyCompact parses the _JWS Compact Serialization_ _token_, verifies it using _key_
+// and returns the payload.
+//
+// If the _token_ has a detached payload, the _payload_ must be passed.
+func (j *JWS) VerifyCompact(
+	c ifctx.ServiceContext,
+	token string,
+	key ifcrypto.Key,
+	payload []byte,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	msg, err := ParseJWSCompact(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Detached {
+		msg.Payload = payload
+	}
+
+	if _, err := j.Verify(c, msg, key, tags...); err != nil {
+		return nil, err
+	}
+
+	return msg.Payload, nil
+}
+
+// verifySignature verifies a single signature _sig_ of _payload_ using _key_.
+func (j *JWS) verifySignature(
+	c ifctx.ServiceContext,
+	payload []byte,
+	sig *JWSSignature,
+	key ifcrypto.Key,
+	tags ...coremodel.Meta,
+) error {
+
+	jalg := sig.GetHeader().Algorithm
+
+	alg, err := jalg.SignAlgorithm()
+	if err != nil {
+		return err
+	}
+
+	if err := jalg.ValidateKey(key); err != nil {
+		return err
+	}
+
+	signature := sig.Signature
+
+	if alg.IsEcdsa() {
+
+		if signature, err = ecdsaSignatureToDER(signature, key.GetKeySize()); err != nil {
+			return err
+		}
+
+	}
+
+	return j.verifier.Verify(c, signingInput(sig.protected, payload), signature, key, alg, tags...)
+}
+
+// GetHeader returns the union of the protected and the unprotected header.
+func (s *JWSSignature) GetHeader() JWSHeader {
+
+	header := s.Protected
+
+	if s.Header == nil {
+		return header
+	}
+
+	if header.Algorithm == "" {
+		header.Algorithm = s.Header.Algorithm
+	}
+
+	if header.KeyID == "" {
+		header.KeyID = s.Header.KeyID
+	}
+
+	if header.Type == "" {
+		header.Type = s.Header.Type
+	}
+
+	if header.ContentType == "" {
+		header.ContentType = s.Header.ContentType
+	}
+
+	return header
+}
+
+// Compact returns the _JWS Compact Serialization_.
+//
+// The message must have exactly one signature without any unprotected header.
+func (m *JWSMessage) Compact() (string, error) {
+
+	if len(m.Signatures) != 1 {
+		return "", fmt.Errorf("compact serialization requires one signature, got: %d", len(m.Signatures))
+	}
+
+	sig := m.Signatures[0]
+
+	if sig.Header != nil {
+		return "", fmt.Errorf("compact serialization do not support a unprotected header")
+	}
+
+	payload := ""
+
+	if !m.Detached {
+		payload = encode(m.Payload)
+	}
+
+	return sig.protected + "." + payload + "." + encode(sig.Signature), nil
+}
+
+// jwsSignatureJSON is a signature of the _JSON_ serializations.
+type jwsSignatureJSON struct {
+	Protected string          `json:"protected,omitempty"`
+	Header    json.RawMessage `json:"header,omitempty"`
+	Signature string          `json:"signature"`
+}
+
+// jwsJSON is the union of the flattened and general _JSON_ serializations.
+type jwsJSON struct {
+	Payload *string `json:"payload,omitempty"`
+	jwsSignatureJSON
+	Signatures []jwsSignatureJSON `json:"signatures,omitempty"`
+}
+
+// Flattened returns the _Flattened JWS JSON Serialization_.
+//
+// The message must have exactly one signature.
+func (m *JWSMessage) Flattened() ([]byte, error) {
+
+	if len(m.Signatures) != 1 {
+		return nil, fmt.Errorf("flattened serialization requires one signature, got: %d", len(m.Signatures))
+	}
+
+	sig, err := m.Signatures[0].toJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jwsJSON{Payload: m.encodedPayload(), jwsSignatureJSON: sig})
+}
+
+// General returns the _General JWS JSON Serialization_.
+func (m *JWSMessage) General() ([]byte, error) {
+
+	if len(m.Signatures) == 0 {
+		return nil, fmt.Errorf("general serialization requires at least one signature")
+	}
+
+	out := struct {
+		Payload    *string            `json:"payload,omitempty"`
+		Signatures []jwsSignatureJSON `json:"signatures"`
+	}{Payload: m.encodedPayload()}
+
+	for _, s := range m.Signatures {
+
+		sig, err := s.toJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		out.Signatures = append(out.Signatures, sig)
+
+	}
+
+	return json.Marshal(out)
+}
+
+// encodedPayload returns the encoded payload or `nil` if detached.
+func (m *JWSMessage) encodedPayload() *string {
+
+	if m.Detached {
+		return nil
+	}
+
+	payload := encode(m.Payload)
+
+	return &payload
+}
+
+// toJSON converts the signature to its _JSON_ serialization form.
+func (s *JWSSignature) toJSON() (jwsSignatureJSON, error) {
+
+	sig := jwsSignatureJSON{Protected: s.protected, Signature: encode(s.Signature)}
+
+	if s.Header != nil {
+
+		header, err := json.Marshal(s.Header)
+		if err != nil {
+			return sig, err
+		}
+
+		sig.Header = header
+
+	}
+
+	return sig, nil
+}
+
+// ParseJWSCompact parses a _JWS Compact Serialization_.
+//
+// If the payload is empty, the message is considered to have a detached payload.
+func ParseJWSCompact(token string) (*JWSMessage, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: compact serialization must have three parts", ifcrypto.ErrInvalidSignature)
+	}
+
+	sig, err := parseJWSSignature(jwsSignatureJSON{Protected: parts[0], Signature: parts[2]})
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &JWSMessage{Signatures: []*JWSSignature{sig}, Detached: parts[1] == ""}
+
+	if !msg.Detached {
+
+		if msg.Payload, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			return nil, fmt.Errorf("%w: payload: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+	}
+
+	return msg, nil
+}
+
+// ParseJWSJSON parses a _Flattened_ or _General JWS JSON Serialization_.
+//
+// If no payload member is present, the message has a detached payload.
+func ParseJWSJSON(data []byte) (*JWSMessage, error) {
+
+	var raw jwsJSON
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ifcrypto.ErrInvalidSignature, err)
+	}
+
+	signatures := raw.Signatures
+
+	if raw.Signature != "" {
+
+		if len(signatures) > 0 {
+			return nil, fmt.Errorf("%w: both signature and signatures is present", ifcrypto.ErrInvalidSignature)
+		}
+
+		signatures = []jwsSignatureJSON{raw.jwsSignatureJSON}
+
+	}
+
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("%w: no signatures", ifcrypto.ErrInvalidSignature)
+	}
+
+	msg := &JWSMessage{Detached: raw.Payload == nil}
+
+	if !msg.Detached {
+
+		payload, err := base64.RawURLEncoding.DecodeString(*raw.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+		msg.Payload = payload
+
+	}
+
+	for _, s := range signatures {
+
+		sig, err := parseJWSSignature(s)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Signatures = append(msg.Signatures, sig)
+
+	}
+
+	return msg, nil
+}
+
+// parseJWSSignature decodes and validates the headers and signature of _raw_.
+//
+// The protected and unprotected header members must be disjoint and `crit` is not
+// supported, see _RFC 7515_ section 4.1.11.
+func parseJWSSignature(raw jwsSignatureJSON) (*JWSSignature, error) {
+
+	sig := &JWSSignature{protected: raw.Protected}
+
+	signature, err := base64.RawURLEncoding.DecodeString(raw.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ifcrypto.ErrInvalidSignature, err)
+	}
+
+	sig.Signature = signature
+
+	var protected map[string]json.RawMessage
+
+	if raw.Protected != "" {
+
+		data, err := base64.RawURLEncoding.DecodeString(raw.Protected)
+		if err != nil {
+			return nil, fmt.Errorf("%w: protected header: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+		if err := json.Unmarshal(data, &protected); err != nil {
+			return nil, fmt.Errorf("%w: protected header: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+		if err := json.Unmarshal(data, &sig.Protected); err != nil {
+			return nil, fmt.Errorf("%w: protected header: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+	}
+
+	if len(raw.Header) > 0 {
+
+		var unprotected map[string]json.RawMessage
+
+		if err := json.Unmarshal(raw.Header, &unprotected); err != nil {
+			return nil, fmt.Errorf("%w: header: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+		for name := range unprotected {
+
+			if _, ok := protected[name]; ok {
+
+				return nil, fmt.Errorf(
+					"%w: header parameter: %s is both protected and unprotected",
+					ifcrypto.ErrInvalidSignature, name,
+				)
+
+			}
+
+		}
+
+		if _, ok := unprotected["crit"]; ok {
+			return nil, fmt.Errorf("%w: crit must be protected", ifcrypto.ErrInvalidSignature)
+		}
+
+		sig.Header = &JWSHeader{}
+
+		if err := json.Unmarshal(raw.Header, sig.Header); err != nil {
+			return nil, fmt.Errorf("%w: header: %v", ifcrypto.ErrInvalidSignature, err)
+		}
+
+	}
+
+	if _, ok := protected["crit"]; ok {
+		return nil, fmt.Errorf("%w: crit is not supported", ErrUnsupportedAlgorithm)
+	}
+
+	if sig.GetHeader().Algorithm == "" {
+		return nil, fmt.Errorf("%w: missing header parameter: alg", ifcrypto.ErrInvalidSignature)
+	}
+
+	return sig, nil
+}
+
+// signingInput returns the _JWS Signing Input_ of the _protected_ header and _payload_.
+func signingInput(protected string, payload []byte) []byte {
+
+	var b bytes.Buffer
+
+	b.WriteString(protected)
+	b.WriteByte('.')
+	b.WriteString(encode(payload))
+
+	return b.Bytes()
+}
+
+// ecdsaSignatureToRaw converts a _ASN.1 DER_ _ECDSA_ _signature_ into the _R || S_ form
+// where each integer is the size of the curve order of _keySize_ bits.
+func ecdsaSignatureToRaw(signature []byte, keySize int) ([]byte, error) {
+
+	var sig struct {
+		R, S *big.Int
+	}
+
+	if rest, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data after ECDSA signature")
+	}
+
+	size := (keySize + 7) / 8
+
+	if sig.R.Sign() < 0 || sig.S.Sign() < 0 || sig.R.BitLen() > size*8 || sig.S.BitLen() > size*8 {
+		return nil, fmt.Errorf("ECDSA signature do not match key size: %d", keySize)
+	}
+
+	raw := make([]byte, 2*size)
+
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+
+	return raw, nil
+}
+
+// ecdsaSignatureToDER converts a _R || S_ _ECDSA_ _signature_ of a _keySize_ bits key
+// into _ASN.1 DER_.
+func ecdsaSignatureToDER(signature []byte, keySize int) ([]byte, error) {
+
+	size := (keySize + 7) / 8
+
+	if len(signature) != 2*size {
+
+		return nil, fmt.Errorf(
+			"%w: ECDSA signature must be %d bytes, got: %d",
+			ifcrypto.ErrInvalidSignature, 2*size, len(signature),
+		)
+
+	}
+
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(signature[:size]),
+		S: new(big.Int).SetBytes(signature[size:]),
+	})
+}
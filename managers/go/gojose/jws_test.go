@@ -0,0 +1,293 @@
+package gojose
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWSVerifyRFC7515ES256(t *testing.T) {
+
+	jwk, err := ParseJWK([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU",
+		"y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0",
+		"d":"jpsQnnGQmL-YBIffH1136cspYG6-0iY7X1fCE9-E9LI","use":"sig"}`))
+	require.NoError(t, err)
+
+	public, err := jwk.Public()
+	require.NoError(t, err)
+
+	key, err := public.Key()
+	require.NoError(t, err)
+
+	token := "eyJhbGciOiJFUzI1NiJ9" +
+		".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+		".DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q"
+
+	jws := NewJWS(nil, gocrypto.NewSigner())
+
+	payload, err := jws.VerifyCompact(ctx.NewServiceContext(nil, nil), token, key, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(payload), `{"iss":"joe"`))
+
+}
+
+func TestJWSSignRFC8037EdDSA(t *testing.T) {
+
+	jwk, err := ParseJWK([]byte(rfc8037Ed25519))
+	require.NoError(t, err)
+
+	jwk.Use = JwkUseSignature
+
+	key, err := jwk.Key()
+	require.NoError(t, err)
+
+	signer := gocrypto.NewSigner()
+
+	// The key has no kid, hence the protected header is the same as in the RFC
+	token, err := NewJWS(signer, signer).SignCompact(
+		nil, []byte("Example of Ed25519 signing"), key, ifcrypto.SignAlgorithmEd25519, nil,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"+
+		".hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg", token)
+
+}
+
+func TestJWSCompactAllAlgorithms(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	usage := []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, usage...)
+	require.NoError(t, err)
+
+	p256, err := gocrypto.NewECDSAPrivateKey("p256", 256, usage...)
+	require.NoError(t, err)
+
+	p384, err := gocrypto.NewECDSAPrivateKey("p384", 384, usage...)
+	require.NoError(t, err)
+
+	p521, err := gocrypto.NewECDSAPrivateKey("p521", 521, usage...)
+	require.NoError(t, err)
+
+	k256, err := gocrypto.NewSecp256k1PrivateKey("k256", usage...)
+	require.NoError(t, err)
+
+	ed, err := gocrypto.NewEd25519PrivateKey("ed", usage...)
+	require.NoError(t, err)
+
+	tests := []struct {
+		key  ifcrypto.KeyPair
+		alg  ifcrypto.SignAlgorithm
+		jalg Algorithm
+		size int
+	}{
+		{rsaKey, ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, AlgorithmRS256, 256},
+		{rsaKey, ifcrypto.SignAlgorithmRsaPkcs1V15Sha384, AlgorithmRS384, 256},
+		{rsaKey, ifcrypto.SignAlgorithmRsaPkcs1V15Sha512, AlgorithmRS512, 256},
+		{rsaKey, ifcrypto.SignAlgorithmRsaPssSha256, AlgorithmPS256, 256},
+		{rsaKey, ifcrypto.SignAlgorithmRsaPssSha384, AlgorithmPS384, 256},
+		{rsaKey, ifcrypto.SignAlgorithmRsaPssSha512, AlgorithmPS512, 256},
+		{p256, ifcrypto.SignAlgorithmEcdSha256, AlgorithmES256, 64},
+		{p384, ifcrypto.SignAlgorithmEcdSha384, AlgorithmES384, 96},
+		{p521, ifcrypto.SignAlgorithmEcdSha512, AlgorithmES512, 132},
+		{k256, ifcrypto.SignAlgorithmEcdSha256, AlgorithmES256K, 64},
+		{ed, ifcrypto.SignAlgorithmEd25519, AlgorithmEdDSA, 64},
+	}
+
+	signer := gocrypto.NewSigner()
+	jws := NewJWS(signer, signer)
+
+	for _, tt := range tests {
+
+		t.Run(string(tt.jalg), func(t *testing.T) {
+
+			token, err := jws.SignCompact(c, []byte("hello world"), tt.key, tt.alg, &JWSHeader{Type: "JWT"})
+			require.NoError(t, err)
+
+			msg, err := ParseJWSCompact(token)
+			require.NoError(t, err)
+
+			header := msg.Signatures[0].GetHeader()
+			assert.Equal(t, tt.jalg, header.Algorithm)
+			assert.Equal(t, tt.key.GetID(), header.KeyID)
+			assert.Equal(t, "JWT", header.Type)
+			assert.Len(t, msg.Signatures[0].Signature, tt.size)
+
+			payload, err := jws.VerifyCompact(c, token, tt.key.GetPublic(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(payload))
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + encode([]byte("hello World")) + "." + parts[2]
+
+			_, err = jws.VerifyCompact(c, tampered, tt.key.GetPublic(), nil)
+			assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature), "got: %v", err)
+
+		})
+
+	}
+
+}
+
+func TestJWSGeneralSerialization(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	ecKey, err := gocrypto.NewECDSAPrivateKey("ec", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	other, err := gocrypto.NewECDSAPrivateKey("other", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	signer := gocrypto.NewSigner()
+	jws := NewJWS(signer, signer)
+
+	msg := NewJWSMessage([]byte(`{"hello":"world"}`))
+	require.NoError(t, jws.Sign(c, msg, rsaKey, ifcrypto.SignAlgorithmRsaPssSha256, nil))
+	require.NoError(t, jws.Sign(c, msg, ecKey, ifcrypto.SignAlgorithmEcdSha256, &JWSHeader{KeyID: "ec"}))
+
+	msg.Signatures[1].Header = &JWSHeader{ContentType: "json"}
+
+	_, err = msg.Compact()
+	assert.Error(t, err)
+
+	_, err = msg.Flattened()
+	assert.Error(t, err)
+
+	data, err := msg.General()
+	require.NoError(t, err)
+
+	parsed, err := ParseJWSJSON(data)
+	require.NoError(t, err)
+	require.Len(t, parsed.Signatures, 2)
+	assert.Equal(t, `{"hello":"world"}`, string(parsed.Payload))
+
+	sig, err := jws.Verify(c, parsed, rsaKey.GetPublic())
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmPS256, sig.GetHeader().Algorithm)
+
+	sig, err = jws.Verify(c, parsed, ecKey.GetPublic())
+	require.NoError(t, err)
+	assert.Equal(t, "json", sig.GetHeader().ContentType)
+
+	_, err = jws.Verify(c, parsed, other.GetPublic())
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+}
+
+func TestJWSFlattenedDetachedPayload(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := gocrypto.NewEd25519PrivateKey("ed", ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	signer := gocrypto.NewSigner()
+	jws := NewJWS(signer, signer)
+
+	msg := NewJWSMessage([]byte("detached content"))
+	msg.Detached = true
+
+	require.NoError(t, jws.Sign(c, msg, key, ifcrypto.SignAlgorithmEd25519, nil))
+
+	data, err := msg.Flattened()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "payload")
+
+	parsed, err := ParseJWSJSON(data)
+	require.NoError(t, err)
+	assert.True(t, parsed.Detached)
+
+	parsed.Payload = []byte("detached content")
+
+	_, err = jws.Verify(c, parsed, key.GetPublic())
+	require.NoError(t, err)
+
+	parsed.Payload = []byte("other content")
+
+	_, err = jws.Verify(c, parsed, key.GetPublic())
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+	token, err := msg.Compact()
+	require.NoError(t, err)
+	assert.Contains(t, token, "..")
+
+	payload, err := jws.VerifyCompact(c, token, key.GetPublic(), []byte("detached content"))
+	require.NoError(t, err)
+	assert.Equal(t, "detached content", string(payload))
+
+}
+
+func TestJWSRejectsInvalidHeaders(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key, err := gocrypto.NewECDSAPrivateKey("ec", 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	signer := gocrypto.NewSigner()
+	jws := NewJWS(signer, signer)
+
+	msg := NewJWSMessage([]byte("hello"))
+	require.NoError(t, jws.Sign(c, msg, key, ifcrypto.SignAlgorithmEcdSha256, nil))
+
+	sig := msg.Signatures[0]
+
+	header := func(h string) []byte {
+
+		data, err := json.Marshal(map[string]interface{}{
+			"payload":   encode(msg.Payload),
+			"protected": sig.protected,
+			"header":    json.RawMessage(h),
+			"signature": encode(sig.Signature),
+		})
+
+		require.NoError(t, err)
+		return data
+
+	}
+
+	_, err = ParseJWSJSON(header(`{"kid":"ec"}`))
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature), "duplicate kid")
+
+	_, err = ParseJWSJSON(header(`{"crit":["exp"]}`))
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature), "unprotected crit")
+
+	crit := encode([]byte(`{"alg":"ES256","crit":["b64"],"b64":false}`))
+
+	_, err = ParseJWSCompact(crit + "." + encode(msg.Payload) + "." + encode(sig.Signature))
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm), "protected crit")
+
+	none := encode([]byte(`{"alg":"none"}`))
+
+	parsed, err := ParseJWSCompact(none + "." + encode(msg.Payload) + ".")
+	require.NoError(t, err)
+
+	_, err = jws.Verify(c, parsed, key.GetPublic())
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm), "alg none")
+
+	rs256 := encode([]byte(`{"alg":"RS256"}`))
+
+	parsed, err = ParseJWSCompact(rs256 + "." + encode(msg.Payload) + "." + encode(sig.Signature))
+	require.NoError(t, err)
+
+	_, err = jws.Verify(c, parsed, key.GetPublic())
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm), "alg do not match key")
+
+	_, err = ParseJWSCompact("a.b")
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+}
@@ -0,0 +1,440 @@
+package gojose
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+var (
+	// ErrInvalidJWT is returned when a _JWT_ is malformed or do not validate. All other
+	// _JWT_ errors wraps this error.
+	ErrInvalidJWT = errors.New("invalid JWT")
+	// ErrJWTExpired is returned when the `exp` claim has passed.
+	ErrJWTExpired = fmt.Errorf("%w: token is expired", ErrInvalidJWT)
+	// ErrJWTNotYetValid is returned when the `nbf` claim has not yet passed.
+	ErrJWTNotYetValid = fmt.Errorf("%w: token is not yet valid", ErrInvalidJWT)
+	// ErrJWTUnknownKey is returned when the `kid` is not in the `JWTKeySet` or is retired.
+	ErrJWTUnknownKey = fmt.Errorf("%w: unknown key", ErrInvalidJWT)
+)
+
+// NumericDate is a _JWT_ time, i.e. the number of seconds since the unix epoch.
+type NumericDate int64
+
+// NewNumericDate returns the `NumericDate` of _t_, truncated to seconds.
+func NewNumericDate(t time.Time) NumericDate {
+	return NumericDate(t.Unix())
+}
+
+// Time returns the `NumericDate` as a `time.Time`.
+func (d NumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+//
+// Since _RFC 7519_ allows fractional seconds, those are accepted but truncated.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+
+	var f float64
+
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%w: numeric date: %v", ErrInvalidJWT, err)
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || f > math.MaxInt64 {
+		return fmt.Errorf("%w: numeric date out of range", ErrInvalidJWT)
+	}
+
+	*d = NumericDate(f)
+
+	return nil
+}
+
+// JWTAudience is the `aud` claim. It is marshalled as a single string when having
+// one element and accepts both a single string and a array of strings.
+type JWTAudience []string
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (a JWTAudience) MarshalJSON() ([]byte, error) {
+
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (a *JWTAudience) UnmarshalJSON(data []byte) error {
+
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = JWTAudience{single}
+		return nil
+	}
+
+	var many []string
+
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("%w: aud: %v", ErrInvalidJWT, err)
+	}
+
+	*a = many
+
+	return nil
+}
+
+// Contains returns `true` if _aud_ is one of the audiences.
+func (a JWTAudience) Contains(aud string) bool {
+	return containsString(a, aud)
+}
+
+// JWTClaims is the claims set of a _JWT_ as of _RFC 7519_.
+//
+// All claims that are not registered claims are in _Private_. When marshalled, a registered
+// claim takes precedence over a private claim with the same name.
+type JWTClaims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  JWTAudience `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+	// Private holds all other claims.
+	Private map[string]interface{} `json:"-"`
+}
+
+// registeredClaims are the claims that has a field in `JWTClaims`.
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// jwtClaims is used to (un)marshal the registered claims without recursion.
+type jwtClaims JWTClaims
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (c JWTClaims) MarshalJSON() ([]byte, error) {
+
+	registered, err := json.Marshal(jwtClaims(c))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Private) == 0 {
+		return registered, nil
+	}
+
+	claims := map[string]interface{}{}
+
+	for k, v := range c.Private {
+		claims[k] = v
+	}
+
+	if err := json.Unmarshal(registered, &claims); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(claims)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (c *JWTClaims) UnmarshalJSON(data []byte) error {
+
+	var registered jwtClaims
+
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return err
+	}
+
+	var private map[string]interface{}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	if err := d.Decode(&private); err != nil {
+		return err
+	}
+
+	for _, name := range registeredClaims {
+		delete(private, name)
+	}
+
+	if len(private) == 0 {
+		private = nil
+	}
+
+	*c = JWTClaims(registered)
+	c.Private = private
+
+	return nil
+}
+
+// JWTIssuer issues signed _JWT_ using the active key of a `JWTKeySet`.
+//
+// The signing is done by a `ifcrypto.Signer`, hence the key may be in process memory,
+// e.g. `gocrypto.NewSigner`, or in _AWS KMS_.
+//
+// .Example
+// [source,go]
+// ----
+// issuer := gojose.NewJWTIssuer(km, keys).WithIssuer("https://auth.example.com").WithTTL(time.Hour); token, err := issuer.Issue(c, &gojose.JWTClaims{Subject: "user"})
+// ----
+type JWTIssuer struct {
+	jws    *JWS
+	keys   *JWTKeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewJWTIssuer creates a new `JWTIssuer` that signs using _signer_ and the active key of _keys_.
+func NewJWTIssuer(signer ifcrypto.Signer, keys *JWTKeySet) *JWTIssuer {
+
+	return &JWTIssuer{jws: NewJWS(signer, nil), keys: keys, now: time.Now}
+
+}
+
+// WithIssuer sets the `iss` claim of tokens that has none.
+func (i *JWTIssuer) WithIssuer(issuer string) *JWTIssuer {
+
+	i.issuer = issuer
+	return i
+
+}
+
+// WithTTL sets the time to live of tokens that has no `exp` claim.
+func (i *JWTIssuer) WithTTL(ttl time.Duration) *JWTIssuer {
+
+	i.ttl = ttl
+	return i
+
+}
+
+// WithClock replaces the clock, `time.Now` is used by default.
+func (i *JWTIssuer) WithClock(now func() time.Time) *JWTIssuer {
+
+	i.now = now
+	return i
+
+}
+
+// Issue signs the _claims_ and returns the _JWS Compact Serialization_ of the token.
+//
+// The `iat` is set to now, and `iss` and `exp` from the issuer configuration, if not present
+// in _claims_. The header has the `typ` _JWT_ and the `kid` of the active key. The _tags_
+// are passed to the `ifcrypto.Signer`.
+func (i *JWTIssuer) Issue(
+	c ifctx.ServiceContext,
+	claims *JWTClaims,
+	tags ...coremodel.Meta,
+) (string, error) {
+
+	key, alg, ok := i.keys.Active()
+	if !ok {
+		return "", fmt.Errorf("no active signing key")
+	}
+
+	now := i.now()
+	issue := JWTClaims{}
+
+	if claims != nil {
+		issue = *claims
+	}
+
+	if issue.IssuedAt == 0 {
+		issue.IssuedAt = NewNumericDate(now)
+	}
+
+	if issue.ExpiresAt == 0 && i.ttl > 0 {
+		issue.ExpiresAt = NewNumericDate(now.Add(i.ttl))
+	}
+
+	if issue.Issuer == "" {
+		issue.Issuer = i.issuer
+	}
+
+	payload, err := json.Marshal(issue)
+	if err != nil {
+		return "", err
+	}
+
+	return i.jws.SignCompact(c, payload, key, alg, &JWSHeader{Type: "JWT", KeyID: key.GetID()}, tags...)
+}
+
+// JWTValidator validates signed _JWT_ using the keys of a `JWTKeySet`.
+//
+// The verification key is selected by the `kid` header and the `alg` must match the algorithm
+// the key was added with. The `exp` claim is required. If the token has a `aud` claim, the
+// validator must be configured with one of its audiences as of _RFC 7519_ section 4.1.3.
+//
+// .Example
+// [source,go]
+// ----
+// validator := gojose.NewJWTValidator(gocrypto.NewSigner(), keys).WithIssuer("https://auth.example.com").WithAudience("api"); claims, err := validator.Validate(c, token)
+// ----
+type JWTValidator struct {
+	jws      *JWS
+	keys     *JWTKeySet
+	issuers  []string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTValidator creates a new `JWTValidator` that verifies using _verifier_ and _keys_.
+func NewJWTValidator(verifier ifcrypto.Verifier, keys *JWTKeySet) *JWTValidator {
+
+	return &JWTValidator{jws: NewJWS(nil, verifier), keys: keys, now: time.Now}
+
+}
+
+// WithIssuer requires the `iss` claim to be one of _issuers_.
+func (v *JWTValidator) WithIssuer(issuers ...string) *JWTValidator {
+
+	v.issuers = issuers
+	return v
+
+}
+
+// WithAudience requires the `aud` claim to contain _audience_.
+func (v *JWTValidator) WithAudience(audience string) *JWTValidator {
+
+	v.audience = audience
+	return v
+
+}
+
+// WithLeeway allows for _leeway_ clock skew when validating `exp`, `nbf` and `iat`.
+func (v *JWTValidator) WithLeeway(leeway time.Duration) *JWTValidator {
+
+	v.leeway = leeway
+	return v
+
+}
+
+// WithClock replaces the clock, `time.Now` is used by default.
+func (v *JWTValidator) WithClock(now func() time.Time) *JWTValidator {
+
+	v.now = now
+	return v
+
+}
+
+// Validate verifies the signature of _token_ and validates its claims.
+//
+// All errors wraps `ErrInvalidJWT`, a invalid signature do also wrap `ifcrypto.ErrInvalidSignature`.
+// The _tags_ are passed to the `ifcrypto.Verifier`.
+func (v *JWTValidator) Validate(
+	c ifctx.ServiceContext,
+	token string,
+	tags ...coremodel.Meta,
+) (*JWTClaims, error) {
+
+	msg, err := ParseJWSCompact(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWT, err)
+	}
+
+	if msg.Detached {
+		return nil, fmt.Errorf("%w: missing payload", ErrInvalidJWT)
+	}
+
+	header := msg.Signatures[0].GetHeader()
+
+	if header.Type != "" && !strings.EqualFold(header.Type, "JWT") {
+		return nil, fmt.Errorf("%w: unsupported typ: %s", ErrInvalidJWT, header.Type)
+	}
+
+	if header.ContentType != "" {
+		return nil, fmt.Errorf("%w: nested tokens are not supported", ErrInvalidJWT)
+	}
+
+	if header.KeyID == "" {
+		return nil, fmt.Errorf("%w: missing header parameter: kid", ErrInvalidJWT)
+	}
+
+	now := v.now()
+
+	key, alg, ok := v.keys.Lookup(header.KeyID, now)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJWTUnknownKey, header.KeyID)
+	}
+
+	expected, err := AlgorithmFromSignAlgorithm(key, alg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWT, err)
+	}
+
+	if header.Algorithm != expected {
+
+		return nil, fmt.Errorf(
+			"%w: key: %s requires alg: %s, got: %s", ErrInvalidJWT, header.KeyID, expected, header.Algorithm,
+		)
+
+	}
+
+	if _, err := v.jws.Verify(c, msg, key, tags...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWT, err)
+	}
+
+	var claims JWTClaims
+
+	if err := json.Unmarshal(msg.Payload, &claims); err != nil {
+
+		if errors.Is(err, ErrInvalidJWT) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidJWT, err)
+
+	}
+
+	if err := v.validateClaims(&claims, now); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// validateClaims validates the registered _claims_ at _now_.
+func (v *JWTValidator) validateClaims(claims *JWTClaims, now time.Time) error {
+
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing claim: exp", ErrInvalidJWT)
+	}
+
+	if !now.Before(claims.ExpiresAt.Time().Add(v.leeway)) {
+		return fmt.Errorf("%w: at: %s", ErrJWTExpired, claims.ExpiresAt.Time().UTC())
+	}
+
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(claims.NotBefore.Time()) {
+		return fmt.Errorf("%w: until: %s", ErrJWTNotYetValid, claims.NotBefore.Time().UTC())
+	}
+
+	if claims.IssuedAt != 0 && now.Add(v.leeway).Before(claims.IssuedAt.Time()) {
+		return fmt.Errorf("%w: issued in the future: %s", ErrInvalidJWT, claims.IssuedAt.Time().UTC())
+	}
+
+	if len(v.issuers) > 0 && !containsString(v.issuers, claims.Issuer) {
+		return fmt.Errorf("%w: unexpected issuer: %s", ErrInvalidJWT, claims.Issuer)
+	}
+
+	if len(claims.Audience) > 0 || v.audience != "" {
+
+		if v.audience == "" || !claims.Audience.Contains(v.audience) {
+			return fmt.Errorf("%w: audience: %v do not contain: %s", ErrInvalidJWT, claims.Audience, v.audience)
+		}
+
+	}
+
+	return nil
+}
@@ -0,0 +1,348 @@
+package gojose
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that may be moved in tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) now() time.Time {
+	return f.t
+}
+
+func newSigningKey(t *testing.T, id string) *gocrypto.ECDSAPrivateKey {
+
+	key, err := gocrypto.NewECDSAPrivateKey(id, 256, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestJWTIssueAndValidate(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+
+	keys := NewJWTKeySet()
+	require.NoError(t, keys.Rotate(newSigningKey(t, "key-1"), ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	signer := gocrypto.NewSigner()
+
+	issuer := NewJWTIssuer(signer, keys).
+		WithIssuer("https://auth.example.com").
+		WithTTL(time.Hour).
+		WithClock(clock.now)
+
+	token, err := issuer.Issue(c, &JWTClaims{
+		Subject:  "user-1",
+		Audience: JWTAudience{"api"},
+		Private:  map[string]interface{}{"scope": "read write", "iss": "ignored"},
+	})
+	require.NoError(t, err)
+
+	msg, err := ParseJWSCompact(token)
+	require.NoError(t, err)
+	assert.Equal(t, JWSHeader{Algorithm: AlgorithmES256, KeyID: "key-1", Type: "JWT"}, msg.Signatures[0].Protected)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Payload, &raw))
+	assert.Equal(t, "api", raw["aud"])
+	assert.Equal(t, "https://auth.example.com", raw["iss"])
+
+	validator := NewJWTValidator(signer, keys).
+		WithIssuer("https://auth.example.com").
+		WithAudience("api").
+		WithClock(clock.now)
+
+	claims, err := validator.Validate(c, token)
+	require.NoError(t, err)
+
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, NumericDate(1700000000), claims.IssuedAt)
+	assert.Equal(t, NumericDate(1700003600), claims.ExpiresAt)
+	assert.Equal(t, map[string]interface{}{"scope": "read write"}, claims.Private)
+
+	clock.t = clock.t.Add(time.Hour)
+
+	_, err = validator.Validate(c, token)
+	assert.True(t, errors.Is(err, ErrJWTExpired), "got: %v", err)
+
+	_, err = validator.WithLeeway(time.Minute).Validate(c, token)
+	assert.NoError(t, err)
+
+}
+
+func TestJWTValidateClaims(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	now := time.Unix(1700000000, 0)
+
+	keys := NewJWTKeySet()
+	require.NoError(t, keys.Rotate(newSigningKey(t, "key-1"), ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	signer := gocrypto.NewSigner()
+	issuer := NewJWTIssuer(signer, keys).WithClock(func() time.Time { return now })
+
+	validator := func() *JWTValidator {
+
+		return NewJWTValidator(signer, keys).
+			WithIssuer("a", "b").
+			WithAudience("api").
+			WithClock(func() time.Time { return now })
+
+	}
+
+	exp := NewNumericDate(now.Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		claims JWTClaims
+		err    error
+	}{
+		{"valid", JWTClaims{Issuer: "b", Audience: JWTAudience{"x", "api"}, ExpiresAt: exp}, nil},
+		{"missing exp", JWTClaims{Issuer: "a", Audience: JWTAudience{"api"}}, ErrInvalidJWT},
+		{"expired", JWTClaims{Issuer: "a", Audience: JWTAudience{"api"}, ExpiresAt: NewNumericDate(now)}, ErrJWTExpired},
+		{
+			"not yet valid",
+			JWTClaims{Issuer: "a", Audience: JWTAudience{"api"}, ExpiresAt: exp, NotBefore: NewNumericDate(now.Add(time.Second))},
+			ErrJWTNotYetValid,
+		},
+		{
+			"issued in future",
+			JWTClaims{Issuer: "a", Audience: JWTAudience{"api"}, ExpiresAt: exp, IssuedAt: NewNumericDate(now.Add(time.Minute))},
+			ErrInvalidJWT,
+		},
+		{"wrong issuer", JWTClaims{Issuer: "c", Audience: JWTAudience{"api"}, ExpiresAt: exp}, ErrInvalidJWT},
+		{"wrong audience", JWTClaims{Issuer: "a", Audience: JWTAudience{"web"}, ExpiresAt: exp}, ErrInvalidJWT},
+		{"missing audience", JWTClaims{Issuer: "a", ExpiresAt: exp}, ErrInvalidJWT},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+
+			token, err := issuer.Issue(c, &tt.claims)
+			require.NoError(t, err)
+
+			_, err = validator().Validate(c, token)
+
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.err), "got: %v", err)
+			}
+
+		})
+
+	}
+
+	token, err := issuer.Issue(c, &JWTClaims{Issuer: "a", Audience: JWTAudience{"api"}, ExpiresAt: exp})
+	require.NoError(t, err)
+
+	_, err = NewJWTValidator(signer, keys).WithClock(func() time.Time { return now }).Validate(c, token)
+	assert.True(t, errors.Is(err, ErrInvalidJWT), "validator must identify with aud")
+
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("key-2", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	keys := NewJWTKeySet()
+	require.NoError(t, keys.Rotate(newSigningKey(t, "key-1"), ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	signer := gocrypto.NewSigner()
+	issuer := NewJWTIssuer(signer, keys).WithTTL(24 * time.Hour).WithClock(clock.now)
+	validator := NewJWTValidator(signer, keys).WithClock(clock.now)
+
+	old, err := issuer.Issue(c, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, keys.Rotate(rsaKey, ifcrypto.SignAlgorithmRsaPssSha256, clock.t.Add(time.Hour)))
+
+	next, err := issuer.Issue(c, nil)
+	require.NoError(t, err)
+
+	msg, err := ParseJWSCompact(next)
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", msg.Signatures[0].Protected.KeyID)
+	assert.Equal(t, AlgorithmPS256, msg.Signatures[0].Protected.Algorithm)
+
+	_, err = validator.Validate(c, old)
+	assert.NoError(t, err, "retiring key still verifies")
+
+	_, err = validator.Validate(c, next)
+	assert.NoError(t, err)
+
+	set, err := keys.JWKSet(clock.now())
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+	assert.False(t, set.Keys[0].IsPrivate())
+
+	clock.t = clock.t.Add(time.Hour)
+
+	_, err = validator.Validate(c, old)
+	assert.True(t, errors.Is(err, ErrJWTUnknownKey), "got: %v", err)
+
+	_, err = validator.Validate(c, next)
+	assert.NoError(t, err)
+
+	set, err = keys.JWKSet(clock.now())
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, "key-2", set.Keys[0].KeyID)
+
+	keys.Remove("key-2")
+
+	_, err = issuer.Issue(c, nil)
+	assert.Error(t, err)
+
+}
+
+func TestJWTValidateWithPublishedJWKSet(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key := newSigningKey(t, "key-1")
+
+	keys := NewJWTKeySet()
+	require.NoError(t, keys.Rotate(key, ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	signer := gocrypto.NewSigner()
+
+	token, err := NewJWTIssuer(signer, keys).WithTTL(time.Hour).Issue(c, &JWTClaims{Subject: "s"})
+	require.NoError(t, err)
+
+	set, err := keys.JWKSet(time.Now())
+	require.NoError(t, err)
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	published, err := ParseJWKSet(data)
+	require.NoError(t, err)
+
+	remote := NewJWTKeySet()
+	require.NoError(t, remote.AddJWKSet(published))
+
+	claims, err := NewJWTValidator(signer, remote).Validate(c, token)
+	require.NoError(t, err)
+	assert.Equal(t, "s", claims.Subject)
+
+	require.NoError(t, remote.Rotate(newSigningKey(t, "key-2"), ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	republished, err := remote.JWKSet(time.Now())
+	require.NoError(t, err)
+	require.Len(t, republished.Keys, 1, "keys of other issuers are not published")
+	assert.Equal(t, "key-2", republished.Keys[0].KeyID)
+
+}
+
+func TestJWTValidateWithJWKSetWithoutUse(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	key := newSigningKey(t, "k1")
+
+	keys := NewJWTKeySet()
+	require.NoError(t, keys.Rotate(key, ifcrypto.SignAlgorithmEcdSha256, time.Time{}))
+
+	signer := gocrypto.NewSigner()
+
+	token, err := NewJWTIssuer(signer, keys).WithTTL(time.Hour).Issue(c, &JWTClaims{Subject: "s"})
+	require.NoError(t, err)
+
+	set, err := keys.JWKSet(time.Now())
+	require.NoError(t, err)
+
+	for _, jwk := range set.Keys {
+		jwk.Use, jwk.KeyOps, jwk.Algorithm = "", nil, ""
+	}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"use"`)
+	assert.NotContains(t, string(data), `"key_ops"`)
+	assert.NotContains(t, string(data), `"alg"`, "alg is inferred from crv")
+
+	published, err := ParseJWKSet(data)
+	require.NoError(t, err)
+
+	remote := NewJWTKeySet()
+	require.NoError(t, remote.AddJWKSet(published))
+
+	claims, err := NewJWTValidator(signer, remote).Validate(c, token)
+	require.NoError(t, err)
+	assert.Equal(t, "s", claims.Subject)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("rsa", 2048, ifcrypto.KeyUsageSign)
+	require.NoError(t, err)
+
+	jwk, err := NewJWKFromKey(rsaKey.GetPublic(), AlgorithmRS256)
+	require.NoError(t, err)
+
+	jwk.Algorithm, jwk.Use, jwk.KeyOps = "", JwkUseSignature, nil
+
+	err = NewJWTKeySet().AddJWKSet(&JWKSet{Keys: []*JWK{jwk}})
+	assert.True(t, errors.Is(err, ErrInvalidJWK), "RSA keys must have alg, got: %v", err)
+
+}
+
+func TestJWTRejectsAlgorithmSubstitution(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+
+	rsaKey, err := gocrypto.NewRSAPrivateKey("key-1", 2048, ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify)
+	require.NoError(t, err)
+
+	signer := gocrypto.NewSigner()
+
+	issuing := NewJWTKeySet()
+	require.NoError(t, issuing.Rotate(rsaKey, ifcrypto.SignAlgorithmRsaPkcs1V15Sha256, time.Time{}))
+
+	token, err := NewJWTIssuer(signer, issuing).WithTTL(time.Hour).Issue(c, nil)
+	require.NoError(t, err)
+
+	validating := NewJWTKeySet()
+	require.NoError(t, validating.Add(rsaKey.GetPublic(), ifcrypto.SignAlgorithmRsaPssSha256))
+
+	_, err = NewJWTValidator(signer, validating).Validate(c, token)
+	assert.True(t, errors.Is(err, ErrInvalidJWT), "got: %v", err)
+
+	_, err = NewJWTValidator(signer, validating).Validate(c, "a.b.c")
+	assert.True(t, errors.Is(err, ErrInvalidJWT), "got: %v", err)
+
+}
+
+func TestJWTClaimsJSON(t *testing.T) {
+
+	var claims JWTClaims
+
+	require.NoError(t, json.Unmarshal([]byte(`{"aud":["a","b"],"exp":1300819380.5,"n":1}`), &claims))
+	assert.Equal(t, JWTAudience{"a", "b"}, claims.Audience)
+	assert.Equal(t, NumericDate(1300819380), claims.ExpiresAt)
+	assert.Equal(t, map[string]interface{}{"n": json.Number("1")}, claims.Private)
+
+	data, err := json.Marshal(claims)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"aud":["a","b"],"exp":1300819380,"n":1}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"exp":"tomorrow"}`), &claims))
+
+}
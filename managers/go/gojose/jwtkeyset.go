@@ -0,0 +1,296 @@
+package gojose
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// JWTKeySet holds the keys that a `JWTIssuer` signs with and a `JWTValidator` verifies with.
+//
+// One key is the active key that new tokens are signed with. When a new key is activated using
+// `Rotate`, the previous active key is retiring: it is still used to verify tokens until its
+// retirement time has passed, hence tokens issued before the rotation remain valid. Keys of
+// other issuers, e.g. from a published `JWKSet`, may be added for verification only.
+//
+// The keys may be in process memory or remote, such as _AWS KMS_ keys. It is safe to use from
+// multiple go routines.
+//
+// .Example
+// [source,go]
+// ----
+// keys := gojose.NewJWTKeySet(); err := keys.Rotate(next, ifcrypto.SignAlgorithmEcdSha256, time.Now().Add(24*time.Hour))
+// ----
+type JWTKeySet struct {
+	mu     sync.RWMutex
+	keys   map[string]*jwtKey
+	active string
+}
+
+// jwtKey is a key of a `JWTKeySet`.
+type jwtKey struct {
+	key ifcrypto.Key
+	alg ifcrypto.SignAlgorithm
+	// retireAt is the time when the key is no longer used for verification, zero if never.
+	retireAt time.Time
+	// imported is `true` if the key is of another issuer, added using `AddJWKSet`.
+	imported bool
+}
+
+// NewJWTKeySet creates a new, empty, `JWTKeySet`.
+func NewJWTKeySet() *JWTKeySet {
+
+	return &JWTKeySet{keys: map[string]*jwtKey{}}
+
+}
+
+// Add adds _key_ that verifies tokens signed using _alg_.
+//
+// The `ifcrypto.Key.GetID` is the `kid` and must not already exist in the set.
+func (s *JWTKeySet) Add(key ifcrypto.Key, alg ifcrypto.SignAlgorithm) error {
+	return s.add(key, alg, false)
+}
+
+// add adds _key_ using _alg_, _imported_ is `true` if the key is of another issuer.
+func (s *JWTKeySet) add(key ifcrypto.Key, alg ifcrypto.SignAlgorithm, imported bool) error {
+
+	if _, err := AlgorithmFromSignAlgorithm(key, alg); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key.GetID()]; ok {
+		return fmt.Errorf("key: %s already exists", key.GetID())
+	}
+
+	s.keys[key.GetID()] = &jwtKey{key: key, alg: alg, imported: imported}
+
+	return nil
+}
+
+// AddJWKSet adds all signature keys of _set_ for verification.
+//
+// Keys with a `alg` that is not a signature algorithm, or that may not verify, are skipped. If
+// `alg` is absent, it is inferred from the `crv` of _EC_ and _OKP_ keys. Since a _RSA_ key may be
+// used with several algorithms, a _RSA_ signature key without `alg` is an error.
+//
+// The keys are not published by `JWKSet`.
+func (s *JWTKeySet) AddJWKSet(set *JWKSet) error {
+
+	for _, jwk := range set.Keys {
+
+		alg, ok, err := jwkSignAlgorithm(jwk)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		key, err := jwk.Key()
+		if err != nil {
+			return err
+		}
+
+		if err := s.add(key, alg, true); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+// Rotate makes _key_ the active signing key using _alg_.
+//
+// The previously active key, if any, is retiring and verifies tokens until _retireAt_. A
+// zero _retireAt_ keeps verifying until the key is removed using `Remove`. If _key_ is
+// already in the set, it is activated and its retirement is cleared.
+func (s *JWTKeySet) Rotate(key ifcrypto.Key, alg ifcrypto.SignAlgorithm, retireAt time.Time) error {
+
+	if _, err := AlgorithmFromSignAlgorithm(key, alg); err != nil {
+		return err
+	}
+
+	if !key.CanSign(alg) {
+		return fmt.Errorf("key: %s can not sign using: %s", key.GetID(), alg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.keys[s.active]; ok && s.active != key.GetID() {
+		previous.retireAt = retireAt
+	}
+
+	s.keys[key.GetID()] = &jwtKey{key: key, alg: alg}
+	s.active = key.GetID()
+
+	return nil
+}
+
+// Remove removes the key with _kid_. If it is the active key, no key is active anymore.
+func (s *JWTKeySet) Remove(kid string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, kid)
+
+	if s.active == kid {
+		s.active = ""
+	}
+
+}
+
+// Active returns the active signing key and its algorithm.
+func (s *JWTKeySet) Active() (ifcrypto.Key, ifcrypto.SignAlgorithm, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.keys[s.active]
+	if !ok {
+		return nil, "", false
+	}
+
+	return entry.key, entry.alg, true
+}
+
+// Lookup returns the key with _kid_ and its algorithm if it is not retired at _now_.
+func (s *JWTKeySet) Lookup(kid string, now time.Time) (ifcrypto.Key, ifcrypto.SignAlgorithm, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.keys[kid]
+	if !ok || entry.retired(now) {
+		return nil, "", false
+	}
+
+	return entry.key, entry.alg, true
+}
+
+// JWKSet returns the public portion of all keys that are not retired at _now_, suitable
+// to publish for other validators. Keys of other issuers, added using `AddJWKSet`, are not
+// published.
+//
+// Remote keys, e.g. in _AWS KMS_, must have their public portion loaded.
+func (s *JWTKeySet) JWKSet(now time.Time) (*JWKSet, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kids := make([]string, 0, len(s.keys))
+
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+
+	sort.Strings(kids)
+
+	set := NewJWKSet()
+
+	for _, kid := range kids {
+
+		entry := s.keys[kid]
+
+		if entry.imported || entry.retired(now) {
+			continue
+		}
+
+		alg, err := AlgorithmFromSignAlgorithm(entry.key, entry.alg)
+		if err != nil {
+			return nil, err
+		}
+
+		jwk, err := NewJWKFromKey(entry.key, alg)
+		if err != nil {
+			return nil, err
+		}
+
+		public, err := jwk.Public()
+		if err != nil {
+			return nil, err
+		}
+
+		set.Keys = append(set.Keys, public)
+
+	}
+
+	return set, nil
+}
+
+// jwkSignAlgorithm returns the signature algorithm of the _jwk_ or `false` if it is not a
+// signature key.
+//
+// If `alg` is absent, the algorithm is inferred from `crv`, e.g. `AlgorithmES256` for _P-256_.
+func jwkSignAlgorithm(jwk *JWK) (ifcrypto.SignAlgorithm, bool, error) {
+
+	if jwk.Algorithm != "" {
+
+		alg, err := jwk.SignAlgorithm()
+		return alg, err == nil, nil
+
+	}
+
+	verify := false
+
+	for _, u := range jwk.GetKeyUsage() {
+
+		if u == ifcrypto.KeyUsageVerify {
+			verify = true
+		}
+
+	}
+
+	if !verify || jwk.KeyType == JwkKeyTypeOct {
+		return "", false, nil
+	}
+
+	var alg Algorithm
+
+	switch {
+	case jwk.KeyType == JwkKeyTypeRSA:
+
+		return "", false, fmt.Errorf(
+			"%w: RSA key: %s must specify alg, it can not be inferred", ErrInvalidJWK, jwk.KeyID,
+		)
+
+	case jwk.KeyType == JwkKeyTypeEC && jwk.Curve == "P-256":
+		alg = AlgorithmES256
+	case jwk.KeyType == JwkKeyTypeEC && jwk.Curve == "P-384":
+		alg = AlgorithmES384
+	case jwk.KeyType == JwkKeyTypeEC && jwk.Curve == "P-521":
+		alg = AlgorithmES512
+	case jwk.KeyType == JwkKeyTypeEC && jwk.Curve == "secp256k1":
+		alg = AlgorithmES256K
+	case jwk.KeyType == JwkKeyTypeOKP && jwk.Curve == "Ed25519":
+		alg = AlgorithmEdDSA
+	default:
+
+		return "", false, fmt.Errorf(
+			"%w: can not infer alg of key: %s of type: %s and curve: %s",
+			ErrUnsupportedAlgorithm, jwk.KeyID, jwk.KeyType, jwk.Curve,
+		)
+
+	}
+
+	sign, err := alg.SignAlgorithm()
+	if err != nil {
+		return "", false, err
+	}
+
+	return sign, true, nil
+}
+
+// retired returns `true` if the key is retired at _now_.
+func (k *jwtKey) retired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
@@ -0,0 +1,75 @@
+package gokms
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/model/coremodel"
+)
+
+// GenerateDataKey implements the `ifcrypto.DataKeyGenerator` interface.
+//
+// The _key_ must be a symmetric key that can encrypt using `ifcrypto.ChiperAES256`, the
+// data key is encrypted using it. Use `Decrypt` with `ifcrypto.ChiperAES256` to decrypt
+// the data key.
+func (km *GoKms) GenerateDataKey(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, []byte, error) {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !k.IsSymmetric() || !k.CanEncrypt(ifcrypto.ChiperAES256) {
+		return nil, nil, fmt.Errorf(
+			"%w: key: %s can not encrypt data keys", ifkms.ErrInvalidKeyUsage, k.GetID(),
+		)
+	}
+
+	size := cipher.GetDataKeySize()
+	if size <= 0 {
+		return nil, nil, fmt.Errorf("cipher: %s is not supported as data key", cipher)
+	}
+
+	plaintext := make([]byte, size)
+
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, err
+	}
+
+	encrypted, err := km.cipher.Encrypt(c, plaintext, k, ifcrypto.ChiperAES256, tags...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plaintext, encrypted, nil
+}
+
+// GenerateDataKeyWithoutPlaintext implements the `ifcrypto.DataKeyGenerator` interface.
+//
+// This is the same as `GenerateDataKey` but the plaintext data key is zeroed and not returned.
+func (km *GoKms) GenerateDataKeyWithoutPlaintext(
+	c ifctx.ServiceContext,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	plaintext, encrypted, err := km.GenerateDataKey(c, key, cipher, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range plaintext {
+		plaintext[i] = 0
+	}
+
+	return encrypted, nil
+}
@@ -0,0 +1,112 @@
+package gokms
+
+import (
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+)
+
+// GoKmsKey is a handle to a key that is managed by the `GoKms` and implements the
+// `ifcrypto.KeyPair` interface.
+//
+// The `GoKms` never hands out the in memory key, since that would allow operations on
+// disabled or pending deletion keys. Instead, the private or symmetric key material is
+// only reachable through the `GoKms` operations where the key state is checked, the same
+// as a remote _AWS KMS_ key. The public portion of a asymmetric key is present in memory
+// and hence it is possible to verify or encrypt locally. A symmetric key has no public
+// portion and `GetPublic` returns `nil`.
+type GoKmsKey struct {
+	// key is the managed key and must never be returned.
+	key ifcrypto.Key
+	// public is the public portion, `nil` if symmetric.
+	public ifcrypto.PublicKey
+}
+
+// newGoKmsKey creates a handle to the managed _key_.
+func newGoKmsKey(key ifcrypto.Key) *GoKmsKey {
+
+	handle := &GoKmsKey{key: key}
+
+	if kp, ok := key.(ifcrypto.KeyPair); ok {
+		handle.public = kp.GetPublic()
+	}
+
+	return handle
+}
+
+// GetID returns the key id in the `GoKms`.
+func (r *GoKmsKey) GetID() string {
+	return r.key.GetID()
+}
+
+// GetKeyUsage gets the keys usage. Some keys may have multiple usages.
+func (r *GoKmsKey) GetKeyUsage() []ifcrypto.KeyUsage {
+	return append([]ifcrypto.KeyUsage{}, r.key.GetKeyUsage()...)
+}
+
+// GetKeySize returns the number of bits of the key
+func (r *GoKmsKey) GetKeySize() int {
+	return r.key.GetKeySize()
+}
+
+// GetKeyType returns this keys `KeyType`.
+func (r *GoKmsKey) GetKeyType() ifcrypto.KeyType {
+	return r.key.GetKeyType()
+}
+
+// GetSupportedChiphers returns all the chipers that the key be used with.
+func (r *GoKmsKey) GetSupportedChiphers() []ifcrypto.Chipher {
+	return append([]ifcrypto.Chipher{}, r.key.GetSupportedChiphers()...)
+}
+
+// CanSign checks if the current _Key_ may participate in _alg_ `SignAlgorithm` to do sign operations with.
+func (r *GoKmsKey) CanSign(alg ifcrypto.SignAlgorithm) bool {
+	return r.key.CanSign(alg)
+}
+
+// CanVerify checks if the current _Key_ may participate in _alg_ `SignAlgorithm` to do verify on
+func (r *GoKmsKey) CanVerify(alg ifcrypto.SignAlgorithm) bool {
+	return r.key.CanVerify(alg)
+}
+
+// CanMac checks if the current _Key_ may be used to generate and verify a _MAC_ using _alg_ `MacAlgorithm`.
+func (r *GoKmsKey) CanMac(alg ifcrypto.MacAlgorithm) bool {
+	return r.key.CanMac(alg)
+}
+
+// CanEncrypt checks if the current _Key_ may be used to encrypt using the _cipher_.
+func (r *GoKmsKey) CanEncrypt(cipher ifcrypto.Chipher) bool {
+	return r.key.CanEncrypt(cipher)
+}
+
+// CanDecrypt checks if the current _Key_ may be used to decrypt using the _cipher_.
+func (r *GoKmsKey) CanDecrypt(cipher ifcrypto.Chipher) bool {
+	return r.key.CanDecrypt(cipher)
+}
+
+// GetPublic returns the public portion of the key.
+//
+// If symmetric key, `nil` is returned.
+func (r *GoKmsKey) GetPublic() ifcrypto.PublicKey {
+	return r.public
+}
+
+// GetKey returns the key id since the key is only accessible through the `GoKms`.
+func (r *GoKmsKey) GetKey() interface{} {
+	return r.GetID()
+}
+
+// IsSymmetric returns `true` if this is a `KeyTypeSymmetric`
+//
+// This is a convenience function instead of `GetKeyType`.
+func (r *GoKmsKey) IsSymmetric() bool {
+	return r.key.IsSymmetric()
+}
+
+// IsPrivate returns `true` since the handle always refers to the private, or symmetric, key.
+func (r *GoKmsKey) IsPrivate() bool {
+	return true
+}
+
+// IsRemoteKey returns `true` since the key material is not reachable through the handle.
+func (r *GoKmsKey) IsRemoteKey() bool {
+	return true
+}
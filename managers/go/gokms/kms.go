@@ -1 +1,831 @@
 package gokms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifctx"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/mariotoffia/goservice/utils"
+)
+
+// aliasPrefix is the mandatory prefix of all aliases.
+const aliasPrefix = "alias/"
+
+// GoKms is a process local _KMS_ that implements the `ifkms.KeyManager`, `ifcrypto.Signer`,
+// `ifcrypto.Verifier`, `ifcrypto.Mac`, `ifcrypto.Cipherable`, `ifcrypto.DataKeyGenerator`,
+// `ifcrypto.KeyGenerator` and `ifcrypto.KeyAgreement` interfaces.
+//
+// All keys are kept in memory using the `gocrypto` keys and the semantics follows the
+// _AWS KMS_ as close as possible. Hence, it is possible to use this in unit tests
+// or in environments where _AWS KMS_ is not available.
+//
+// It is safe to use from multiple go routines.
+type GoKms struct {
+	mu        sync.RWMutex
+	keys      map[string]*keyEntry
+	aliases   map[string]string
+	signer    gocrypto.GoSigner
+	cipher    gocrypto.GoCipher
+	mac       gocrypto.GoMac
+	generator gocrypto.GoKeyGenerator
+	agreement gocrypto.GoKeyAgreement
+	// now returns current time, it is replaceable in order to test deletion.
+	now func() time.Time
+}
+
+// keyEntry is a key that is managed by the `GoKms`.
+type keyEntry struct {
+	key          ifcrypto.Key
+	state        ifkms.KeyState
+	description  string
+	tags         []coremodel.Tag
+	creationDate time.Time
+	deletionDate time.Time
+}
+
+// NewGoKms creates a new, empty, `GoKms`.
+func NewGoKms() *GoKms {
+
+	return &GoKms{
+		keys:      map[string]*keyEntry{},
+		aliases:   map[string]string{},
+		signer:    gocrypto.NewSigner(),
+		cipher:    gocrypto.NewCipher(),
+		mac:       gocrypto.NewMac(),
+		generator: gocrypto.NewKeyGenerator(),
+		agreement: gocrypto.NewKeyAgreement(),
+		now:       time.Now,
+	}
+
+}
+
+// ImportKey imports a existing in memory _key_, for example a `gocrypto.RSAPrivateKey`.
+//
+// The `ifcrypto.Key.GetID` is used as key id and must not already exist. The key is
+// in `ifkms.KeyStateEnabled` when imported.
+func (km *GoKms) ImportKey(key ifcrypto.Key, description string, tags ...coremodel.Tag) error {
+
+	if key == nil || key.GetID() == "" {
+		return fmt.Errorf("must specify a key with a id")
+	}
+
+	if key.IsRemoteKey() {
+		return fmt.Errorf("key: %s is not in memory", key.GetID())
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if _, ok := km.keys[key.GetID()]; ok {
+		return fmt.Errorf("%w: key: %s", ifkms.ErrAlreadyExists, key.GetID())
+	}
+
+	km.keys[key.GetID()] = &keyEntry{
+		key:          key,
+		state:        ifkms.KeyStateEnabled,
+		description:  description,
+		tags:         append([]coremodel.Tag{}, tags...),
+		creationDate: km.now(),
+	}
+
+	return nil
+}
+
+// CreateKey implements the `ifkms.KeyManager` interface.
+//
+// Asymmetric keys may either be used for sign / verify, encrypt / decrypt or key agreement, the
+// same as _AWS KMS_. Only _RSA_ keys may encrypt / decrypt, _NIST_ curve keys may sign / verify or
+// do key agreement and _X25519_ keys may only do key agreement. Use `gocrypto.GoCipher` for _ECIES_.
+// The _secp256k1_ and _Ed25519_ keys may only be used for sign / verify. Symmetric keys may either be
+// used for `ifcrypto.KeyUsageMac` or encrypt / decrypt. A `GoKmsKey` handle to the created key is
+// returned.
+func (km *GoKms) CreateKey(
+	c ifctx.ServiceContext,
+	keyType ifcrypto.KeyType,
+	keySize int,
+	usage []ifcrypto.KeyUsage,
+	description string,
+	tags []coremodel.Tag,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	return km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType:     keyType,
+		KeySize:     keySize,
+		Usage:       usage,
+		Description: description,
+		Tags:        tags,
+	}, meta...)
+}
+
+// GenerateKey implements the `ifcrypto.KeyGenerator` interface.
+//
+// The key is generated using `gocrypto.GoKeyGenerator` and imported using the _spec_
+// _Description_ and _Tags_. The same usage restrictions as `CreateKey` applies. A `GoKmsKey`
+// handle to the generated key is returned.
+func (km *GoKms) GenerateKey(
+	c ifctx.ServiceContext,
+	spec ifcrypto.KeySpec,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	if err := validateUsage(spec.KeyType, spec.Usage); err != nil {
+		return nil, err
+	}
+
+	key, err := km.generator.GenerateKey(c, spec, meta...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := km.ImportKey(key, spec.Description, spec.Tags...); err != nil {
+		return nil, err
+	}
+
+	return newGoKmsKey(key), nil
+}
+
+// GetKey implements the `ifkms.KeyManager` interface.
+//
+// A `GoKmsKey` handle is returned, hence the key may only be used through the `GoKms` where
+// the key state is honored. If asymmetric, the public portion may be used locally.
+func (km *GoKms) GetKey(
+	c ifctx.ServiceContext,
+	keyID string,
+	meta ...coremodel.Meta,
+) (ifcrypto.Key, error) {
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGoKmsKey(entry.key), nil
+}
+
+// DescribeKey implements the `ifkms.KeyManager` interface.
+func (km *GoKms) DescribeKey(
+	c ifctx.ServiceContext,
+	keyID string,
+	meta ...coremodel.Meta,
+) (*ifkms.KeyInfo, error) {
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := km.keyInfo(entry)
+	return &info, nil
+}
+
+// ListKeys implements the `ifkms.KeyManager` interface.
+//
+// The keys are sorted by creation date.
+func (km *GoKms) ListKeys(c ifctx.ServiceContext, meta ...coremodel.Meta) ([]ifkms.KeyInfo, error) {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	km.purge()
+
+	keys := make([]ifkms.KeyInfo, 0, len(km.keys))
+
+	for _, entry := range km.keys {
+		keys = append(keys, km.keyInfo(entry))
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+
+		if keys[i].CreationDate.Equal(keys[j].CreationDate) {
+			return keys[i].Key.GetID() < keys[j].Key.GetID()
+		}
+
+		return keys[i].CreationDate.Before(keys[j].CreationDate)
+
+	})
+
+	return keys, nil
+}
+
+// EnableKey implements the `ifkms.KeyManager` interface.
+func (km *GoKms) EnableKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error {
+	return km.setState(keyID, ifkms.KeyStateEnabled)
+}
+
+// DisableKey implements the `ifkms.KeyManager` interface.
+func (km *GoKms) DisableKey(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error {
+	return km.setState(keyID, ifkms.KeyStateDisabled)
+}
+
+// ScheduleKeyDeletion implements the `ifkms.KeyManager` interface.
+//
+// The key is removed, along with its aliases, when accessed after the deletion date.
+func (km *GoKms) ScheduleKeyDeletion(
+	c ifctx.ServiceContext,
+	keyID string,
+	pendingWindowInDays int,
+	meta ...coremodel.Meta,
+) (time.Time, error) {
+
+	if pendingWindowInDays < 7 || pendingWindowInDays > 30 {
+
+		return time.Time{}, fmt.Errorf(
+			"pending window must be between 7 and 30 days, got: %d", pendingWindowInDays,
+		)
+
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if entry.state == ifkms.KeyStatePendingDeletion {
+
+		return time.Time{}, fmt.Errorf(
+			"%w: key: %s is already pending deletion", ifkms.ErrInvalidKeyState, entry.key.GetID(),
+		)
+
+	}
+
+	entry.state = ifkms.KeyStatePendingDeletion
+	entry.deletionDate = km.now().AddDate(0, 0, pendingWindowInDays)
+
+	return entry.deletionDate, nil
+}
+
+// CancelKeyDeletion implements the `ifkms.KeyManager` interface.
+func (km *GoKms) CancelKeyDeletion(c ifctx.ServiceContext, keyID string, meta ...coremodel.Meta) error {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	if entry.state != ifkms.KeyStatePendingDeletion {
+
+		return fmt.Errorf(
+			"%w: key: %s is not pending deletion", ifkms.ErrInvalidKeyState, entry.key.GetID(),
+		)
+
+	}
+
+	entry.state = ifkms.KeyStateDisabled
+	entry.deletionDate = time.Time{}
+
+	return nil
+}
+
+// CreateAlias implements the `ifkms.KeyManager` interface.
+func (km *GoKms) CreateAlias(c ifctx.ServiceContext, alias, keyID string, meta ...coremodel.Meta) error {
+
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	km.purge()
+
+	if _, ok := km.aliases[alias]; ok {
+		return fmt.Errorf("%w: alias: %s", ifkms.ErrAlreadyExists, alias)
+	}
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	km.aliases[alias] = entry.key.GetID()
+	return nil
+}
+
+// UpdateAlias implements the `ifkms.KeyManager` interface.
+func (km *GoKms) UpdateAlias(c ifctx.ServiceContext, alias, keyID string, meta ...coremodel.Meta) error {
+
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	km.purge()
+
+	if _, ok := km.aliases[alias]; !ok {
+		return fmt.Errorf("%w: alias: %s", ifkms.ErrKeyNotFound, alias)
+	}
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	km.aliases[alias] = entry.key.GetID()
+	return nil
+}
+
+// DeleteAlias implements the `ifkms.KeyManager` interface.
+func (km *GoKms) DeleteAlias(c ifctx.ServiceContext, alias string, meta ...coremodel.Meta) error {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	km.purge()
+
+	if _, ok := km.aliases[alias]; !ok {
+		return fmt.Errorf("%w: alias: %s", ifkms.ErrKeyNotFound, alias)
+	}
+
+	delete(km.aliases, alias)
+	return nil
+}
+
+// TagKey implements the `ifkms.KeyManager` interface.
+func (km *GoKms) TagKey(
+	c ifctx.ServiceContext,
+	keyID string,
+	tags []coremodel.Tag,
+	meta ...coremodel.Meta,
+) error {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+
+		replaced := false
+
+		for i := range entry.tags {
+
+			if entry.tags[i].Name == tag.Name {
+				entry.tags[i] = tag
+				replaced = true
+			}
+
+		}
+
+		if !replaced {
+			entry.tags = append(entry.tags, tag)
+		}
+
+	}
+
+	return nil
+}
+
+// UntagKey implements the `ifkms.KeyManager` interface.
+func (km *GoKms) UntagKey(
+	c ifctx.ServiceContext,
+	keyID string,
+	tagNames []string,
+	meta ...coremodel.Meta,
+) error {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	tags := []coremodel.Tag{}
+
+	for _, tag := range entry.tags {
+
+		if _, ok := utils.Contains(tagNames, tag.Name); !ok {
+			tags = append(tags, tag)
+		}
+
+	}
+
+	entry.tags = tags
+	return nil
+}
+
+// Sign implements the `ifcrypto.Signer` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+func (km *GoKms) Sign(
+	c ifctx.ServiceContext,
+	msg []byte,
+	key ifcrypto.Key,
+	signAlgorithm ifcrypto.SignAlgorithm,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.CanSign(signAlgorithm) {
+		return nil, fmt.Errorf("%w: key: %s can not sign using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), signAlgorithm)
+	}
+
+	return km.signer.Sign(c, msg, k, signAlgorithm, tags...)
+}
+
+// Verify implements the `ifcrypto.Verifier` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+func (km *GoKms) Verify(
+	c ifctx.ServiceContext,
+	msg []byte,
+	signature []byte,
+	key ifcrypto.Key,
+	signAlgorithm ifcrypto.SignAlgorithm,
+	tags ...coremodel.Meta,
+) error {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !k.CanVerify(signAlgorithm) {
+		return fmt.Errorf("%w: key: %s can not verify using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), signAlgorithm)
+	}
+
+	return km.signer.Verify(c, msg, signature, k, signAlgorithm, tags...)
+}
+
+// GenerateMac implements the `ifcrypto.Mac` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+func (km *GoKms) GenerateMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.CanMac(alg) {
+		return nil, fmt.Errorf("%w: key: %s can not generate mac using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), alg)
+	}
+
+	return km.mac.GenerateMac(c, msg, k, alg, tags...)
+}
+
+// VerifyMac implements the `ifcrypto.Mac` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+func (km *GoKms) VerifyMac(
+	c ifctx.ServiceContext,
+	msg []byte,
+	mac []byte,
+	key ifcrypto.Key,
+	alg ifcrypto.MacAlgorithm,
+	tags ...coremodel.Meta,
+) error {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !k.CanMac(alg) {
+		return fmt.Errorf("%w: key: %s can not verify mac using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), alg)
+	}
+
+	return km.mac.VerifyMac(c, msg, mac, k, alg, tags...)
+}
+
+// Encrypt implements the `ifcrypto.Cipherable` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias. If
+// it is a asymmetric key, the public portion is used to encrypt.
+func (km *GoKms) Encrypt(
+	c ifctx.ServiceContext,
+	plaintext []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.CanEncrypt(cipher) {
+		return nil, fmt.Errorf("%w: key: %s can not encrypt using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), cipher)
+	}
+
+	if kp, ok := k.(ifcrypto.KeyPair); ok {
+		return km.cipher.Encrypt(c, plaintext, kp.GetPublic(), cipher, tags...)
+	}
+
+	return km.cipher.Encrypt(c, plaintext, k, cipher, tags...)
+}
+
+// Decrypt implements the `ifcrypto.Cipherable` interface.
+//
+// The _key_ is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+func (km *GoKms) Decrypt(
+	c ifctx.ServiceContext,
+	encrypted []byte,
+	key ifcrypto.Key,
+	cipher ifcrypto.Chipher,
+	tags ...coremodel.Meta,
+) ([]byte, error) {
+
+	k, err := km.usableKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.CanDecrypt(cipher) {
+		return nil, fmt.Errorf("%w: key: %s can not decrypt using: %s", ifkms.ErrInvalidKeyUsage, k.GetID(), cipher)
+	}
+
+	plaintext, err := km.cipher.Decrypt(c, encrypted, k, cipher, tags...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ifkms.ErrInvalidCiphertext, err.Error())
+	}
+
+	return plaintext, nil
+}
+
+// DeriveSharedSecret implements the `ifcrypto.KeyAgreement` interface.
+//
+// The _private_ key is resolved by its `ifcrypto.Key.GetID`, hence it may be a handle or a alias.
+// The _peer_ is a in memory public key, e.g. the `GoKmsKey.GetPublic` of another key.
+func (km *GoKms) DeriveSharedSecret(
+	c ifctx.ServiceContext,
+	private ifcrypto.Key,
+	peer ifcrypto.PublicKey,
+	meta ...coremodel.Meta,
+) ([]byte, error) {
+
+	k, err := km.usableKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return km.agreement.DeriveSharedSecret(c, k, peer, meta...)
+}
+
+// usableKey resolves the _key_ and ensures that it is in `ifkms.KeyStateEnabled`.
+func (km *GoKms) usableKey(key ifcrypto.Key) (ifcrypto.Key, error) {
+
+	if key == nil {
+		return nil, fmt.Errorf("must specify a key")
+	}
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entry, err := km.lookup(key.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	switch entry.state {
+	case ifkms.KeyStateEnabled:
+		return entry.key, nil
+	case ifkms.KeyStateDisabled:
+		return nil, fmt.Errorf("%w: key: %s", ifkms.ErrKeyDisabled, entry.key.GetID())
+	}
+
+	return nil, fmt.Errorf("%w: key: %s is %s", ifkms.ErrInvalidKeyState, entry.key.GetID(), entry.state)
+}
+
+// setState sets the _state_ of the key, it may not be pending deletion.
+func (km *GoKms) setState(keyID string, state ifkms.KeyState) error {
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, err := km.lookup(keyID)
+	if err != nil {
+		return err
+	}
+
+	if entry.state == ifkms.KeyStatePendingDeletion {
+
+		return fmt.Errorf(
+			"%w: key: %s is pending deletion", ifkms.ErrInvalidKeyState, entry.key.GetID(),
+		)
+
+	}
+
+	entry.state = state
+	return nil
+}
+
+// lookup resolves the _keyID_, that may be a alias, to a key entry.
+//
+// Keys that are past their deletion date are treated as not found. The caller
+// must hold the lock.
+func (km *GoKms) lookup(keyID string) (*keyEntry, error) {
+
+	id := keyID
+
+	if strings.HasPrefix(keyID, aliasPrefix) {
+
+		target, ok := km.aliases[keyID]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: alias: %s", ifkms.ErrKeyNotFound, keyID)
+		}
+
+		id = target
+
+	}
+
+	entry, ok := km.keys[id]
+
+	if !ok || km.isDeleted(entry) {
+		return nil, fmt.Errorf("%w: key: %s", ifkms.ErrKeyNotFound, keyID)
+	}
+
+	return entry, nil
+}
+
+// isDeleted returns `true` if the _entry_ is pending deletion and the deletion date has passed.
+func (km *GoKms) isDeleted(entry *keyEntry) bool {
+
+	return entry.state == ifkms.KeyStatePendingDeletion &&
+		!km.now().Before(entry.deletionDate)
+
+}
+
+// purge removes all keys, and their aliases, that are past their deletion date.
+//
+// The caller must hold the write lock.
+func (km *GoKms) purge() {
+
+	for id, entry := range km.keys {
+
+		if !km.isDeleted(entry) {
+			continue
+		}
+
+		delete(km.keys, id)
+
+		for alias, target := range km.aliases {
+
+			if target == id {
+				delete(km.aliases, alias)
+			}
+
+		}
+
+	}
+
+}
+
+// keyInfo renders the _entry_ as a `ifkms.KeyInfo`. The caller must hold the lock.
+func (km *GoKms) keyInfo(entry *keyEntry) ifkms.KeyInfo {
+
+	aliases := []string{}
+
+	for alias, target := range km.aliases {
+
+		if target == entry.key.GetID() {
+			aliases = append(aliases, alias)
+		}
+
+	}
+
+	sort.Strings(aliases)
+
+	return ifkms.KeyInfo{
+		Key:          newGoKmsKey(entry.key),
+		State:        entry.state,
+		Description:  entry.description,
+		Aliases:      aliases,
+		Tags:         append([]coremodel.Tag{}, entry.tags...),
+		CreationDate: entry.creationDate,
+		DeletionDate: entry.deletionDate,
+	}
+
+}
+
+// validateAlias ensures that the _alias_ is prefixed with _"alias/"_ and has a name.
+func validateAlias(alias string) error {
+
+	if !strings.HasPrefix(alias, aliasPrefix) || len(alias) == len(aliasPrefix) {
+		return fmt.Errorf("alias must be prefixed with %s and have a name, got: %s", aliasPrefix, alias)
+	}
+
+	return nil
+}
+
+// validateUsage ensures that the _usage_ is allowed for the _keyType_.
+//
+// Asymmetric keys may either sign / verify, encrypt / decrypt or do key agreement. Only _RSA_ keys
+// may encrypt / decrypt and only _NIST_ curve and _X25519_ keys may do key agreement where _X25519_
+// may not sign / verify. Only symmetric keys may generate / verify mac.
+func validateUsage(keyType ifcrypto.KeyType, usage []ifcrypto.KeyUsage) error {
+
+	if len(usage) == 0 {
+		return fmt.Errorf("must specify at least one key usage")
+	}
+
+	signing, ciphering, mac, agreement := false, false, false, false
+
+	for _, u := range usage {
+
+		switch u {
+		case ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify:
+			signing = true
+		case ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt:
+			ciphering = true
+		case ifcrypto.KeyUsageMac:
+			mac = true
+		case ifcrypto.KeyUsageKeyAgreement:
+			agreement = true
+		default:
+			return fmt.Errorf("unsupported key usage: %s", u)
+		}
+
+	}
+
+	if mac && keyType != ifcrypto.KeyTypeSymmetric {
+		return fmt.Errorf("key type: %s may not be used to generate or verify mac", keyType)
+	}
+
+	switch keyType {
+	case ifcrypto.KeyTypeSymmetric:
+
+		if signing {
+			return fmt.Errorf("symmetric keys may not be used to sign or verify")
+		}
+
+		if mac && ciphering {
+			return fmt.Errorf("symmetric keys may either generate / verify mac or encrypt / decrypt")
+		}
+
+		if agreement {
+			return fmt.Errorf("symmetric keys may not be used for key agreement")
+		}
+
+	case ifcrypto.KeyTypeRsa:
+
+		if agreement {
+			return fmt.Errorf("key type: %s may not be used for key agreement", keyType)
+		}
+
+		if signing && ciphering {
+			return fmt.Errorf("asymmetric keys may either sign / verify or encrypt / decrypt")
+		}
+
+	case ifcrypto.KeyTypeEccNistP, ifcrypto.KeyTypeX25519:
+
+		if ciphering {
+			return fmt.Errorf("key type: %s may not be used to encrypt or decrypt", keyType)
+		}
+
+		if signing && keyType == ifcrypto.KeyTypeX25519 {
+			return fmt.Errorf("key type: %s may not be used to sign or verify", keyType)
+		}
+
+		if signing && agreement {
+			return fmt.Errorf("asymmetric keys may either sign / verify or do key agreement")
+		}
+
+	default:
+
+		if ciphering || agreement {
+			return fmt.Errorf("key type: %s may only be used to sign or verify", keyType)
+		}
+
+	}
+
+	return nil
+}
@@ -0,0 +1,472 @@
+package gokms
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/goservice/ctx"
+	"github.com/mariotoffia/goservice/interfaces/ifcrypto"
+	"github.com/mariotoffia/goservice/interfaces/ifkms"
+	"github.com/mariotoffia/goservice/managers/go/gocrypto"
+	"github.com/mariotoffia/goservice/model/coremodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ifkms.KeyManager    = &GoKms{}
+	_ ifcrypto.Signer     = &GoKms{}
+	_ ifcrypto.Verifier   = &GoKms{}
+	_ ifcrypto.Mac        = &GoKms{}
+	_ ifcrypto.Cipherable = &GoKms{}
+
+	_ ifcrypto.DataKeyGenerator = &GoKms{}
+	_ ifcrypto.KeyGenerator     = &GoKms{}
+	_ ifcrypto.KeyAgreement     = &GoKms{}
+
+	_ ifcrypto.KeyPair = &GoKmsKey{}
+)
+
+var signVerify = []ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageVerify}
+var encryptDecrypt = []ifcrypto.KeyUsage{ifcrypto.KeyUsageEncrypt, ifcrypto.KeyUsageDecrypt}
+
+func TestSignVerifyUsingAlias(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 384, signVerify, "signing", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 384, key.GetKeySize())
+
+	require.NoError(t, km.CreateAlias(c, "alias/signing", key.GetID()))
+
+	alias, err := km.GetKey(c, "alias/signing")
+	require.NoError(t, err)
+
+	signature, err := km.Sign(c, []byte("msg"), alias, ifcrypto.SignAlgorithmEcdSha384)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, []byte("msg"), signature, key, ifcrypto.SignAlgorithmEcdSha384))
+
+	err = km.Verify(c, []byte("other"), signature, key, ifcrypto.SignAlgorithmEcdSha384)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidSignature))
+
+	_, err = km.Encrypt(c, []byte("msg"), key, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyUsage))
+}
+
+func TestSignVerifyEd25519(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeEd25519, 256, signVerify, "webhooks", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ifcrypto.KeyTypeEd25519, key.GetKeyType())
+
+	signature, err := km.Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEd25519)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, []byte("msg"), signature, key, ifcrypto.SignAlgorithmEd25519))
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeEd25519, 256, encryptDecrypt, "invalid", nil)
+	assert.Error(t, err)
+
+}
+
+func TestSignVerifySecp256k1(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeEccSecgP256k1, 256, signVerify, "blockchain", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ifcrypto.KeyTypeEccSecgP256k1, key.GetKeyType())
+
+	signature, err := km.Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, km.Verify(c, []byte("msg"), signature, key, ifcrypto.SignAlgorithmEcdSha256))
+
+}
+
+func TestGenerateVerifyMac(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}, "", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, km.CreateAlias(c, "alias/hmac", key.GetID()))
+
+	alias, err := km.GetKey(c, "alias/hmac")
+	require.NoError(t, err)
+
+	msg := []byte("hello world")
+
+	mac, err := km.GenerateMac(c, msg, alias, ifcrypto.MacAlgorithmHmacSha384)
+	require.NoError(t, err)
+	assert.Len(t, mac, 48)
+
+	assert.NoError(t, km.VerifyMac(c, msg, mac, alias, ifcrypto.MacAlgorithmHmacSha384))
+
+	err = km.VerifyMac(c, []byte("tampered"), mac, alias, ifcrypto.MacAlgorithmHmacSha384)
+	assert.True(t, errors.Is(err, ifcrypto.ErrInvalidMac))
+
+	_, err = km.Encrypt(c, msg, alias, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyUsage))
+
+	require.NoError(t, km.DisableKey(c, key.GetID()))
+
+	_, err = km.GenerateMac(c, msg, alias, ifcrypto.MacAlgorithmHmacSha384)
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+
+	aes, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+
+	_, err = km.GenerateMac(c, msg, aes, ifcrypto.MacAlgorithmHmacSha256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyUsage))
+
+	_, err = km.CreateKey(
+		c, ifcrypto.KeyTypeSymmetric, 256,
+		[]ifcrypto.KeyUsage{ifcrypto.KeyUsageMac, ifcrypto.KeyUsageEncrypt}, "", nil,
+	)
+
+	assert.Error(t, err, "symmetric keys can not both mac and encrypt")
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeRsa, 2048, []ifcrypto.KeyUsage{ifcrypto.KeyUsageMac}, "", nil)
+	assert.Error(t, err, "asymmetric keys can not mac")
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	symmetric, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+
+	ec := coremodel.Meta{Name: coremodel.MetaEncryptionContext, Value: map[string]string{"a": "b"}}
+
+	encrypted, err := km.Encrypt(c, []byte("secret"), symmetric, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+
+	plaintext, err := km.Decrypt(c, encrypted, symmetric, ifcrypto.ChiperAES256, ec)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = km.Decrypt(c, encrypted, symmetric, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidCiphertext))
+
+	rsa, err := km.CreateKey(c, ifcrypto.KeyTypeRsa, 2048, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+
+	encrypted, err = km.Encrypt(c, []byte("secret"), rsa, ifcrypto.ChiperRsaOaepSha256)
+	require.NoError(t, err)
+
+	plaintext, err = km.Decrypt(c, encrypted, rsa, ifcrypto.ChiperRsaOaepSha256)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+}
+
+func TestKeyAgreementKeys(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	agreement := []ifcrypto.KeyUsage{ifcrypto.KeyUsageKeyAgreement}
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeX25519, 256, agreement, "device", nil)
+	require.NoError(t, err)
+	assert.Equal(t, agreement, key.GetKeyUsage())
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeX25519, 256, encryptDecrypt, "", nil)
+	assert.Error(t, err, "x25519 keys can not encrypt, use gocrypto.GoCipher for ECIES")
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeX25519, 256, signVerify, "", nil)
+	assert.Error(t, err, "x25519 keys can not sign")
+
+	_, err = km.CreateKey(
+		c, ifcrypto.KeyTypeEccNistP, 384,
+		[]ifcrypto.KeyUsage{ifcrypto.KeyUsageKeyAgreement, ifcrypto.KeyUsageSign}, "", nil,
+	)
+
+	assert.Error(t, err, "key agreement keys can not sign")
+
+	nist, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 384, agreement, "", nil)
+	require.NoError(t, err)
+
+	peer, err := gocrypto.NewX25519PrivateKey("peer", ifcrypto.KeyUsageKeyAgreement)
+	require.NoError(t, err)
+
+	secret, err := km.DeriveSharedSecret(c, key, peer.GetPublic())
+	require.NoError(t, err)
+
+	expected, err := gocrypto.NewKeyAgreement().DeriveSharedSecret(c, peer, key.(ifcrypto.KeyPair).GetPublic())
+	require.NoError(t, err)
+	assert.Equal(t, expected, secret)
+
+	_, err = km.DeriveSharedSecret(c, nist, peer.GetPublic())
+	assert.Error(t, err, "not on the same curve")
+
+	_, err = gocrypto.NewKeyAgreement().DeriveSharedSecret(c, key, peer.GetPublic())
+	assert.Error(t, err, "private key is not reachable outside of the kms")
+
+	require.NoError(t, km.DisableKey(c, key.GetID()))
+
+	_, err = km.DeriveSharedSecret(c, key, peer.GetPublic())
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+
+	signing, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 384, signVerify, "", nil)
+	require.NoError(t, err)
+
+	_, err = km.DeriveSharedSecret(c, signing, nist.(ifcrypto.KeyPair).GetPublic())
+	assert.Error(t, err, "signing keys may not do key agreement")
+}
+
+func TestDisabledAndDeletedKeys(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	km.now = func() time.Time { return now }
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, km.CreateAlias(c, "alias/data", key.GetID()))
+
+	require.NoError(t, km.DisableKey(c, key.GetID()))
+
+	_, err = km.Encrypt(c, []byte("msg"), key, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+
+	require.NoError(t, km.EnableKey(c, "alias/data"))
+
+	_, err = km.Encrypt(c, []byte("msg"), key, ifcrypto.ChiperAES256)
+	assert.NoError(t, err)
+
+	_, err = km.ScheduleKeyDeletion(c, key.GetID(), 3)
+	assert.Error(t, err)
+
+	deletion, err := km.ScheduleKeyDeletion(c, key.GetID(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 7), deletion)
+
+	_, err = km.Encrypt(c, []byte("msg"), key, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyState))
+
+	require.NoError(t, km.CancelKeyDeletion(c, key.GetID()))
+
+	info, err := km.DescribeKey(c, key.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, ifkms.KeyStateDisabled, info.State)
+	assert.Equal(t, []string{"alias/data"}, info.Aliases)
+
+	_, err = km.ScheduleKeyDeletion(c, key.GetID(), 7)
+	require.NoError(t, err)
+
+	now = now.AddDate(0, 0, 8)
+
+	_, err = km.GetKey(c, key.GetID())
+	assert.True(t, errors.Is(err, ifkms.ErrKeyNotFound))
+
+	keys, err := km.ListKeys(c)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = km.GetKey(c, "alias/data")
+	assert.True(t, errors.Is(err, ifkms.ErrKeyNotFound))
+}
+
+func TestGetKeyDoNotRevealKey(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 256, signVerify, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, km.CreateAlias(c, "alias/signing", key.GetID()))
+
+	stored, err := km.GetKey(c, "alias/signing")
+	require.NoError(t, err)
+	assert.Equal(t, key.GetID(), stored.GetKey())
+	assert.True(t, stored.IsRemoteKey())
+
+	signer := gocrypto.NewSigner()
+
+	_, err = signer.Sign(c, []byte("msg"), stored, ifcrypto.SignAlgorithmEcdSha256)
+	assert.Error(t, err, "private key is not reachable outside of the kms")
+
+	signature, err := km.Sign(c, []byte("msg"), stored, ifcrypto.SignAlgorithmEcdSha256)
+	require.NoError(t, err)
+
+	assert.NoError(t, signer.Verify(c, []byte("msg"), signature, stored, ifcrypto.SignAlgorithmEcdSha256))
+
+	require.NoError(t, km.DisableKey(c, key.GetID()))
+
+	_, err = km.Sign(c, []byte("msg"), stored, ifcrypto.SignAlgorithmEcdSha256)
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+
+	info, err := km.DescribeKey(c, key.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, key.GetID(), info.Key.GetKey())
+
+	symmetric, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, symmetric.GetID(), symmetric.GetKey())
+	assert.Nil(t, symmetric.(ifcrypto.KeyPair).GetPublic(), "symmetric keys has no public portion")
+
+	_, err = gocrypto.NewCipher().Encrypt(c, []byte("msg"), symmetric, ifcrypto.ChiperAES256)
+	assert.Error(t, err, "symmetric key is not reachable outside of the kms")
+
+	assert.Error(t, km.ImportKey(stored, ""), "handles may not be imported")
+}
+
+func TestCreateKeyValidation(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	_, err := km.CreateKey(c, ifcrypto.KeyTypeRsa, 1024, signVerify, "", nil)
+	assert.Error(t, err, "unsupported key size")
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 256, encryptDecrypt, "", nil)
+	assert.Error(t, err, "elliptic curve keys can not encrypt")
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeEccSecgP256k1, 256, encryptDecrypt, "", nil)
+	assert.Error(t, err, "secp256k1 keys can not encrypt")
+
+	_, err = km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 256, []ifcrypto.KeyUsage{
+		ifcrypto.KeyUsageSign, ifcrypto.KeyUsageEncrypt,
+	}, "", nil)
+	assert.Error(t, err, "elliptic curve keys can not both sign and encrypt")
+
+	_, err = km.CreateKey(
+		c, ifcrypto.KeyTypeRsa, 2048,
+		[]ifcrypto.KeyUsage{ifcrypto.KeyUsageSign, ifcrypto.KeyUsageEncrypt}, "", nil,
+	)
+
+	assert.Error(t, err, "asymmetric keys can not both sign and encrypt")
+
+	assert.Error(t, km.CreateAlias(c, "no-prefix", "id"))
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "", nil)
+	require.NoError(t, err)
+
+	assert.Error(t, km.UpdateAlias(c, "no-prefix", key.GetID()))
+	assert.Error(t, km.UpdateAlias(c, "alias/", key.GetID()))
+}
+
+func TestGenerateKeyFromSpec(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.GenerateKey(c, ifcrypto.KeySpec{
+		ID:          "rsa-oaep",
+		KeyType:     ifcrypto.KeyTypeRsa,
+		KeySize:     2048,
+		Usage:       encryptDecrypt,
+		Chiphers:    []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256},
+		Description: "oaep only",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rsa-oaep", key.GetID())
+
+	stored, err := km.GetKey(c, "rsa-oaep")
+	require.NoError(t, err)
+	assert.Equal(t, []ifcrypto.Chipher{ifcrypto.ChiperRsaOaepSha256}, stored.GetSupportedChiphers())
+
+	_, err = km.GenerateKey(c, ifcrypto.KeySpec{
+		KeyType: ifcrypto.KeyTypeEccNistP,
+		KeySize: 256,
+		Usage:   encryptDecrypt,
+	})
+	assert.Error(t, err)
+}
+
+func TestConcurrentUse(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 256, signVerify, "", nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			signature, err := km.Sign(c, []byte("msg"), key, ifcrypto.SignAlgorithmEcdSha256)
+			assert.NoError(t, err)
+			assert.NoError(t, km.Verify(c, []byte("msg"), signature, key, ifcrypto.SignAlgorithmEcdSha256))
+
+			_ = km.TagKey(c, key.GetID(), []coremodel.Tag{{Name: "owner", Value: "test"}})
+			_, _ = km.ListKeys(c)
+
+		}()
+
+	}
+
+	wg.Wait()
+
+	info, err := km.DescribeKey(c, key.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, []coremodel.Tag{{Name: "owner", Value: "test"}}, info.Tags)
+}
+
+func TestGenerateDataKeyAndEnvelope(t *testing.T) {
+
+	c := ctx.NewServiceContext(nil, nil)
+	km := NewGoKms()
+
+	key, err := km.CreateKey(c, ifcrypto.KeyTypeSymmetric, 256, encryptDecrypt, "master", nil)
+	require.NoError(t, err)
+
+	plaintext, encrypted, err := km.GenerateDataKey(c, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Len(t, plaintext, 32)
+
+	decrypted, err := km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	encrypted, err = km.GenerateDataKeyWithoutPlaintext(c, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+
+	decrypted, err = km.Decrypt(c, encrypted, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Len(t, decrypted, 32)
+
+	env := gocrypto.NewEnvelope(km, km)
+
+	blob, err := env.Encrypt(c, []byte("hello world"), key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+
+	msg, err := env.Decrypt(c, blob, key, ifcrypto.ChiperAES256)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello world"), msg)
+
+	require.NoError(t, km.DisableKey(c, key.GetID()))
+
+	_, err = env.Decrypt(c, blob, key, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrKeyDisabled))
+
+	signing, err := km.CreateKey(c, ifcrypto.KeyTypeEccNistP, 256, signVerify, "signing", nil)
+	require.NoError(t, err)
+
+	_, _, err = km.GenerateDataKey(c, signing, ifcrypto.ChiperAES256)
+	assert.True(t, errors.Is(err, ifkms.ErrInvalidKeyUsage))
+
+}
@@ -1,5 +1,7 @@
 package coremodel
 
+import "fmt"
+
 // MetaTypes represents well-known tag types.
 type MetaTypes string
 
@@ -7,6 +9,12 @@ const (
 	// TagGrantToken represent the same mechanism as described
 	// https://docs.aws.amazon.com/kms/latest/developerguide/concepts.html#grant_token#Here.
 	MetaGrantToken MetaTypes = "grant-token"
+	// MetaEncryptionContext is a `map[string]string` of non secret data that is bound
+	// to the encrypted data. The exact same encryption context must be supplied when
+	// decrypting, see https://docs.aws.amazon.com/kms/latest/developerguide/concepts.html#encrypt_context#Here.
+	//
+	// If multiple are supplied, those are merged into a single encryption context.
+	MetaEncryptionContext MetaTypes = "encryption-context"
 )
 
 type Meta struct {
@@ -18,3 +26,50 @@ type Tag struct {
 	Name  string
 	Value interface{}
 }
+
+// GetMetaValues returns all _Value_ of those _meta_ that is named _name_.
+//
+// If none is found, an empty slice is returned.
+func GetMetaValues(name MetaTypes, meta ...Meta) []interface{} {
+
+	values := []interface{}{}
+
+	for i := range meta {
+
+		if meta[i].Name == name {
+			values = append(values, meta[i].Value)
+		}
+
+	}
+
+	return values
+}
+
+// GetEncryptionContext merges all `MetaEncryptionContext` in _meta_ into a single map.
+//
+// If no encryption context is present, `nil` is returned. If any of the values
+// is not a `map[string]string` an error is returned.
+func GetEncryptionContext(meta ...Meta) (map[string]string, error) {
+
+	var ec map[string]string
+
+	for _, v := range GetMetaValues(MetaEncryptionContext, meta...) {
+
+		m, ok := v.(map[string]string)
+
+		if !ok {
+			return nil, fmt.Errorf("encryption context must be map[string]string, got: %T", v)
+		}
+
+		if ec == nil {
+			ec = map[string]string{}
+		}
+
+		for k, v := range m {
+			ec[k] = v
+		}
+
+	}
+
+	return ec, nil
+}
@@ -0,0 +1,98 @@
+package cryptoutils
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+// CertificatesToPEM writes the _certs_ onto _w_ as _CERTIFICATE_ PEM blocks in the order given.
+//
+// A chain is written with the leaf certificate first followed by the issuing certificates, as
+// expected by e.g. `tls.X509KeyPair`.
+func CertificatesToPEM(w io.Writer, certs ...*x509.Certificate) error {
+
+	if len(certs) == 0 {
+		return fmt.Errorf("must specify at least one certificate to write")
+	}
+
+	for _, cert := range certs {
+
+		if cert == nil {
+			return fmt.Errorf("must not specify a nil certificate")
+		}
+
+		if err := pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+// CertificateRequestToPEM writes the _csr_ onto _w_ as a _CERTIFICATE REQUEST_ PEM block.
+func CertificateRequestToPEM(w io.Writer, csr *x509.CertificateRequest) error {
+
+	if csr == nil {
+		return fmt.Errorf("must specify certificate request to write")
+	}
+
+	return pem.Encode(w, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw})
+}
+
+// PEMToCertificates parses all _CERTIFICATE_ PEM blocks in _data_, in the order they appear.
+//
+// It is an error if _data_ do not contain any certificate.
+func PEMToCertificates(data []byte) ([]*x509.Certificate, error) {
+
+	keys, err := PEMToKey("", data,
+		func(fqPath string, block *pem.Block) (key interface{}, stop bool, err error) {
+
+			key, err = x509.ParseCertificate(block.Bytes)
+			return
+
+		}, "CERTIFICATE")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no certificate found in PEM data")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(keys))
+
+	for _, key := range keys {
+		certs = append(certs, key.(*x509.Certificate))
+	}
+
+	return certs, nil
+}
+
+// PEMToCertificateRequest parses the first _CERTIFICATE REQUEST_ PEM block in _data_.
+//
+// The signature of the request is not checked, use `x509.CertificateRequest.CheckSignature`.
+func PEMToCertificateRequest(data []byte) (*x509.CertificateRequest, error) {
+
+	keys, err := PEMToKey("", data,
+		func(fqPath string, block *pem.Block) (key interface{}, stop bool, err error) {
+
+			key, err = x509.ParseCertificateRequest(block.Bytes)
+			stop = err == nil
+			return
+
+		}, "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no certificate request found in PEM data")
+	}
+
+	return keys[0].(*x509.CertificateRequest), nil
+}
@@ -18,7 +18,7 @@ func ECDSAPrivateKeyToPEM(w io.Writer, key *ecdsa.PrivateKey, public bool) error
 		return fmt.Errorf("must specify private key to write")
 	}
 
-	privateKeyBytes, err := x509.MarshalECPrivateKey(key)
+	privateKeyBytes, err := MarshalECPrivateKey(key)
 
 	if err != nil {
 		return err
@@ -35,7 +35,7 @@ func ECDSAPrivateKeyToPEM(w io.Writer, key *ecdsa.PrivateKey, public bool) error
 
 	if public {
 
-		publicKeyBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		publicKeyBytes, err := MarshalPKIXECPublicKey(&key.PublicKey)
 
 		if err != nil {
 			return err
@@ -63,7 +63,7 @@ func ECDSAPublicKeyToPEM(w io.Writer, key *ecdsa.PublicKey) error {
 		return fmt.Errorf("must specify public key to write")
 	}
 
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(key)
+	publicKeyBytes, err := MarshalPKIXECPublicKey(key)
 
 	if err != nil {
 		return err
@@ -90,14 +90,14 @@ func PEMToECDSAPrivateKey(data []byte) (key *ecdsa.PrivateKey, err error) {
 			var k interface{}
 			if strings.HasPrefix(block.Type, "EC") {
 
-				if k, err = x509.ParseECPrivateKey(block.Bytes); err == nil {
+				if k, err = ParseECPrivateKey(block.Bytes); err == nil {
 					key = k
 					stop = true
 				}
 
 			} else {
 
-				if k, err = x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+				if k, err = ParsePKCS8ECPrivateKey(block.Bytes); err == nil {
 					key = k
 					stop = true
 				}
@@ -108,7 +108,11 @@ func PEMToECDSAPrivateKey(data []byte) (key *ecdsa.PrivateKey, err error) {
 
 		}, "PRIVATE KEY", "EC PRIVATE KEY")
 
-	return keys[0].(*ecdsa.PrivateKey), err
+	if err != nil || len(keys) == 0 {
+		return nil, fmt.Errorf("no EC private key found: %v", err)
+	}
+
+	return keys[0].(*ecdsa.PrivateKey), nil
 }
 
 func PEMToECDSAPublicKey(data []byte) (key *ecdsa.PublicKey, err error) {
@@ -118,7 +122,7 @@ func PEMToECDSAPublicKey(data []byte) (key *ecdsa.PublicKey, err error) {
 		func(fqPath string, block *pem.Block) (key interface{}, stop bool, err error) {
 
 			var k interface{}
-			if k, err = x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+			if k, err = ParsePKIXECPublicKey(block.Bytes); err == nil {
 				key = k
 				stop = true
 			}
@@ -127,5 +131,98 @@ func PEMToECDSAPublicKey(data []byte) (key *ecdsa.PublicKey, err error) {
 
 		}, "PUBLIC KEY", "EC PUBLIC KEY")
 
-	return keys[0].(*ecdsa.PublicKey), err
+	if err != nil || len(keys) == 0 {
+		return nil, fmt.Errorf("no EC public key found: %v", err)
+	}
+
+	return keys[0].(*ecdsa.PublicKey), nil
+}
+
+// MarshalECPrivateKey marshals the _key_ into _SEC 1_ `ECPrivateKey` _DER_.
+//
+// In addition to the curves supported by `x509.MarshalECPrivateKey` it supports `Secp256k1`.
+func MarshalECPrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+
+	if IsSecp256k1(key.Curve) {
+		return MarshalSecp256k1PrivateKey(key)
+	}
+
+	return x509.MarshalECPrivateKey(key)
+}
+
+// MarshalPKIXECPublicKey marshals the _key_ into _SPKI_ _DER_.
+//
+// In addition to the curves supported by `x509.MarshalPKIXPublicKey` it supports `Secp256k1`.
+func MarshalPKIXECPublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+
+	if IsSecp256k1(key.Curve) {
+		return MarshalSecp256k1PublicKey(key)
+	}
+
+	return x509.MarshalPKIXPublicKey(key)
+}
+
+// ParseECPrivateKey parses a _SEC 1_ `ECPrivateKey` _DER_.
+//
+// In addition to the curves supported by `x509.ParseECPrivateKey` it supports `Secp256k1`.
+func ParseECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+
+	key, err := x509.ParseECPrivateKey(der)
+	if err == nil {
+		return key, nil
+	}
+
+	if secp, serr := ParseSecp256k1PrivateKey(der); serr == nil {
+		return secp, nil
+	}
+
+	return nil, err
+}
+
+// ParsePKCS8ECPrivateKey parses a _PKCS#8_ _DER_ that must contain a _ECDSA_ key.
+//
+// In addition to the curves supported by `x509.ParsePKCS8PrivateKey` it supports `Secp256k1`.
+func ParsePKCS8ECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+
+	if err != nil {
+
+		if secp, serr := ParseSecp256k1PKCS8PrivateKey(der); serr == nil {
+			return secp, nil
+		}
+
+		return nil, err
+
+	}
+
+	if ecdsakey, ok := key.(*ecdsa.PrivateKey); ok {
+		return ecdsakey, nil
+	}
+
+	return nil, fmt.Errorf("not a *ecdsa.PrivateKey: %T", key)
+}
+
+// ParsePKIXECPublicKey parses a _SPKI_ _DER_ that must contain a _ECDSA_ key.
+//
+// In addition to the curves supported by `x509.ParsePKIXPublicKey` it supports `Secp256k1`.
+func ParsePKIXECPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+
+	key, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+
+		if secp, serr := ParseSecp256k1PublicKey(der); serr == nil {
+			return secp, nil
+		}
+
+		return nil, err
+
+	}
+
+	if ecdsakey, ok := key.(*ecdsa.PublicKey); ok {
+		return ecdsakey, nil
+	}
+
+	return nil, fmt.Errorf("not a *ecdsa.PublicKey: %T", key)
 }
@@ -0,0 +1,124 @@
+package cryptoutils
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+// Ed25519PrivateKeyToPEM writes the private key onto _w_ using the _PKCS#8_ PEM format.
+//
+// If _public_ is set to `true`, it will include public as well (_SPKI_).
+func Ed25519PrivateKeyToPEM(w io.Writer, key ed25519.PrivateKey, public bool) error {
+
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("must specify private key to write")
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	privateKeyBlock := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privateKeyBytes,
+	}
+
+	if err := pem.Encode(w, privateKeyBlock); err != nil {
+		return err
+	}
+
+	if public {
+		return Ed25519PublicKeyToPEM(w, key.Public().(ed25519.PublicKey))
+	}
+
+	return nil
+
+}
+
+// Ed25519PublicKeyToPEM writes the public key onto the _w_ `io.Writer` using the _SPKI_ PEM format.
+func Ed25519PublicKeyToPEM(w io.Writer, key ed25519.PublicKey) error {
+
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("must specify public key to write")
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	publicKeyBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	}
+
+	return pem.Encode(w, publicKeyBlock)
+}
+
+// PEMToEd25519PrivateKey parses the first _PKCS#8_ encoded _Ed25519_ private key in _data_.
+func PEMToEd25519PrivateKey(data []byte) (ed25519.PrivateKey, error) {
+
+	keys, err := PEMToKey("", data,
+		func(fqPath string, block *pem.Block) (key interface{}, stop bool, err error) {
+
+			var k interface{}
+			if k, err = x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+				key = k
+				stop = true
+			}
+
+			return
+
+		}, "PRIVATE KEY")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no private key found")
+	}
+
+	if key, ok := keys[0].(ed25519.PrivateKey); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("not a ed25519.PrivateKey: %T", keys[0])
+}
+
+// PEMToEd25519PublicKey parses the first _SPKI_ encoded _Ed25519_ public key in _data_.
+func PEMToEd25519PublicKey(data []byte) (ed25519.PublicKey, error) {
+
+	keys, err := PEMToKey("", data,
+		func(fqPath string, block *pem.Block) (key interface{}, stop bool, err error) {
+
+			var k interface{}
+			if k, err = x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+				key = k
+				stop = true
+			}
+
+			return
+
+		}, "PUBLIC KEY")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public key found")
+	}
+
+	if key, ok := keys[0].(ed25519.PublicKey); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("not a ed25519.PublicKey: %T", keys[0])
+}
@@ -0,0 +1,236 @@
+package cryptoutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+var (
+	// OIDNamedCurveSecp256k1 is the _SEC 2_ object identifier of the _secp256k1_ curve.
+	OIDNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	// oidPublicKeyECDSA is the `id-ecPublicKey` object identifier from _RFC 5480_.
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// Secp256k1 returns the `elliptic.Curve` of the _SEC 2_ _secp256k1_ curve.
+//
+// The curve is used to represent _secp256k1_ keys as `*ecdsa.PrivateKey` and `*ecdsa.PublicKey`,
+// it is not supported by `crypto/x509`, use the _secp256k1_ functions in this package to marshal
+// and parse keys.
+//
+// NOTE: The `elliptic.Curve` arithmetic is not constant time, never use the curve with `crypto/ecdsa`
+// to generate keys or sign. Use the `github.com/decred/dcrd/dcrec/secp256k1/v4` packages instead.
+func Secp256k1() elliptic.Curve {
+	return secp256k1.S256()
+}
+
+// IsSecp256k1 returns `true` if the _curve_ is the `Secp256k1` curve.
+func IsSecp256k1(curve elliptic.Curve) bool {
+	return curve != nil && curve.Params().Name == "secp256k1"
+}
+
+// ecPrivateKey is the _SEC 1_ `ECPrivateKey` structure.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the _PKCS#8_ `PrivateKeyInfo` structure.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is the _SPKI_ `SubjectPublicKeyInfo` structure.
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalSecp256k1PrivateKey marshals the _secp256k1_ _key_ into _SEC 1_ `ECPrivateKey` _DER_.
+func MarshalSecp256k1PrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+
+	if key == nil || !IsSecp256k1(key.Curve) {
+		return nil, fmt.Errorf("not a secp256k1 private key")
+	}
+
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    key.D.FillBytes(make([]byte, 32)),
+		NamedCurveOID: OIDNamedCurveSecp256k1,
+		PublicKey:     asn1.BitString{Bytes: marshalSecp256k1Point(&key.PublicKey), BitLength: 65 * 8},
+	})
+
+}
+
+// MarshalSecp256k1PKCS8PrivateKey marshals the _secp256k1_ _key_ into _PKCS#8_ _DER_.
+func MarshalSecp256k1PKCS8PrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+
+	if key == nil || !IsSecp256k1(key.Curve) {
+		return nil, fmt.Errorf("not a secp256k1 private key")
+	}
+
+	params, err := asn1.Marshal(OIDNamedCurveSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	// The curve is in the algorithm identifier, hence omitted in the ECPrivateKey
+	sec1, err := asn1.Marshal(ecPrivateKey{
+		Version:    1,
+		PrivateKey: key.D.FillBytes(make([]byte, 32)),
+		PublicKey:  asn1.BitString{Bytes: marshalSecp256k1Point(&key.PublicKey), BitLength: 65 * 8},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkcs8{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PrivateKey: sec1,
+	})
+
+}
+
+// MarshalSecp256k1PublicKey marshals the _secp256k1_ _key_ into _SPKI_ _DER_.
+func MarshalSecp256k1PublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+
+	if key == nil || !IsSecp256k1(key.Curve) {
+		return nil, fmt.Errorf("not a secp256k1 public key")
+	}
+
+	params, err := asn1.Marshal(OIDNamedCurveSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(publicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: marshalSecp256k1Point(key), BitLength: 65 * 8},
+	})
+
+}
+
+// ParseSecp256k1PrivateKey parses a _SEC 1_ `ECPrivateKey` _DER_ of a _secp256k1_ key.
+//
+// If the curve is omitted, it is assumed to be _secp256k1_.
+func ParseSecp256k1PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+
+	var key ecPrivateKey
+
+	if rest, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data after EC private key")
+	}
+
+	if len(key.NamedCurveOID) > 0 && !key.NamedCurveOID.Equal(OIDNamedCurveSecp256k1) {
+		return nil, fmt.Errorf("not a secp256k1 private key, curve: %s", key.NamedCurveOID)
+	}
+
+	d := new(big.Int).SetBytes(key.PrivateKey)
+
+	if d.Sign() == 0 || d.Cmp(Secp256k1().Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid secp256k1 private key")
+	}
+
+	priv := secp256k1.PrivKeyFromBytes(key.PrivateKey)
+	defer priv.Zero()
+
+	return priv.ToECDSA(), nil
+}
+
+// ParseSecp256k1PKCS8PrivateKey parses a _PKCS#8_ _DER_ of a _secp256k1_ key.
+func ParseSecp256k1PKCS8PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+
+	var key pkcs8
+
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+
+	if err := checkSecp256k1Algorithm(key.Algo); err != nil {
+		return nil, err
+	}
+
+	return ParseSecp256k1PrivateKey(key.PrivateKey)
+}
+
+// ParseSecp256k1PublicKey parses a _SPKI_ _DER_ of a _secp256k1_ key.
+func ParseSecp256k1PublicKey(der []byte) (*ecdsa.PublicKey, error) {
+
+	var info publicKeyInfo
+
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	if err := checkSecp256k1Algorithm(info.Algorithm); err != nil {
+		return nil, err
+	}
+
+	return unmarshalSecp256k1Point(info.PublicKey.RightAlign())
+}
+
+// checkSecp256k1Algorithm ensures that the _algo_ is a _ECDSA_ key on the _secp256k1_ curve.
+func checkSecp256k1Algorithm(algo pkix.AlgorithmIdentifier) error {
+
+	if !algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return fmt.Errorf("not a EC key, algorithm: %s", algo.Algorithm)
+	}
+
+	var curve asn1.ObjectIdentifier
+
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return err
+	}
+
+	if !curve.Equal(OIDNamedCurveSecp256k1) {
+		return fmt.Errorf("not a secp256k1 key, curve: %s", curve)
+	}
+
+	return nil
+}
+
+// marshalSecp256k1Point marshals the public point in uncompressed form.
+func marshalSecp256k1Point(key *ecdsa.PublicKey) []byte {
+
+	point := make([]byte, 65)
+	point[0] = 4
+
+	key.X.FillBytes(point[1:33])
+	key.Y.FillBytes(point[33:])
+
+	return point
+}
+
+// unmarshalSecp256k1Point unmarshals a uncompressed point and ensures that it is on the curve.
+func unmarshalSecp256k1Point(point []byte) (*ecdsa.PublicKey, error) {
+
+	if len(point) != 65 || point[0] != 4 {
+		return nil, fmt.Errorf("only uncompressed secp256k1 points are supported")
+	}
+
+	key, err := secp256k1.ParsePubKey(point)
+	if err != nil {
+		return nil, fmt.Errorf("point is not on the secp256k1 curve: %w", err)
+	}
+
+	return key.ToECDSA(), nil
+}
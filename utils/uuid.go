@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewUUID generates a random, version 4, _UUID_ using `rand.Reader` as entropy.
+//
+// It panics if not possible to read from `rand.Reader`.
+func NewUUID() string {
+
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %s", err.Error()))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant is 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}